@@ -10,6 +10,7 @@ import (
 	"sort"
 	"strings"
 	"text/template"
+	"time"
 )
 
 //go:embed config.yml.tpl
@@ -40,6 +41,13 @@ type Config struct {
 	DebugLogFilePath       string
 	DisableInternalMetrics bool
 	ServerOnly             bool
+	MaxSpans               int
+	MaxMetrics             int
+	MaxLogs                int
+	MaxMemory              string
+	Retention              time.Duration
+	DataDir                string
+	SpanMetrics            bool
 }
 
 func NewConfig(
@@ -53,6 +61,13 @@ func NewConfig(
 	debugLogFilePath string,
 	disableInternalMetrics bool,
 	serverOnly bool,
+	maxSpans int,
+	maxMetrics int,
+	maxLogs int,
+	maxMemory string,
+	retention time.Duration,
+	dataDir string,
+	spanMetrics bool,
 ) (*Config, error) {
 	cfg := &Config{
 		OTLPHost:               otlpHost,
@@ -65,6 +80,13 @@ func NewConfig(
 		DebugLogFilePath:       debugLogFilePath,
 		DisableInternalMetrics: disableInternalMetrics,
 		ServerOnly:             serverOnly,
+		MaxSpans:               maxSpans,
+		MaxMetrics:             maxMetrics,
+		MaxLogs:                maxLogs,
+		MaxMemory:              maxMemory,
+		Retention:              retention,
+		DataDir:                dataDir,
+		SpanMetrics:            spanMetrics,
 	}
 
 	if err := cfg.validate(); err != nil {
@@ -132,6 +154,8 @@ func (c *Config) RenderYml() (string, error) {
 	if err != nil {
 		return "", err
 	}
+	// render the duration in a human readable format instead of nanoseconds
+	params["Retention"] = c.Retention.String()
 
 	var buf strings.Builder
 	if err := tpl.Execute(&buf, params); err != nil {
@@ -163,5 +187,13 @@ func (c *Config) validate() error {
 		return errors.New("the initial data JSON file does not exist")
 	}
 
+	if c.MaxSpans < 0 || c.MaxMetrics < 0 || c.MaxLogs < 0 {
+		return errors.New("the max number of spans, metrics and logs must not be negative")
+	}
+
+	if c.Retention < 0 {
+		return errors.New("the retention must not be negative")
+	}
+
 	return nil
 }
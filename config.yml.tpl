@@ -54,6 +54,13 @@ exporters:
     debug_log_file_path: '{{ .DebugLogFilePath }}'
     http_port: {{ .HTTPAPIPort }}
     server_only: {{ if .ServerOnly }}true{{else}}false{{end}}
+    max_spans: {{ .MaxSpans }}
+    max_metrics: {{ .MaxMetrics }}
+    max_logs: {{ .MaxLogs }}
+    max_memory: '{{ .MaxMemory }}'
+    retention: {{ .Retention }}
+    data_dir: '{{ .DataDir }}'
+    span_metrics: {{ if .SpanMetrics }}true{{else}}false{{end}}
 service:
 {{- if .DisableInternalMetrics}}
   telemetry:
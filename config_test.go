@@ -3,6 +3,7 @@ package main
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -119,6 +120,13 @@ func TestConfigRenderYml(t *testing.T) {
 		},
 		DebugLogFilePath:       "/tmp/otel-tui.log",
 		DisableInternalMetrics: true,
+		MaxSpans:               2000,
+		MaxMetrics:             4000,
+		MaxLogs:                5000,
+		MaxMemory:              "512MiB",
+		Retention:              15 * time.Minute,
+		DataDir:                "/tmp/otel-tui",
+		SpanMetrics:            true,
 	}
 	want := `yaml:
 receivers:
@@ -195,6 +203,13 @@ exporters:
     debug_log_file_path: '/tmp/otel-tui.log'
     http_port: 0
     server_only: false
+    max_spans: 2000
+    max_metrics: 4000
+    max_logs: 5000
+    max_memory: '512MiB'
+    retention: 15m0s
+    data_dir: '/tmp/otel-tui'
+    span_metrics: true
 service:
   telemetry:
     metrics:
@@ -257,6 +272,13 @@ exporters:
     debug_log_file_path: ''
     http_port: 0
     server_only: false
+    max_spans: 0
+    max_metrics: 0
+    max_logs: 0
+    max_memory: ''
+    retention: 0s
+    data_dir: ''
+    span_metrics: false
 service:
   pipelines:
     traces:
@@ -318,6 +340,20 @@ func TestValidate(t *testing.T) {
 			},
 			want: errors.New("the initial data JSON file does not exist"),
 		},
+		{
+			name: "NG_Negative_Max_Count",
+			cfg: &Config{
+				MaxSpans: -1,
+			},
+			want: errors.New("the max number of spans, metrics and logs must not be negative"),
+		},
+		{
+			name: "NG_Negative_Retention",
+			cfg: &Config{
+				Retention: -time.Minute,
+			},
+			want: errors.New("the retention must not be negative"),
+		},
 	}
 
 	for _, tt := range tests {
@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/spf13/cobra"
 	"go.opentelemetry.io/collector/component"
@@ -54,6 +55,11 @@ func newCommand(params otelcol.CollectorSettings) *cobra.Command {
 		debugLogFlag                                bool
 		disableInternalMetricsFlag                  bool
 		serverOnlyFlag                              bool
+		maxSpansFlag, maxMetricsFlag, maxLogsFlag   int
+		maxMemoryFlag                               string
+		retentionFlag                               time.Duration
+		dataDirFlag                                 string
+		spanMetricsFlag                             bool
 	)
 
 	rootCmd := &cobra.Command{
@@ -77,6 +83,13 @@ func newCommand(params otelcol.CollectorSettings) *cobra.Command {
 				logPath,
 				disableInternalMetricsFlag,
 				serverOnlyFlag,
+				maxSpansFlag,
+				maxMetricsFlag,
+				maxLogsFlag,
+				maxMemoryFlag,
+				retentionFlag,
+				dataDirFlag,
+				spanMetricsFlag,
 			)
 
 			if err != nil {
@@ -115,6 +128,13 @@ func newCommand(params otelcol.CollectorSettings) *cobra.Command {
 	rootCmd.Flags().BoolVar(&debugLogFlag, "debug-log", false, "Enable debug log output to file (/tmp/otel-tui.log)")
 	rootCmd.Flags().BoolVar(&disableInternalMetricsFlag, "disable-internal-metrics", false, "Disable the collector's internal metrics telemetry reporting")
 	rootCmd.Flags().BoolVar(&serverOnlyFlag, "server-only", false, "Run in headless mode without TUI (HTTP API only)")
+	rootCmd.Flags().IntVar(&maxSpansFlag, "max-spans", 1000, "The max number of service root spans kept in memory")
+	rootCmd.Flags().IntVar(&maxMetricsFlag, "max-metrics", 3000, "The max number of metrics kept in memory")
+	rootCmd.Flags().IntVar(&maxLogsFlag, "max-logs", 1000, "The max number of logs kept in memory")
+	rootCmd.Flags().StringVar(&maxMemoryFlag, "max-memory", "", "The memory budget of the telemetry data kept in memory, evicting the oldest data when exceeded (e.g. 512MiB)")
+	rootCmd.Flags().DurationVar(&retentionFlag, "retention", 0, "The time window of the telemetry data kept in memory, evicting the older data (e.g. 15m, 0 to disable)")
+	rootCmd.Flags().StringVar(&dataDirFlag, "data-dir", "", "The directory to persist the telemetry data kept in memory and restore it on startup (disabled if empty)")
+	rootCmd.Flags().BoolVar(&spanMetricsFlag, "span-metrics", false, "Derive the request count and duration histogram metrics from the spans by service, span name, span kind and status code")
 	return rootCmd
 }
 
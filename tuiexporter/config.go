@@ -1,20 +1,75 @@
 package tuiexporter
 
-import "go.opentelemetry.io/collector/component"
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+)
 
 // Config defines configuration for TUI exporter.
 type Config struct {
-	FromJSONFile     bool   `mapstructure:"from_json_file"`
-	DebugLogFilePath string `mapstructure:"debug_log_file_path"`
-	HTTPPort         int    `mapstructure:"http_port"` // Port for HTTP API server (0 = disabled)
-	ServerOnly       bool   `mapstructure:"server_only"` // Run in headless mode without TUI
+	FromJSONFile     bool          `mapstructure:"from_json_file"`
+	DebugLogFilePath string        `mapstructure:"debug_log_file_path"`
+	HTTPPort         int           `mapstructure:"http_port"`    // Port for HTTP API server (0 = disabled)
+	ServerOnly       bool          `mapstructure:"server_only"`  // Run in headless mode without TUI
+	MaxSpans         int           `mapstructure:"max_spans"`    // Max number of service root spans (0 = default)
+	MaxMetrics       int           `mapstructure:"max_metrics"`  // Max number of metrics (0 = default)
+	MaxLogs          int           `mapstructure:"max_logs"`     // Max number of logs (0 = default)
+	MaxMemory        string        `mapstructure:"max_memory"`   // Memory budget of the stored data such as 512MiB (empty = no limit)
+	Retention        time.Duration `mapstructure:"retention"`    // Time window of the stored data (0 = no limit)
+	DataDir          string        `mapstructure:"data_dir"`     // Directory to persist the stored data (empty = disabled)
+	SpanMetrics      bool          `mapstructure:"span_metrics"` // Derive the RED metrics from the spans
 }
 
 var _ component.Config = (*Config)(nil)
 
 // Validate checks if the exporter configuration is valid
-/* This is not used because the exporter does not have any configuration
 func (cfg *Config) Validate() error {
+	if _, err := parseByteSize(cfg.MaxMemory); err != nil {
+		return fmt.Errorf("invalid max_memory: %w", err)
+	}
+	if cfg.Retention < 0 {
+		return errors.New("retention must not be negative")
+	}
 	return nil
 }
-*/
+
+var byteSizeUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"kb":  1000,
+	"mb":  1000 * 1000,
+	"gb":  1000 * 1000 * 1000,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+}
+
+// parseByteSize parses a size such as 512MiB, 1GB or 1048576 into bytes.
+// An empty string is parsed as zero.
+func parseByteSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i == -1 {
+		i = len(s)
+	}
+	num, unit := s[:i], strings.ToLower(strings.TrimSpace(s[i:]))
+	multiplier, ok := byteSizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q in %q", s[i:], s)
+	}
+	value, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(value * float64(multiplier)), nil
+}
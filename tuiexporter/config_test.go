@@ -0,0 +1,85 @@
+package tuiexporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *Config
+		wantErr bool
+	}{
+		{
+			name:    "OK_Default",
+			config:  &Config{},
+			wantErr: false,
+		},
+		{
+			name:    "OK_MaxMemory",
+			config:  &Config{MaxMemory: "512MiB"},
+			wantErr: false,
+		},
+		{
+			name:    "NG_MaxMemory",
+			config:  &Config{MaxMemory: "512XB"},
+			wantErr: true,
+		},
+		{
+			name:    "OK_Retention",
+			config:  &Config{Retention: 15 * time.Minute},
+			wantErr: false,
+		},
+		{
+			name:    "NG_Retention",
+			config:  &Config{Retention: -time.Minute},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{input: "", want: 0},
+		{input: "1024", want: 1024},
+		{input: "100B", want: 100},
+		{input: "1KB", want: 1000},
+		{input: "1KiB", want: 1024},
+		{input: "512MiB", want: 512 * 1024 * 1024},
+		{input: "1.5 GiB", want: 1536 * 1024 * 1024},
+		{input: "2gb", want: 2 * 1000 * 1000 * 1000},
+		{input: "MiB", wantErr: true},
+		{input: "-1MiB", wantErr: true},
+		{input: "10TB", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseByteSize(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
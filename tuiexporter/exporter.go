@@ -3,6 +3,8 @@ package tuiexporter
 import (
 	"context"
 	"fmt"
+	"log"
+	"net"
 	"net/http"
 	"time"
 
@@ -17,10 +19,13 @@ import (
 )
 
 type tuiExporter struct {
-	app        *tui.TUIApp
-	httpServer *http.Server
-	httpPort   int
-	serverOnly bool
+	app           *tui.TUIApp
+	httpServer    *http.Server
+	httpPort      int
+	serverOnly    bool
+	cancelSweeper context.CancelFunc
+	cancelStreams context.CancelFunc
+	persister     *telemetry.Persister
 }
 
 func newTuiExporter(config *Config) (*tuiExporter, error) {
@@ -31,17 +36,53 @@ func newTuiExporter(config *Config) (*tuiExporter, error) {
 		initialInterval = 1 * time.Second
 	}
 
+	maxMemory, err := parseByteSize(config.MaxMemory)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create store
-	store := telemetry.NewStore(clockwork.NewRealClock())
+	store := telemetry.NewStoreWithConfig(clockwork.NewRealClock(), telemetry.StoreConfig{
+		MaxServiceSpanCount: config.MaxSpans,
+		MaxMetricCount:      config.MaxMetrics,
+		MaxLogCount:         config.MaxLogs,
+		MaxMemoryBytes:      maxMemory,
+		Retention:           config.Retention,
+		SpanMetrics:         config.SpanMetrics,
+	})
 
 	exporter := &tuiExporter{
 		httpPort:   config.HTTPPort,
 		serverOnly: config.ServerOnly,
 	}
 
+	// Restore the persisted data before receiving new data
+	if config.DataDir != "" {
+		persister, err := telemetry.NewPersister(config.DataDir, store)
+		if err != nil {
+			return nil, err
+		}
+		if err := persister.Restore(); err != nil {
+			return nil, fmt.Errorf("failed to restore the data in %s: %w", config.DataDir, err)
+		}
+		exporter.persister = persister
+	}
+
+	// Load the filter presets. If the user config dir is unknown or the presets cannot be
+	// loaded, keep them only in memory.
+	presetPath, err := telemetry.DefaultPresetPath()
+	if err != nil {
+		presetPath = ""
+	}
+	presets, err := telemetry.NewPresetStore(presetPath)
+	if err != nil {
+		log.Printf("Failed to load the presets, they are kept only in memory: %v", err)
+		presets, _ = telemetry.NewPresetStore("")
+	}
+
 	// Only create TUI app if not in server-only mode
 	if !config.ServerOnly {
-		app, err := tui.NewTUIApp(store, initialInterval, config.DebugLogFilePath)
+		app, err := tui.NewTUIApp(store, presets, initialInterval, config.DebugLogFilePath)
 		if err != nil {
 			return nil, err
 		}
@@ -51,7 +92,7 @@ func newTuiExporter(config *Config) (*tuiExporter, error) {
 		exporter.app = &tui.TUIApp{}
 		// Inject the store directly (we'll need to add a method for this)
 		// For now, we'll create the app anyway but won't run it
-		app, err := tui.NewTUIApp(store, initialInterval, config.DebugLogFilePath)
+		app, err := tui.NewTUIApp(store, presets, initialInterval, config.DebugLogFilePath)
 		if err != nil {
 			return nil, err
 		}
@@ -61,10 +102,16 @@ func newTuiExporter(config *Config) (*tuiExporter, error) {
 
 	// Setup HTTP server if port is configured
 	if config.HTTPPort > 0 {
-		httpHandler := httpserver.NewServer(exporter.app.Store())
+		httpHandler := httpserver.NewServer(exporter.app.Store(), presets)
+		// The live streams are closed with this context on shutdown as they never end by themselves
+		streamCtx, cancelStreams := context.WithCancel(context.Background())
+		exporter.cancelStreams = cancelStreams
 		exporter.httpServer = &http.Server{
 			Addr:    fmt.Sprintf(":%d", config.HTTPPort),
 			Handler: httpHandler,
+			BaseContext: func(net.Listener) context.Context {
+				return streamCtx
+			},
 		}
 	}
 
@@ -72,18 +119,27 @@ func newTuiExporter(config *Config) (*tuiExporter, error) {
 }
 
 func (e *tuiExporter) pushTraces(_ context.Context, traces ptrace.Traces) error {
+	if e.persister != nil {
+		return e.persister.AddSpan(&traces)
+	}
 	e.app.Store().AddSpan(&traces)
 
 	return nil
 }
 
 func (e *tuiExporter) pushMetrics(_ context.Context, metrics pmetric.Metrics) error {
+	if e.persister != nil {
+		return e.persister.AddMetric(&metrics)
+	}
 	e.app.Store().AddMetric(&metrics)
 
 	return nil
 }
 
 func (e *tuiExporter) pushLogs(_ context.Context, logs plog.Logs) error {
+	if e.persister != nil {
+		return e.persister.AddLog(&logs)
+	}
 	e.app.Store().AddLog(&logs)
 
 	return nil
@@ -101,6 +157,11 @@ func (e *tuiExporter) Start(_ context.Context, _ component.Host) error {
 		}()
 	}
 
+	// Start evicting the data out of the retention window
+	sweeperCtx, cancel := context.WithCancel(context.Background())
+	e.cancelSweeper = cancel
+	go e.app.Store().RunRetentionSweeper(sweeperCtx)
+
 	// Start HTTP server if configured
 	if e.httpServer != nil {
 		go func() {
@@ -121,8 +182,23 @@ func (e *tuiExporter) Start(_ context.Context, _ component.Host) error {
 
 // Shutdown stops the TUI exporter
 func (e *tuiExporter) Shutdown(ctx context.Context) error {
+	if e.cancelSweeper != nil {
+		e.cancelSweeper()
+	}
+
+	// Take a snapshot to compact the persisted data
+	if e.persister != nil {
+		if err := e.persister.Snapshot(); err != nil {
+			fmt.Printf("error taking a snapshot of the data: %s\n", err)
+		}
+		if err := e.persister.Close(); err != nil {
+			fmt.Printf("error closing the data directory: %s\n", err)
+		}
+	}
+
 	// Stop HTTP server if running
 	if e.httpServer != nil {
+		e.cancelStreams()
 		if err := e.httpServer.Shutdown(ctx); err != nil {
 			fmt.Printf("error shutting down http server: %s\n", err)
 		}
@@ -2,16 +2,34 @@ package tuiexporter
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/telemetry"
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/ptrace"
 )
 
+// useTempConfigDir points the user config dir to a temporary dir so that the tests do not
+// read or write the presets of the user
+func useTempConfigDir(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	return dir
+}
+
 func TestNewTuiExporter(t *testing.T) {
+	useTempConfigDir(t)
+
 	tests := []struct {
 		name                string
 		config              *Config
@@ -41,6 +59,8 @@ func TestNewTuiExporter(t *testing.T) {
 }
 
 func TestPushTraces(t *testing.T) {
+	useTempConfigDir(t)
+
 	exporter, err := newTuiExporter(&Config{})
 	assert.NoError(t, err)
 	traces := ptrace.NewTraces()
@@ -50,6 +70,8 @@ func TestPushTraces(t *testing.T) {
 }
 
 func TestPushMetrics(t *testing.T) {
+	useTempConfigDir(t)
+
 	exporter, err := newTuiExporter(&Config{})
 	assert.NoError(t, err)
 	metrics := pmetric.NewMetrics()
@@ -59,6 +81,8 @@ func TestPushMetrics(t *testing.T) {
 }
 
 func TestPushLogs(t *testing.T) {
+	useTempConfigDir(t)
+
 	exporter, err := newTuiExporter(&Config{})
 	assert.NoError(t, err)
 	logs := plog.NewLogs()
@@ -68,6 +92,8 @@ func TestPushLogs(t *testing.T) {
 }
 
 func TestStartAndShutdown(t *testing.T) {
+	useTempConfigDir(t)
+
 	exporter, err := newTuiExporter(&Config{})
 	assert.NoError(t, err)
 
@@ -79,3 +105,68 @@ func TestStartAndShutdown(t *testing.T) {
 	err = exporter.Shutdown(context.Background())
 	assert.NoError(t, err)
 }
+
+func TestPushWithDataDir(t *testing.T) {
+	useTempConfigDir(t)
+
+	dir := t.TempDir()
+
+	exporter, err := newTuiExporter(&Config{DataDir: dir})
+	assert.NoError(t, err)
+
+	traces := ptrace.NewTraces()
+	traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName("span")
+	err = exporter.pushTraces(context.Background(), traces)
+	assert.NoError(t, err)
+
+	err = exporter.Shutdown(context.Background())
+	assert.NoError(t, err)
+
+	// the data is restored by the next exporter
+	restored, err := newTuiExporter(&Config{DataDir: dir})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(*restored.app.Store().GetSvcSpans()))
+}
+
+func TestPushWithSpanMetrics(t *testing.T) {
+	useTempConfigDir(t)
+
+	dir := t.TempDir()
+
+	exporter, err := newTuiExporter(&Config{DataDir: dir, SpanMetrics: true})
+	assert.NoError(t, err)
+
+	traces := ptrace.NewTraces()
+	traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName("span")
+	err = exporter.pushTraces(context.Background(), traces)
+	assert.NoError(t, err)
+
+	// the calls and the duration
+	assert.Equal(t, 2, len(*exporter.app.Store().GetFilteredMetrics()))
+
+	err = exporter.Shutdown(context.Background())
+	assert.NoError(t, err)
+
+	// the derived metrics are not persisted but derived again from the restored spans
+	restored, err := newTuiExporter(&Config{DataDir: dir, SpanMetrics: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(*restored.app.Store().GetFilteredMetrics()))
+}
+
+func TestNewTuiExporterWithBrokenPresets(t *testing.T) {
+	dir := useTempConfigDir(t)
+	path, err := telemetry.DefaultPresetPath()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(path, dir))
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+	assert.NoError(t, os.WriteFile(path, []byte("{broken"), 0o600))
+
+	// the presets are kept only in memory instead of failing to start
+	exporter, err := newTuiExporter(&Config{})
+	assert.NoError(t, err)
+	assert.NotNil(t, exporter.app)
+
+	b, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "{broken", string(b))
+}
@@ -2,6 +2,7 @@ package httpserver
 
 import (
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -14,6 +15,9 @@ import (
 type PaginationParams struct {
 	Offset int
 	Limit  int
+	// After is the cursor (sequence number) to get only the data added after it.
+	// If it is set, the data is ordered by the sequence number and Offset is ignored.
+	After *uint64
 }
 
 // TimeRangeParams holds time range filter parameters
@@ -32,6 +36,8 @@ type TraceFilterParams struct {
 	Pagination   PaginationParams
 	SortBy       string // "time", "duration", "name"
 	SortOrder    string // "asc", "desc"
+	Query        *telemetry.Query
+	Group        string // "", "trace"
 }
 
 // LogFilterParams holds all log filtering parameters
@@ -43,6 +49,7 @@ type LogFilterParams struct {
 	TraceID       string
 	TimeRange     TimeRangeParams
 	Pagination    PaginationParams
+	Query         *telemetry.Query
 }
 
 // MetricFilterParams holds all metric filtering parameters
@@ -52,6 +59,7 @@ type MetricFilterParams struct {
 	MetricType string // "Gauge", "Sum", "Histogram", "ExponentialHistogram", "Summary"
 	TimeRange  TimeRangeParams
 	Pagination PaginationParams
+	Query      *telemetry.Query
 }
 
 // ParsePaginationParams parses pagination query parameters
@@ -76,6 +84,12 @@ func ParsePaginationParams(r *http.Request) PaginationParams {
 		}
 	}
 
+	if after := r.URL.Query().Get("after"); after != "" {
+		if val, err := strconv.ParseUint(after, 10, 64); err == nil {
+			params.After = &val
+		}
+	}
+
 	return params
 }
 
@@ -104,8 +118,22 @@ func ParseTimeRangeParams(r *http.Request) TimeRangeParams {
 	return params
 }
 
-// ParseTraceFilterParams parses all trace filter parameters
-func ParseTraceFilterParams(r *http.Request) TraceFilterParams {
+// ParseQueryParam parses the filter query (q) with the match mode (regex, ignore_case).
+// If the preset is given, its query and match mode are used unless q is specified.
+func ParseQueryParam(r *http.Request, preset *telemetry.Preset) (*telemetry.Query, error) {
+	query := r.URL.Query().Get("q")
+	mode := telemetry.MatchMode{
+		Regex:      r.URL.Query().Get("regex") == "true",
+		IgnoreCase: r.URL.Query().Get("ignore_case") == "true",
+	}
+	if preset != nil && !r.URL.Query().Has("q") {
+		query, mode = preset.Query, preset.MatchMode()
+	}
+	return telemetry.ParseQuery(query, mode)
+}
+
+// ParseTraceFilterParams parses all trace filter parameters with the optional preset
+func ParseTraceFilterParams(r *http.Request, preset *telemetry.Preset) (TraceFilterParams, error) {
 	params := TraceFilterParams{
 		Service:    r.URL.Query().Get("service"),
 		Status:     strings.ToLower(r.URL.Query().Get("status")),
@@ -113,6 +141,7 @@ func ParseTraceFilterParams(r *http.Request) TraceFilterParams {
 		Pagination: ParsePaginationParams(r),
 		SortBy:     strings.ToLower(r.URL.Query().Get("sort_by")),
 		SortOrder:  strings.ToLower(r.URL.Query().Get("sort_order")),
+		Group:      strings.ToLower(r.URL.Query().Get("group")),
 	}
 
 	// Parse duration filters
@@ -130,6 +159,20 @@ func ParseTraceFilterParams(r *http.Request) TraceFilterParams {
 		}
 	}
 
+	// Sort of the preset
+	if preset != nil && params.SortBy == "" {
+		sortType := preset.GetSortType()
+		if !sortType.IsNone() {
+			params.SortBy = "duration"
+			if params.SortOrder == "" {
+				params.SortOrder = "asc"
+				if sortType.IsDesc() {
+					params.SortOrder = "desc"
+				}
+			}
+		}
+	}
+
 	// Default sort
 	if params.SortBy == "" {
 		params.SortBy = "time"
@@ -138,11 +181,17 @@ func ParseTraceFilterParams(r *http.Request) TraceFilterParams {
 		params.SortOrder = "desc"
 	}
 
-	return params
+	query, err := ParseQueryParam(r, preset)
+	if err != nil {
+		return params, err
+	}
+	params.Query = query
+
+	return params, nil
 }
 
-// ParseLogFilterParams parses all log filter parameters
-func ParseLogFilterParams(r *http.Request) LogFilterParams {
+// ParseLogFilterParams parses all log filter parameters with the optional preset
+func ParseLogFilterParams(r *http.Request, preset *telemetry.Preset) (LogFilterParams, error) {
 	params := LogFilterParams{
 		Service:    r.URL.Query().Get("service"),
 		Severity:   strings.ToLower(r.URL.Query().Get("severity")),
@@ -157,11 +206,17 @@ func ParseLogFilterParams(r *http.Request) LogFilterParams {
 		params.MinSeverity = severityNameToNumber(minSev)
 	}
 
-	return params
+	query, err := ParseQueryParam(r, preset)
+	if err != nil {
+		return params, err
+	}
+	params.Query = query
+
+	return params, nil
 }
 
-// ParseMetricFilterParams parses all metric filter parameters
-func ParseMetricFilterParams(r *http.Request) MetricFilterParams {
+// ParseMetricFilterParams parses all metric filter parameters with the optional preset
+func ParseMetricFilterParams(r *http.Request, preset *telemetry.Preset) (MetricFilterParams, error) {
 	params := MetricFilterParams{
 		Service:    r.URL.Query().Get("service"),
 		MetricName: r.URL.Query().Get("metric"),
@@ -170,7 +225,13 @@ func ParseMetricFilterParams(r *http.Request) MetricFilterParams {
 		Pagination: ParsePaginationParams(r),
 	}
 
-	return params
+	query, err := ParseQueryParam(r, preset)
+	if err != nil {
+		return params, err
+	}
+	params.Query = query
+
+	return params, nil
 }
 
 // FilterSpans applies all filters to a slice of spans
@@ -185,12 +246,52 @@ func FilterSpans(spans []*telemetry.SpanData, params TraceFilterParams) []*telem
 	}
 
 	// Sort
-	sortSpans(filtered, params.SortBy, params.SortOrder)
+	if params.Pagination.After != nil {
+		sortBySeq(filtered, func(sd *telemetry.SpanData) uint64 { return sd.Seq })
+	} else {
+		sortSpans(filtered, params.SortBy, params.SortOrder)
+	}
 
 	// Paginate
 	return paginateSpans(filtered, params.Pagination)
 }
 
+// FilterTraceSummaries returns the summaries of the traces which have any span matching the filters.
+// The duration filters are applied to the duration of the trace instead of the spans.
+func FilterTraceSummaries(store *telemetry.Store, spans []*telemetry.SpanData, params TraceFilterParams) []telemetry.TraceSummary {
+	spanParams := params
+	spanParams.MinDuration = nil
+	spanParams.MaxDuration = nil
+
+	matched := make([]*telemetry.SpanData, 0, len(spans))
+	for _, span := range spans {
+		if matchesSpanFilters(span, spanParams) {
+			matched = append(matched, span)
+		}
+	}
+
+	filtered := []telemetry.TraceSummary{}
+	for _, summary := range store.SummarizeTraces(matched) {
+		if params.MinDuration != nil && summary.Duration < *params.MinDuration {
+			continue
+		}
+		if params.MaxDuration != nil && summary.Duration > *params.MaxDuration {
+			continue
+		}
+		filtered = append(filtered, summary)
+	}
+
+	// Sort
+	if params.Pagination.After != nil {
+		sortBySeq(filtered, func(ts telemetry.TraceSummary) uint64 { return ts.Seq })
+	} else {
+		sortTraceSummaries(filtered, params.SortBy, params.SortOrder)
+	}
+
+	// Paginate
+	return paginateTraceSummaries(filtered, params.Pagination)
+}
+
 // matchesSpanFilters checks if a span matches all filter criteria
 func matchesSpanFilters(span *telemetry.SpanData, params TraceFilterParams) bool {
 	// Service filter
@@ -312,6 +413,10 @@ func sortSpansByName(spans []*telemetry.SpanData, ascending bool) {
 
 // paginateSpans applies pagination to spans
 func paginateSpans(spans []*telemetry.SpanData, pagination PaginationParams) []*telemetry.SpanData {
+	if pagination.After != nil {
+		return afterCursor(spans, *pagination.After, pagination.Limit, func(sd *telemetry.SpanData) uint64 { return sd.Seq })
+	}
+
 	if pagination.Offset >= len(spans) {
 		return []*telemetry.SpanData{}
 	}
@@ -324,6 +429,41 @@ func paginateSpans(spans []*telemetry.SpanData, pagination PaginationParams) []*
 	return spans[pagination.Offset:end]
 }
 
+// sortTraceSummaries sorts trace summaries based on the given criteria
+func sortTraceSummaries(summaries []telemetry.TraceSummary, sortBy, sortOrder string) {
+	ascending := sortOrder == "asc"
+
+	sort.SliceStable(summaries, func(i, j int) bool {
+		a, b := summaries[i], summaries[j]
+		if !ascending {
+			a, b = b, a
+		}
+		switch sortBy {
+		case "duration":
+			return a.Duration < b.Duration
+		case "name":
+			return a.RootSpanName < b.RootSpanName
+		default:
+			return a.StartTime.Before(b.StartTime)
+		}
+	})
+}
+
+// paginateTraceSummaries applies pagination to trace summaries
+func paginateTraceSummaries(summaries []telemetry.TraceSummary, pagination PaginationParams) []telemetry.TraceSummary {
+	if pagination.After != nil {
+		return afterCursor(summaries, *pagination.After, pagination.Limit, func(ts telemetry.TraceSummary) uint64 { return ts.Seq })
+	}
+
+	if pagination.Offset >= len(summaries) {
+		return []telemetry.TraceSummary{}
+	}
+
+	end := min(pagination.Offset+pagination.Limit, len(summaries))
+
+	return summaries[pagination.Offset:end]
+}
+
 // FilterLogs applies all filters to a slice of logs
 func FilterLogs(logs []*telemetry.LogData, params LogFilterParams) []*telemetry.LogData {
 	filtered := make([]*telemetry.LogData, 0, len(logs))
@@ -375,6 +515,11 @@ func matchesLogFilters(log *telemetry.LogData, params LogFilterParams) bool {
 		}
 	}
 
+	// Query filter
+	if !params.Query.MatchLog(log) {
+		return false
+	}
+
 	// Time range filter
 	logTime := log.Log.Timestamp().AsTime()
 	if params.TimeRange.StartTime != nil && logTime.Before(*params.TimeRange.StartTime) {
@@ -389,6 +534,10 @@ func matchesLogFilters(log *telemetry.LogData, params LogFilterParams) bool {
 
 // paginateLogs applies pagination to logs
 func paginateLogs(logs []*telemetry.LogData, pagination PaginationParams) []*telemetry.LogData {
+	if pagination.After != nil {
+		return afterCursor(logs, *pagination.After, pagination.Limit, func(ld *telemetry.LogData) uint64 { return ld.Seq })
+	}
+
 	if pagination.Offset >= len(logs) {
 		return []*telemetry.LogData{}
 	}
@@ -441,6 +590,11 @@ func matchesMetricFilters(metric *telemetry.MetricData, params MetricFilterParam
 		}
 	}
 
+	// Query filter
+	if !params.Query.MatchMetric(metric) {
+		return false
+	}
+
 	// Time range filter
 	if params.TimeRange.StartTime != nil && metric.ReceivedAt.Before(*params.TimeRange.StartTime) {
 		return false
@@ -454,6 +608,10 @@ func matchesMetricFilters(metric *telemetry.MetricData, params MetricFilterParam
 
 // paginateMetrics applies pagination to metrics
 func paginateMetrics(metrics []*telemetry.MetricData, pagination PaginationParams) []*telemetry.MetricData {
+	if pagination.After != nil {
+		return afterCursor(metrics, *pagination.After, pagination.Limit, func(md *telemetry.MetricData) uint64 { return md.Seq })
+	}
+
 	if pagination.Offset >= len(metrics) {
 		return []*telemetry.MetricData{}
 	}
@@ -466,6 +624,37 @@ func paginateMetrics(metrics []*telemetry.MetricData, pagination PaginationParam
 	return metrics[pagination.Offset:end]
 }
 
+// sortBySeq sorts the data by the sequence number in ascending order
+func sortBySeq[T any](data []T, seq func(T) uint64) {
+	sort.SliceStable(data, func(i, j int) bool {
+		return seq(data[i]) < seq(data[j])
+	})
+}
+
+// afterCursor returns up to limit data whose sequence number is greater than the cursor.
+// The data must be ordered by the sequence number.
+func afterCursor[T any](data []T, cursor uint64, limit int, seq func(T) uint64) []T {
+	start := sort.Search(len(data), func(i int) bool {
+		return seq(data[i]) > cursor
+	})
+	end := min(start+limit, len(data))
+
+	return data[start:end]
+}
+
+// NextCursor returns the cursor to get the data added after the given data, which is the
+// largest sequence number in it. If the data is empty, the cursor of the request is returned.
+func NextCursor[T any](data []T, pagination PaginationParams, seq func(T) uint64) uint64 {
+	var cursor uint64
+	if pagination.After != nil {
+		cursor = *pagination.After
+	}
+	for _, d := range data {
+		cursor = max(cursor, seq(d))
+	}
+	return cursor
+}
+
 // severityNameToNumber converts severity name to number
 func severityNameToNumber(name string) int32 {
 	switch strings.ToLower(name) {
@@ -0,0 +1,171 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/telemetry"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/test"
+)
+
+func TestParsePaginationParams(t *testing.T) {
+	cursor := func(v uint64) *uint64 { return &v }
+	tests := []struct {
+		name  string
+		query string
+		want  PaginationParams
+	}{
+		{
+			name:  "default",
+			query: "",
+			want:  PaginationParams{Offset: 0, Limit: 100},
+		},
+		{
+			name:  "offset and limit",
+			query: "offset=10&limit=20",
+			want:  PaginationParams{Offset: 10, Limit: 20},
+		},
+		{
+			name:  "limit over the maximum",
+			query: "limit=5000",
+			want:  PaginationParams{Offset: 0, Limit: 1000},
+		},
+		{
+			name:  "invalid offset and limit",
+			query: "offset=-1&limit=0",
+			want:  PaginationParams{Offset: 0, Limit: 100},
+		},
+		{
+			name:  "after",
+			query: "after=42&limit=10",
+			want:  PaginationParams{Offset: 0, Limit: 10, After: cursor(42)},
+		},
+		{
+			name:  "after zero",
+			query: "after=0",
+			want:  PaginationParams{Offset: 0, Limit: 100, After: cursor(0)},
+		},
+		{
+			name:  "invalid after",
+			query: "after=-1",
+			want:  PaginationParams{Offset: 0, Limit: 100},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/logs?"+tt.query, nil)
+			assert.Equal(t, tt.want, ParsePaginationParams(r))
+		})
+	}
+}
+
+func TestAfterCursor(t *testing.T) {
+	data := []uint64{2, 3, 5, 8, 13}
+	tests := []struct {
+		name   string
+		cursor uint64
+		limit  int
+		want   []uint64
+	}{
+		{name: "from the first", cursor: 0, limit: 10, want: []uint64{2, 3, 5, 8, 13}},
+		{name: "limited", cursor: 0, limit: 2, want: []uint64{2, 3}},
+		{name: "cursor in the data", cursor: 3, limit: 2, want: []uint64{5, 8}},
+		{name: "cursor between the data", cursor: 6, limit: 10, want: []uint64{8, 13}},
+		{name: "cursor at the last", cursor: 13, limit: 10, want: []uint64{}},
+		{name: "cursor after the last", cursor: 100, limit: 10, want: []uint64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := afterCursor(data, tt.cursor, tt.limit, func(seq uint64) uint64 { return seq })
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNextCursor(t *testing.T) {
+	cursor := func(v uint64) *uint64 { return &v }
+	tests := []struct {
+		name       string
+		data       []uint64
+		pagination PaginationParams
+		want       uint64
+	}{
+		{name: "largest in the data", data: []uint64{5, 3, 8}, pagination: PaginationParams{}, want: 8},
+		{name: "largest with the cursor", data: []uint64{5, 8}, pagination: PaginationParams{After: cursor(3)}, want: 8},
+		{name: "empty without the cursor", data: []uint64{}, pagination: PaginationParams{}, want: 0},
+		{name: "empty with the cursor", data: []uint64{}, pagination: PaginationParams{After: cursor(13)}, want: 13},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NextCursor(tt.data, tt.pagination, func(seq uint64) uint64 { return seq })
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCursorPagination(t *testing.T) {
+	store := telemetry.NewStore(clockwork.NewRealClock())
+	presets, err := telemetry.NewPresetStore("")
+	require.NoError(t, err)
+	server := NewServer(store, presets)
+
+	get := func(t *testing.T, path string) (*httptest.ResponseRecorder, []map[string]any) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		var body []map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return w, body
+	}
+
+	t.Run("logs", func(t *testing.T) {
+		lp, testlogs := test.GenerateOTLPLogsPayload(t, 1, 1, []int{1}, [][]int{{2}})
+		store.AddLog(&lp)
+
+		w, page := get(t, "/api/logs?limit=2")
+		require.Equal(t, 2, len(page))
+		next := w.Header().Get("X-Next-Cursor")
+		assert.Equal(t, strconv.FormatFloat(page[1]["seq"].(float64), 'f', -1, 64), next)
+
+		w, rest := get(t, "/api/logs?limit=100&after="+next)
+		assert.Equal(t, len(testlogs.Logs)-2, len(rest))
+		for _, l := range rest {
+			assert.Greater(t, l["seq"].(float64), page[1]["seq"].(float64))
+		}
+
+		// nothing is added after the last cursor
+		last := w.Header().Get("X-Next-Cursor")
+		w, empty := get(t, "/api/logs?after="+last)
+		assert.Empty(t, empty)
+		assert.Equal(t, last, w.Header().Get("X-Next-Cursor"))
+	})
+
+	t.Run("updated trace", func(t *testing.T) {
+		tp, testdata := test.GenerateOTLPTracesPayload(t, 1, 1, []int{1}, [][]int{{1}})
+		store.AddSpan(&tp)
+
+		w, traces := get(t, "/api/traces?group=trace")
+		require.Equal(t, 1, len(traces))
+		next := w.Header().Get("X-Next-Cursor")
+
+		// a span added to the trace makes it returned again
+		tp2, _ := test.GenerateOTLPTracesPayload(t, 1, 1, []int{1}, [][]int{{1}})
+		tp2.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).SetSpanID([8]byte{9, 9, 9, 9, 9, 9, 9, 9})
+		store.AddSpan(&tp2)
+
+		_, updated := get(t, "/api/traces?group=trace&after="+next)
+		require.Equal(t, 1, len(updated))
+		assert.Equal(t, testdata.Spans[0].TraceID().String(), updated[0]["traceId"])
+		assert.Equal(t, float64(2), updated[0]["spanCount"])
+	})
+}
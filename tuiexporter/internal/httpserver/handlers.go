@@ -1,23 +1,29 @@
 package httpserver
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/telemetry"
 )
 
 type Server struct {
-	store *telemetry.Store
-	mux   *http.ServeMux
+	store   *telemetry.Store
+	presets *telemetry.PresetStore
+	mux     *http.ServeMux
 }
 
-func NewServer(store *telemetry.Store) *Server {
+func NewServer(store *telemetry.Store, presets *telemetry.PresetStore) *Server {
 	s := &Server{
-		store: store,
-		mux:   http.NewServeMux(),
+		store:   store,
+		presets: presets,
+		mux:     http.NewServeMux(),
 	}
 	s.setupRoutes()
 	return s
@@ -26,9 +32,14 @@ func NewServer(store *telemetry.Store) *Server {
 func (s *Server) setupRoutes() {
 	// Traces endpoints
 	s.mux.HandleFunc("GET /api/traces", s.handleGetTraces)
+	s.mux.HandleFunc("GET /api/traces/diff", s.handleGetTraceDiff)
 	s.mux.HandleFunc("GET /api/traces/{traceID}", s.handleGetTraceByID)
 	s.mux.HandleFunc("GET /api/traces/{traceID}/services/{service}", s.handleGetTraceByIDAndService)
+	s.mux.HandleFunc("GET /api/traces/{traceID}/export", s.handleExportTrace)
+	s.mux.HandleFunc("GET /api/traces/{traceID}/critical-path", s.handleGetCriticalPath)
+	s.mux.HandleFunc("GET /api/traces/{traceID}/breakdown", s.handleGetBreakdown)
 	s.mux.HandleFunc("GET /api/spans/{spanID}", s.handleGetSpanByID)
+	s.mux.HandleFunc("GET /api/flamegraph", s.handleGetAggregatedFlameGraph)
 
 	// Metrics endpoints
 	s.mux.HandleFunc("GET /api/metrics", s.handleGetMetrics)
@@ -44,9 +55,20 @@ func (s *Server) setupRoutes() {
 
 	// Services endpoint
 	s.mux.HandleFunc("GET /api/services", s.handleGetServices)
+	s.mux.HandleFunc("GET /api/services/{service}/operations", s.handleGetServiceOperations)
 
 	// Stats endpoint
 	s.mux.HandleFunc("GET /api/stats", s.handleGetStats)
+
+	// Export endpoint
+	s.mux.HandleFunc("GET /api/export", s.handleExport)
+
+	// Stream endpoint
+	s.mux.HandleFunc("GET /api/stream", s.handleStream)
+
+	// Filter preset endpoints
+	s.mux.HandleFunc("GET /api/presets", s.handleGetPresets)
+	s.mux.HandleFunc("POST /api/presets", s.handleCreatePreset)
 }
 
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -63,20 +85,69 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The permissive CORS is for reading the data, so that a page of another origin cannot
+	// change the state (e.g. the presets) of otel-tui
+	if !isSafeMethod(r.Method) && !isSameOrigin(r) {
+		respondError(w, http.StatusForbidden, "Cross-origin requests are not allowed for "+r.Method)
+		return
+	}
+
 	s.mux.ServeHTTP(w, r)
 }
 
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+// isSameOrigin returns true if the request is sent from the same origin or not sent from a browser.
+// Browsers always send the Origin header with the cross-origin requests other than GET and HEAD.
+func isSameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return u.Host == r.Host
+}
+
 // Trace handlers
 
 func (s *Server) handleGetTraces(w http.ResponseWriter, r *http.Request) {
+	preset, err := s.presetParam(r, telemetry.SignalTraces)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	// Parse filter parameters
-	filterParams := ParseTraceFilterParams(r)
+	filterParams, err := ParseTraceFilterParams(r, preset)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid query: "+err.Error())
+		return
+	}
+	if filterParams.Group != "" && filterParams.Group != "trace" {
+		respondError(w, http.StatusBadRequest, "Unknown group: "+filterParams.Group)
+		return
+	}
 
 	// Get all spans
 	spans := s.store.GetSvcSpans()
 
 	// Apply filters
-	filtered := FilterSpans(*spans, filterParams)
+	candidates := *spans
+	if filterParams.Query != nil {
+		candidates = s.store.FilterSvcSpans(candidates, filterParams.Query)
+	}
+
+	if filterParams.Group == "trace" {
+		s.respondTraceSummaries(w, candidates, filterParams)
+		return
+	}
+
+	filtered := FilterSpans(candidates, filterParams)
 
 	// Convert to JSON
 	result := make([]SpanJSON, len(filtered))
@@ -89,6 +160,26 @@ func (s *Server) handleGetTraces(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("X-Filtered-Count", strconv.Itoa(len(filtered)))
 	w.Header().Set("X-Offset", strconv.Itoa(filterParams.Pagination.Offset))
 	w.Header().Set("X-Limit", strconv.Itoa(filterParams.Pagination.Limit))
+	w.Header().Set("X-Next-Cursor", strconv.FormatUint(NextCursor(filtered, filterParams.Pagination, func(sd *telemetry.SpanData) uint64 { return sd.Seq }), 10))
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+// respondTraceSummaries responds the summaries of the traces instead of the spans.
+// X-Total-Count is the number of the traces in the store.
+func (s *Server) respondTraceSummaries(w http.ResponseWriter, spans []*telemetry.SpanData, filterParams TraceFilterParams) {
+	filtered := FilterTraceSummaries(s.store, spans, filterParams)
+
+	result := make([]TraceSummaryJSON, len(filtered))
+	for i := range filtered {
+		result[i] = TraceSummaryToJSON(&filtered[i])
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(len(s.store.SummarizeTraces(*s.store.GetSvcSpans()))))
+	w.Header().Set("X-Filtered-Count", strconv.Itoa(len(filtered)))
+	w.Header().Set("X-Offset", strconv.Itoa(filterParams.Pagination.Offset))
+	w.Header().Set("X-Limit", strconv.Itoa(filterParams.Pagination.Limit))
+	w.Header().Set("X-Next-Cursor", strconv.FormatUint(NextCursor(filtered, filterParams.Pagination, func(ts telemetry.TraceSummary) uint64 { return ts.Seq }), 10))
 
 	respondJSON(w, http.StatusOK, result)
 }
@@ -125,6 +216,65 @@ func (s *Server) handleGetTraceByID(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, result)
 }
 
+func (s *Server) handleGetCriticalPath(w http.ResponseWriter, r *http.Request) {
+	traceID := r.PathValue("traceID")
+
+	spans, ok := s.store.SpansByTraceID(traceID)
+	if !ok {
+		respondError(w, http.StatusNotFound, "Trace not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, CriticalPathToJSON(telemetry.NewCriticalPath(traceID, spans)))
+}
+
+func (s *Server) handleGetBreakdown(w http.ResponseWriter, r *http.Request) {
+	traceID := r.PathValue("traceID")
+
+	spans, ok := s.store.SpansByTraceID(traceID)
+	if !ok {
+		respondError(w, http.StatusNotFound, "Trace not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, TraceBreakdownToJSON(telemetry.NewTraceBreakdown(traceID, spans), spans))
+}
+
+func (s *Server) handleGetTraceDiff(w http.ResponseWriter, r *http.Request) {
+	traceIDA := r.URL.Query().Get("a")
+	traceIDB := r.URL.Query().Get("b")
+	if traceIDA == "" || traceIDB == "" {
+		respondError(w, http.StatusBadRequest, "a and b are required")
+		return
+	}
+
+	spans := s.store.SpansByTraceIDs(traceIDA, traceIDB)
+	for i, traceID := range []string{traceIDA, traceIDB} {
+		if spans[i] == nil {
+			respondError(w, http.StatusNotFound, "Trace not found: "+traceID)
+			return
+		}
+	}
+
+	respondJSON(w, http.StatusOK, TraceDiffToJSON(telemetry.NewTraceDiff(traceIDA, spans[0], traceIDB, spans[1])))
+}
+
+func (s *Server) handleGetAggregatedFlameGraph(w http.ResponseWriter, r *http.Request) {
+	rootSpanName := r.URL.Query().Get("root_span_name")
+	if rootSpanName == "" {
+		respondError(w, http.StatusBadRequest, "root_span_name is required")
+		return
+	}
+
+	g := s.store.AggregateFlameGraph(rootSpanName)
+	if g.TraceCount == 0 {
+		respondError(w, http.StatusNotFound, "No traces found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, AggregatedFlameGraphToJSON(g))
+}
+
 func (s *Server) handleGetTraceByIDAndService(w http.ResponseWriter, r *http.Request) {
 	traceID := r.PathValue("traceID")
 	service := r.PathValue("service")
@@ -161,11 +311,21 @@ func (s *Server) handleGetSpanByID(w http.ResponseWriter, r *http.Request) {
 // Metric handlers
 
 func (s *Server) handleGetMetrics(w http.ResponseWriter, r *http.Request) {
+	preset, err := s.presetParam(r, telemetry.SignalMetrics)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	// Parse filter parameters
-	filterParams := ParseMetricFilterParams(r)
+	filterParams, err := ParseMetricFilterParams(r, preset)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid query: "+err.Error())
+		return
+	}
 
 	// Get all metrics
-	s.store.ApplyFilterMetrics("")
+	s.store.ApplyFilterMetrics("", telemetry.MatchMode{})
 	metrics := s.store.GetFilteredMetrics()
 
 	// Apply filters
@@ -182,6 +342,7 @@ func (s *Server) handleGetMetrics(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("X-Filtered-Count", strconv.Itoa(len(filtered)))
 	w.Header().Set("X-Offset", strconv.Itoa(filterParams.Pagination.Offset))
 	w.Header().Set("X-Limit", strconv.Itoa(filterParams.Pagination.Limit))
+	w.Header().Set("X-Next-Cursor", strconv.FormatUint(NextCursor(filtered, filterParams.Pagination, func(md *telemetry.MetricData) uint64 { return md.Seq }), 10))
 
 	respondJSON(w, http.StatusOK, result)
 }
@@ -189,7 +350,7 @@ func (s *Server) handleGetMetrics(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleGetMetricsByService(w http.ResponseWriter, r *http.Request) {
 	service := r.PathValue("service")
 
-	s.store.ApplyFilterMetrics(service)
+	s.store.ApplyFilterMetrics(service, telemetry.MatchMode{})
 	metrics := s.store.GetFilteredMetrics()
 
 	result := make([]MetricJSON, len(*metrics))
@@ -222,11 +383,21 @@ func (s *Server) handleGetMetricsByServiceAndName(w http.ResponseWriter, r *http
 // Log handlers
 
 func (s *Server) handleGetLogs(w http.ResponseWriter, r *http.Request) {
+	preset, err := s.presetParam(r, telemetry.SignalLogs)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	// Parse filter parameters
-	filterParams := ParseLogFilterParams(r)
+	filterParams, err := ParseLogFilterParams(r, preset)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid query: "+err.Error())
+		return
+	}
 
 	// Get all logs
-	s.store.ApplyFilterLogs("")
+	s.store.ApplyFilterLogs("", telemetry.MatchMode{})
 	logs := s.store.GetFilteredLogs()
 
 	// Apply filters
@@ -243,6 +414,7 @@ func (s *Server) handleGetLogs(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("X-Filtered-Count", strconv.Itoa(len(filtered)))
 	w.Header().Set("X-Offset", strconv.Itoa(filterParams.Pagination.Offset))
 	w.Header().Set("X-Limit", strconv.Itoa(filterParams.Pagination.Limit))
+	w.Header().Set("X-Next-Cursor", strconv.FormatUint(NextCursor(filtered, filterParams.Pagination, func(ld *telemetry.LogData) uint64 { return ld.Seq }), 10))
 
 	respondJSON(w, http.StatusOK, result)
 }
@@ -268,82 +440,7 @@ func (s *Server) handleGetLogsByTraceID(w http.ResponseWriter, r *http.Request)
 // Topology handler
 
 func (s *Server) handleGetTopology(w http.ResponseWriter, r *http.Request) {
-	cache := s.store.GetTraceCache()
-
-	// Build topology from dependency graph
-	topology := s.buildTopology(cache)
-
-	respondJSON(w, http.StatusOK, topology)
-}
-
-func (s *Server) buildTopology(cache *telemetry.TraceCache) TopologyJSON {
-	// Access the internal span map to build dependencies
-	// We'll need to get the dependencies similar to how getDependencies works
-	nodes := make(map[string]*TopologyNodeJSON)
-	edges := make(map[string]*TopologyEdgeJSON)
-
-	// Get all spans and build the graph
-	spans := s.store.GetSvcSpans()
-	for _, spanData := range *spans {
-		span := spanData.Span
-		serviceName := spanData.GetServiceName()
-
-		// Add node if not exists
-		if _, ok := nodes[serviceName]; !ok {
-			nodes[serviceName] = &TopologyNodeJSON{
-				Service: serviceName,
-				Depth:   0, // Will calculate later
-			}
-		}
-
-		// Check for parent span
-		parentSpanID := span.ParentSpanID().String()
-		if parentSpanID != "" && !span.ParentSpanID().IsEmpty() {
-			if parentSpan, ok := cache.GetSpanByID(parentSpanID); ok {
-				parentServiceName := parentSpan.GetServiceName()
-
-				// Don't create edge if parent and child are same service
-				if parentServiceName != serviceName {
-					edgeKey := parentServiceName + "->" + serviceName
-
-					// Add parent node if not exists
-					if _, ok := nodes[parentServiceName]; !ok {
-						nodes[parentServiceName] = &TopologyNodeJSON{
-							Service: parentServiceName,
-							Depth:   0,
-						}
-					}
-
-					// Add or increment edge
-					if edge, ok := edges[edgeKey]; ok {
-						edge.Count++
-					} else {
-						edges[edgeKey] = &TopologyEdgeJSON{
-							Source: parentServiceName,
-							Target: serviceName,
-							Count:  1,
-						}
-					}
-				}
-			}
-		}
-	}
-
-	// Convert maps to slices
-	nodeSlice := make([]TopologyNodeJSON, 0, len(nodes))
-	for _, node := range nodes {
-		nodeSlice = append(nodeSlice, *node)
-	}
-
-	edgeSlice := make([]TopologyEdgeJSON, 0, len(edges))
-	for _, edge := range edges {
-		edgeSlice = append(edgeSlice, *edge)
-	}
-
-	return TopologyJSON{
-		Nodes: nodeSlice,
-		Edges: edgeSlice,
-	}
+	respondJSON(w, http.StatusOK, TopologyToJSON(s.store.Topology(time.Time{})))
 }
 
 // Services handler
@@ -365,16 +462,33 @@ func (s *Server) handleGetServices(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, services)
 }
 
+func (s *Server) handleGetServiceOperations(w http.ResponseWriter, r *http.Request) {
+	service := r.PathValue("service")
+
+	stats := s.store.OperationStats(service)
+	if len(stats) == 0 {
+		respondError(w, http.StatusNotFound, "No spans found for service")
+		return
+	}
+
+	result := make([]OperationStatsJSON, len(stats))
+	for i, o := range stats {
+		result[i] = OperationStatsToJSON(o)
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
 // Stats handler
 
 func (s *Server) handleGetStats(w http.ResponseWriter, r *http.Request) {
 	spans := s.store.GetSvcSpans()
 
 	// Get filtered metrics and logs to get accurate counts
-	s.store.ApplyFilterMetrics("")
+	s.store.ApplyFilterMetrics("", telemetry.MatchMode{})
 	metrics := s.store.GetFilteredMetrics()
 
-	s.store.ApplyFilterLogs("")
+	s.store.ApplyFilterLogs("", telemetry.MatchMode{})
 	logs := s.store.GetFilteredLogs()
 
 	// Count unique traces
@@ -396,14 +510,125 @@ func (s *Server) handleGetStats(w http.ResponseWriter, r *http.Request) {
 		TraceCount:          len(traceSet),
 		ServiceCount:        len(serviceSet),
 		LastUpdated:         s.store.UpdatedAt(),
-		MaxServiceSpanCount: telemetry.MAX_SERVICE_SPAN_COUNT,
-		MaxMetricCount:      telemetry.MAX_METRIC_COUNT,
-		MaxLogCount:         telemetry.MAX_LOG_COUNT,
+		MaxServiceSpanCount: s.store.MaxServiceSpanCount(),
+		MaxMetricCount:      s.store.MaxMetricCount(),
+		MaxLogCount:         s.store.MaxLogCount(),
+		MemoryUsageBytes:    s.store.MemoryUsage(),
+		MaxMemoryBytes:      s.store.MaxMemoryBytes(),
+		SubscriberCount:     s.store.SubscriberCount(),
+		DroppedBatches:      s.store.DroppedBatches(),
 	}
 
 	respondJSON(w, http.StatusOK, stats)
 }
 
+// Export handlers
+
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	signals, err := telemetry.ParseSignals(r.URL.Query().Get("signal"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var filtered bool
+	switch scope := r.URL.Query().Get("scope"); scope {
+	case "", "all":
+		filtered = false
+	case "filtered":
+		filtered = true
+	default:
+		respondError(w, http.StatusBadRequest, "invalid scope: "+scope)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := s.store.ExportJSONLines(&buf, signals, filtered); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="otel-tui-export.jsonl"`)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+func (s *Server) handleExportTrace(w http.ResponseWriter, r *http.Request) {
+	traceID := r.PathValue("traceID")
+
+	format, err := telemetry.ParseTraceFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	b, ok, err := s.store.ExportTrace(traceID, format)
+	if !ok {
+		respondError(w, http.StatusNotFound, "Trace not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, telemetry.TraceExportFileName(traceID, format)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(b)
+}
+
+// Preset handlers
+
+func (s *Server) handleGetPresets(w http.ResponseWriter, r *http.Request) {
+	signals, err := telemetry.ParseSignals(r.URL.Query().Get("signal"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result := []telemetry.Preset{}
+	for _, signal := range signals {
+		result = append(result, s.presets.List(signal)...)
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleCreatePreset(w http.ResponseWriter, r *http.Request) {
+	var preset telemetry.Preset
+	if err := json.NewDecoder(r.Body).Decode(&preset); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid preset: "+err.Error())
+		return
+	}
+	if err := preset.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid preset: "+err.Error())
+		return
+	}
+
+	if err := s.presets.Save(preset); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, preset)
+}
+
+// presetParam returns the preset of the signal specified by the preset parameter.
+// It returns nil if the parameter is not specified.
+func (s *Server) presetParam(r *http.Request, signal telemetry.Signal) (*telemetry.Preset, error) {
+	name := r.URL.Query().Get("preset")
+	if name == "" {
+		return nil, nil
+	}
+	preset, ok := s.presets.Get(signal, name)
+	if !ok {
+		return nil, fmt.Errorf("unknown %s preset: %s", signal, name)
+	}
+	return &preset, nil
+}
+
 // Helper functions
 
 func respondJSON(w http.ResponseWriter, status int, data interface{}) {
@@ -422,7 +647,7 @@ func respondError(w http.ResponseWriter, status int, message string) {
 // These are convenience methods that wrap the mutex
 
 func (s *Server) getServicesByMetrics() []string {
-	s.store.ApplyFilterMetrics("")
+	s.store.ApplyFilterMetrics("", telemetry.MatchMode{})
 	metrics := s.store.GetFilteredMetrics()
 
 	serviceSet := make(map[string]bool)
@@ -438,7 +663,7 @@ func (s *Server) getServicesByMetrics() []string {
 }
 
 func (s *Server) getServicesByLogs() []string {
-	s.store.ApplyFilterLogs("")
+	s.store.ApplyFilterLogs("", telemetry.MatchMode{})
 	logs := s.store.GetFilteredLogs()
 
 	serviceSet := make(map[string]bool)
@@ -0,0 +1,62 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/telemetry"
+)
+
+func TestCreatePresetOrigin(t *testing.T) {
+	tests := []struct {
+		name   string
+		origin string
+		want   int
+	}{
+		{name: "no origin", origin: "", want: http.StatusCreated},
+		{name: "same origin", origin: "http://localhost:8000", want: http.StatusCreated},
+		{name: "cross origin", origin: "http://example.com", want: http.StatusForbidden},
+		{name: "cross origin on the other port", origin: "http://localhost:3000", want: http.StatusForbidden},
+		{name: "malformed origin", origin: "://", want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			presets, err := telemetry.NewPresetStore("")
+			require.NoError(t, err)
+			server := NewServer(telemetry.NewStore(clockwork.NewRealClock()), presets)
+
+			body := `{"name": "errors", "signal": "logs", "query": "error"}`
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:8000/api/presets", strings.NewReader(body))
+			r.Header.Set("Content-Type", "text/plain")
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			w := httptest.NewRecorder()
+			server.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.want, w.Code)
+			saved := tt.want == http.StatusCreated
+			assert.Equal(t, saved, len(presets.List(telemetry.SignalLogs)) == 1)
+		})
+	}
+
+	t.Run("cross origin read", func(t *testing.T) {
+		presets, err := telemetry.NewPresetStore("")
+		require.NoError(t, err)
+		server := NewServer(telemetry.NewStore(clockwork.NewRealClock()), presets)
+
+		r := httptest.NewRequest(http.MethodGet, "http://localhost:8000/api/presets", nil)
+		r.Header.Set("Origin", "http://example.com")
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	})
+}
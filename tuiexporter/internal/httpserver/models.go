@@ -29,6 +29,7 @@ type SpanJSON struct {
 	ScopeName         string                 `json:"scopeName"`
 	ScopeVersion      string                 `json:"scopeVersion"`
 	ReceivedAt        time.Time              `json:"receivedAt"`
+	Seq               uint64                 `json:"seq"`
 }
 
 // SpanStatusJSON represents span status
@@ -66,6 +67,7 @@ type MetricJSON struct {
 	ScopeName          string                 `json:"scopeName"`
 	ScopeVersion       string                 `json:"scopeVersion"`
 	ReceivedAt         time.Time              `json:"receivedAt"`
+	Seq                uint64                 `json:"seq"`
 }
 
 // DataPointJSON represents a generic data point
@@ -110,6 +112,7 @@ type LogJSON struct {
 	ScopeName          string                 `json:"scopeName"`
 	ScopeVersion       string                 `json:"scopeVersion"`
 	ReceivedAt         time.Time              `json:"receivedAt"`
+	Seq                uint64                 `json:"seq"`
 }
 
 // TraceJSON represents a complete trace with all spans
@@ -119,6 +122,144 @@ type TraceJSON struct {
 	Services []string   `json:"services"`
 }
 
+// TraceSummaryJSON represents a summary of a trace
+type TraceSummaryJSON struct {
+	TraceID           string    `json:"traceId"`
+	RootSpanName      string    `json:"rootSpanName"`
+	RootServiceName   string    `json:"rootServiceName"`
+	StartTimeUnixNano int64     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   int64     `json:"endTimeUnixNano"`
+	DurationNano      int64     `json:"durationNano"`
+	DurationText      string    `json:"durationText"`
+	SpanCount         int       `json:"spanCount"`
+	Services          []string  `json:"services"`
+	ErrorCount        int       `json:"errorCount"`
+	Incomplete        bool      `json:"incomplete"`
+	ReceivedAt        time.Time `json:"receivedAt"`
+	Seq               uint64    `json:"seq"`
+}
+
+// CriticalPathJSON represents the critical path of a trace
+type CriticalPathJSON struct {
+	TraceID      string                 `json:"traceId"`
+	DurationNano int64                  `json:"durationNano"`
+	DurationText string                 `json:"durationText"`
+	Spans        []CriticalPathSpanJSON `json:"spans"`
+}
+
+// CriticalPathSpanJSON represents a span on the critical path
+type CriticalPathSpanJSON struct {
+	SpanID            string `json:"spanId"`
+	ParentSpanID      string `json:"parentSpanId"`
+	Name              string `json:"name"`
+	ServiceName       string `json:"serviceName"`
+	StartTimeUnixNano int64  `json:"startTimeUnixNano"`
+	EndTimeUnixNano   int64  `json:"endTimeUnixNano"`
+	DurationNano      int64  `json:"durationNano"`
+	SelfTimeNano      int64  `json:"selfTimeNano"`
+	CriticalTimeNano  int64  `json:"criticalTimeNano"`
+}
+
+// TraceBreakdownJSON represents the breakdown of the time of a trace
+type TraceBreakdownJSON struct {
+	TraceID      string                   `json:"traceId"`
+	SelfTimeNano int64                    `json:"selfTimeNano"`
+	ByService    []TimeBreakdownEntryJSON `json:"byService"`
+	BySpanName   []TimeBreakdownEntryJSON `json:"bySpanName"`
+	Spans        []SpanSelfTimeJSON       `json:"spans"`
+}
+
+// TimeBreakdownEntryJSON represents the time spent in a service or a span name
+type TimeBreakdownEntryJSON struct {
+	ServiceName   string  `json:"serviceName"`
+	SpanName      string  `json:"spanName,omitempty"`
+	SpanCount     int     `json:"spanCount"`
+	TotalTimeNano int64   `json:"totalTimeNano"`
+	SelfTimeNano  int64   `json:"selfTimeNano"`
+	SelfTimeRatio float64 `json:"selfTimeRatio"`
+}
+
+// SpanSelfTimeJSON represents the self time of a span
+type SpanSelfTimeJSON struct {
+	SpanID       string `json:"spanId"`
+	Name         string `json:"name"`
+	ServiceName  string `json:"serviceName"`
+	DurationNano int64  `json:"durationNano"`
+	SelfTimeNano int64  `json:"selfTimeNano"`
+}
+
+// TraceDiffJSON represents the comparison of two traces
+type TraceDiffJSON struct {
+	TraceIDA          string         `json:"traceIdA"`
+	TraceIDB          string         `json:"traceIdB"`
+	DurationNanoA     int64          `json:"durationNanoA"`
+	DurationNanoB     int64          `json:"durationNanoB"`
+	DurationDeltaNano int64          `json:"durationDeltaNano"`
+	SpansOnlyInA      int            `json:"spansOnlyInA"`
+	SpansOnlyInB      int            `json:"spansOnlyInB"`
+	SpansChanged      int            `json:"spansChanged"`
+	Spans             []SpanDiffJSON `json:"spans"`
+}
+
+// SpanDiffJSON represents a pair of the spans aligned in two traces
+type SpanDiffJSON struct {
+	ServiceName       string              `json:"serviceName"`
+	SpanName          string              `json:"spanName"`
+	SpanIDA           string              `json:"spanIdA,omitempty"`
+	SpanIDB           string              `json:"spanIdB,omitempty"`
+	DurationNanoA     int64               `json:"durationNanoA"`
+	DurationNanoB     int64               `json:"durationNanoB"`
+	DurationDeltaNano int64               `json:"durationDeltaNano"`
+	OnlyInA           bool                `json:"onlyInA"`
+	OnlyInB           bool                `json:"onlyInB"`
+	StatusA           string              `json:"statusA,omitempty"`
+	StatusB           string              `json:"statusB,omitempty"`
+	StatusChanged     bool                `json:"statusChanged"`
+	ChangedAttributes []AttributeDiffJSON `json:"changedAttributes"`
+	Children          []SpanDiffJSON      `json:"children"`
+}
+
+// AttributeDiffJSON represents a span attribute whose values differ in two traces
+type AttributeDiffJSON struct {
+	Key    string `json:"key"`
+	ValueA string `json:"valueA"`
+	ValueB string `json:"valueB"`
+}
+
+// AggregatedFlameGraphJSON represents the flame graph merged from the traces with the same root span name
+type AggregatedFlameGraphJSON struct {
+	RootSpanName string                `json:"rootSpanName"`
+	TraceCount   int                   `json:"traceCount"`
+	Frames       []AggregatedFrameJSON `json:"frames"`
+}
+
+// AggregatedFrameJSON represents a frame of the aggregated flame graph
+type AggregatedFrameJSON struct {
+	ServiceName             string                `json:"serviceName"`
+	SpanName                string                `json:"spanName"`
+	SpanCount               int                   `json:"spanCount"`
+	TraceCount              int                   `json:"traceCount"`
+	TotalDurationNano       int64                 `json:"totalDurationNano"`
+	AvgDurationNano         int64                 `json:"avgDurationNano"`
+	P95DurationNano         int64                 `json:"p95DurationNano"`
+	AvgDurationPerTraceNano int64                 `json:"avgDurationPerTraceNano"`
+	Children                []AggregatedFrameJSON `json:"children"`
+}
+
+// OperationStatsJSON represents the aggregate of the spans with the same service and span name
+type OperationStatsJSON struct {
+	ServiceName        string  `json:"serviceName"`
+	SpanName           string  `json:"spanName"`
+	Count              int     `json:"count"`
+	ErrorCount         int     `json:"errorCount"`
+	ErrorRate          float64 `json:"errorRate"`
+	P50DurationNano    int64   `json:"p50DurationNano"`
+	P90DurationNano    int64   `json:"p90DurationNano"`
+	P99DurationNano    int64   `json:"p99DurationNano"`
+	BucketCounts       []int   `json:"bucketCounts"`
+	ExplicitBoundsNano []int64 `json:"explicitBoundsNano"`
+}
+
 // TopologyJSON represents service topology
 type TopologyJSON struct {
 	Nodes []TopologyNodeJSON `json:"nodes"`
@@ -127,15 +268,28 @@ type TopologyJSON struct {
 
 // TopologyNodeJSON represents a service node
 type TopologyNodeJSON struct {
-	Service string `json:"service"`
-	Depth   int    `json:"depth"`
+	Service         string  `json:"service"`
+	Depth           int     `json:"depth"`
+	External        bool    `json:"external"`
+	Attribute       string  `json:"attribute,omitempty"`
+	RequestCount    int     `json:"requestCount"`
+	ErrorCount      int     `json:"errorCount"`
+	RequestRate     float64 `json:"requestRate"`
+	ErrorRate       float64 `json:"errorRate"`
+	AvgDurationNano int64   `json:"avgDurationNano"`
+	P95DurationNano int64   `json:"p95DurationNano"`
 }
 
 // TopologyEdgeJSON represents a connection between services
 type TopologyEdgeJSON struct {
-	Source string `json:"source"`
-	Target string `json:"target"`
-	Count  int    `json:"count"`
+	Source          string  `json:"source"`
+	Target          string  `json:"target"`
+	Count           int     `json:"count"`
+	ErrorCount      int     `json:"errorCount"`
+	CallRate        float64 `json:"callRate"`
+	ErrorRate       float64 `json:"errorRate"`
+	AvgDurationNano int64   `json:"avgDurationNano"`
+	P95DurationNano int64   `json:"p95DurationNano"`
 }
 
 // StatsJSON represents store statistics
@@ -149,6 +303,10 @@ type StatsJSON struct {
 	MaxServiceSpanCount int      `json:"maxServiceSpanCount"`
 	MaxMetricCount     int       `json:"maxMetricCount"`
 	MaxLogCount        int       `json:"maxLogCount"`
+	MemoryUsageBytes   int64     `json:"memoryUsageBytes"`
+	MaxMemoryBytes     int64     `json:"maxMemoryBytes"`
+	SubscriberCount    int       `json:"subscriberCount"`
+	DroppedBatches     uint64    `json:"droppedBatches"`
 }
 
 // Conversion functions
@@ -180,6 +338,229 @@ func SpanDataToJSON(sd *telemetry.SpanData) SpanJSON {
 		ScopeName:          sd.ScopeSpans.Scope().Name(),
 		ScopeVersion:       sd.ScopeSpans.Scope().Version(),
 		ReceivedAt:         sd.ReceivedAt,
+		Seq:                sd.Seq,
+	}
+}
+
+// TraceSummaryToJSON converts TraceSummary to TraceSummaryJSON
+func TraceSummaryToJSON(ts *telemetry.TraceSummary) TraceSummaryJSON {
+	return TraceSummaryJSON{
+		TraceID:           ts.TraceID,
+		RootSpanName:      ts.RootSpanName,
+		RootServiceName:   ts.RootServiceName,
+		StartTimeUnixNano: ts.StartTime.UnixNano(),
+		EndTimeUnixNano:   ts.EndTime.UnixNano(),
+		DurationNano:      ts.Duration.Nanoseconds(),
+		DurationText:      ts.GetDurationText(),
+		SpanCount:         ts.SpanCount,
+		Services:          ts.Services,
+		ErrorCount:        ts.ErrorCount,
+		Incomplete:        ts.Incomplete,
+		ReceivedAt:        ts.ReceivedAt,
+		Seq:               ts.Seq,
+	}
+}
+
+// CriticalPathToJSON converts CriticalPath to CriticalPathJSON
+func CriticalPathToJSON(cp *telemetry.CriticalPath) CriticalPathJSON {
+	spans := make([]CriticalPathSpanJSON, len(cp.Spans))
+	for i, cs := range cp.Spans {
+		span := cs.Span.Span
+		spans[i] = CriticalPathSpanJSON{
+			SpanID:            span.SpanID().String(),
+			ParentSpanID:      span.ParentSpanID().String(),
+			Name:              span.Name(),
+			ServiceName:       cs.Span.GetServiceName(),
+			StartTimeUnixNano: int64(span.StartTimestamp()),
+			EndTimeUnixNano:   int64(span.EndTimestamp()),
+			DurationNano:      span.EndTimestamp().AsTime().Sub(span.StartTimestamp().AsTime()).Nanoseconds(),
+			SelfTimeNano:      cs.SelfTime.Nanoseconds(),
+			CriticalTimeNano:  cs.CriticalTime.Nanoseconds(),
+		}
+	}
+
+	return CriticalPathJSON{
+		TraceID:      cp.TraceID,
+		DurationNano: cp.Duration.Nanoseconds(),
+		DurationText: cp.Duration.String(),
+		Spans:        spans,
+	}
+}
+
+// TraceBreakdownToJSON converts TraceBreakdown to TraceBreakdownJSON with the self time of the spans
+func TraceBreakdownToJSON(b *telemetry.TraceBreakdown, spans []*telemetry.SpanData) TraceBreakdownJSON {
+	entriesToJSON := func(entries []telemetry.TimeBreakdownEntry) []TimeBreakdownEntryJSON {
+		result := make([]TimeBreakdownEntryJSON, len(entries))
+		for i, e := range entries {
+			result[i] = TimeBreakdownEntryJSON{
+				ServiceName:   e.ServiceName,
+				SpanName:      e.SpanName,
+				SpanCount:     e.SpanCount,
+				TotalTimeNano: e.TotalTime.Nanoseconds(),
+				SelfTimeNano:  e.SelfTime.Nanoseconds(),
+				SelfTimeRatio: b.SelfTimeRatio(e),
+			}
+		}
+		return result
+	}
+
+	spanSelfTimes := make([]SpanSelfTimeJSON, 0, len(spans))
+	for _, sd := range spans {
+		spanID := sd.Span.SpanID().String()
+		self, _ := b.SpanSelfTime(spanID)
+		spanSelfTimes = append(spanSelfTimes, SpanSelfTimeJSON{
+			SpanID:       spanID,
+			Name:         sd.GetSpanName(),
+			ServiceName:  sd.GetServiceName(),
+			DurationNano: sd.Span.EndTimestamp().AsTime().Sub(sd.Span.StartTimestamp().AsTime()).Nanoseconds(),
+			SelfTimeNano: self.Nanoseconds(),
+		})
+	}
+
+	return TraceBreakdownJSON{
+		TraceID:      b.TraceID,
+		SelfTimeNano: b.SelfTime.Nanoseconds(),
+		ByService:    entriesToJSON(b.ByService),
+		BySpanName:   entriesToJSON(b.BySpanName),
+		Spans:        spanSelfTimes,
+	}
+}
+
+// TraceDiffToJSON converts TraceDiff to TraceDiffJSON
+func TraceDiffToJSON(d *telemetry.TraceDiff) TraceDiffJSON {
+	var diffsToJSON func(diffs []*telemetry.SpanDiff) []SpanDiffJSON
+	diffsToJSON = func(diffs []*telemetry.SpanDiff) []SpanDiffJSON {
+		result := make([]SpanDiffJSON, len(diffs))
+		for i, sd := range diffs {
+			j := SpanDiffJSON{
+				ServiceName:       sd.ServiceName,
+				SpanName:          sd.SpanName,
+				DurationDeltaNano: sd.DurationDelta.Nanoseconds(),
+				OnlyInA:           sd.OnlyInA(),
+				OnlyInB:           sd.OnlyInB(),
+				StatusChanged:     sd.StatusChanged,
+				ChangedAttributes: make([]AttributeDiffJSON, len(sd.ChangedAttributes)),
+				Children:          diffsToJSON(sd.Children),
+			}
+			if sd.A != nil {
+				j.SpanIDA = sd.A.Span.SpanID().String()
+				j.DurationNanoA = sd.A.Span.EndTimestamp().AsTime().Sub(sd.A.Span.StartTimestamp().AsTime()).Nanoseconds()
+				j.StatusA = sd.A.Span.Status().Code().String()
+			}
+			if sd.B != nil {
+				j.SpanIDB = sd.B.Span.SpanID().String()
+				j.DurationNanoB = sd.B.Span.EndTimestamp().AsTime().Sub(sd.B.Span.StartTimestamp().AsTime()).Nanoseconds()
+				j.StatusB = sd.B.Span.Status().Code().String()
+			}
+			for k, ad := range sd.ChangedAttributes {
+				j.ChangedAttributes[k] = AttributeDiffJSON{
+					Key:    ad.Key,
+					ValueA: ad.ValueA,
+					ValueB: ad.ValueB,
+				}
+			}
+			result[i] = j
+		}
+		return result
+	}
+
+	return TraceDiffJSON{
+		TraceIDA:          d.TraceIDA,
+		TraceIDB:          d.TraceIDB,
+		DurationNanoA:     d.DurationA.Nanoseconds(),
+		DurationNanoB:     d.DurationB.Nanoseconds(),
+		DurationDeltaNano: d.DurationDelta().Nanoseconds(),
+		SpansOnlyInA:      d.SpansOnlyInA,
+		SpansOnlyInB:      d.SpansOnlyInB,
+		SpansChanged:      d.SpansChanged,
+		Spans:             diffsToJSON(d.Roots),
+	}
+}
+
+// AggregatedFlameGraphToJSON converts AggregatedFlameGraph to AggregatedFlameGraphJSON
+func AggregatedFlameGraphToJSON(g *telemetry.AggregatedFlameGraph) AggregatedFlameGraphJSON {
+	var framesToJSON func(frames []*telemetry.AggregatedFrame) []AggregatedFrameJSON
+	framesToJSON = func(frames []*telemetry.AggregatedFrame) []AggregatedFrameJSON {
+		result := make([]AggregatedFrameJSON, len(frames))
+		for i, f := range frames {
+			result[i] = AggregatedFrameJSON{
+				ServiceName:             f.ServiceName,
+				SpanName:                f.SpanName,
+				SpanCount:               f.SpanCount,
+				TraceCount:              f.TraceCount,
+				TotalDurationNano:       f.TotalTime.Nanoseconds(),
+				AvgDurationNano:         f.Average.Nanoseconds(),
+				P95DurationNano:         f.P95.Nanoseconds(),
+				AvgDurationPerTraceNano: g.AverageTimePerTrace(f).Nanoseconds(),
+				Children:                framesToJSON(f.Children),
+			}
+		}
+		return result
+	}
+
+	return AggregatedFlameGraphJSON{
+		RootSpanName: g.RootSpanName,
+		TraceCount:   g.TraceCount,
+		Frames:       framesToJSON(g.Roots),
+	}
+}
+
+// OperationStatsToJSON converts OperationStats to OperationStatsJSON
+func OperationStatsToJSON(o *telemetry.OperationStats) OperationStatsJSON {
+	bounds := make([]int64, len(telemetry.LatencyBucketBounds))
+	for i, b := range telemetry.LatencyBucketBounds {
+		bounds[i] = b.Nanoseconds()
+	}
+
+	return OperationStatsJSON{
+		ServiceName:        o.ServiceName,
+		SpanName:           o.SpanName,
+		Count:              o.Count,
+		ErrorCount:         o.ErrorCount,
+		ErrorRate:          o.ErrorRate(),
+		P50DurationNano:    o.P50.Nanoseconds(),
+		P90DurationNano:    o.P90.Nanoseconds(),
+		P99DurationNano:    o.P99.Nanoseconds(),
+		BucketCounts:       o.BucketCounts,
+		ExplicitBoundsNano: bounds,
+	}
+}
+
+// TopologyToJSON converts Topology to TopologyJSON
+func TopologyToJSON(topo *telemetry.Topology) TopologyJSON {
+	nodes := make([]TopologyNodeJSON, 0, len(topo.Nodes))
+	for _, n := range topo.Nodes {
+		nodes = append(nodes, TopologyNodeJSON{
+			Service:         n.Service,
+			Depth:           n.Depth,
+			External:        n.External,
+			Attribute:       n.Attribute,
+			RequestCount:    n.Count,
+			ErrorCount:      n.ErrorCount,
+			RequestRate:     topo.Rate(&n.TopologyStats),
+			ErrorRate:       n.ErrorRate(),
+			AvgDurationNano: n.Average.Nanoseconds(),
+			P95DurationNano: n.P95.Nanoseconds(),
+		})
+	}
+
+	edges := make([]TopologyEdgeJSON, 0, len(topo.Edges))
+	for _, e := range topo.Edges {
+		edges = append(edges, TopologyEdgeJSON{
+			Source:          e.Source,
+			Target:          e.Target,
+			Count:           e.Count,
+			ErrorCount:      e.ErrorCount,
+			CallRate:        topo.Rate(&e.TopologyStats),
+			ErrorRate:       e.ErrorRate(),
+			AvgDurationNano: e.Average.Nanoseconds(),
+			P95DurationNano: e.P95.Nanoseconds(),
+		})
+	}
+
+	return TopologyJSON{
+		Nodes: nodes,
+		Edges: edges,
 	}
 }
 
@@ -198,6 +579,7 @@ func MetricDataToJSON(md *telemetry.MetricData) MetricJSON {
 		ScopeName:          md.ScopeMetric.Scope().Name(),
 		ScopeVersion:       md.ScopeMetric.Scope().Version(),
 		ReceivedAt:         md.ReceivedAt,
+		Seq:                md.Seq,
 	}
 }
 
@@ -220,6 +602,7 @@ func LogDataToJSON(ld *telemetry.LogData) LogJSON {
 		ScopeName:            ld.ScopeLog.Scope().Name(),
 		ScopeVersion:         ld.ScopeLog.Scope().Version(),
 		ReceivedAt:           ld.ReceivedAt,
+		Seq:                  ld.Seq,
 	}
 }
 
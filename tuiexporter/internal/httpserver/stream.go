@@ -0,0 +1,206 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/telemetry"
+)
+
+// Stream settings
+const (
+	STREAM_BUFFER_SIZE        = 256
+	STREAM_KEEPALIVE_INTERVAL = 15 * time.Second
+)
+
+// StreamParams represents the parameters of the live stream
+type StreamParams struct {
+	Signals []telemetry.Signal
+	Service string
+	// After is the cursor to resume the stream from. Nil means only the data added after
+	// the client connected is sent.
+	After *uint64
+}
+
+// ParseStreamParams parses the stream parameters. The cursor is taken from the after parameter
+// or the Last-Event-ID header sent by EventSource on reconnection.
+func ParseStreamParams(r *http.Request) (StreamParams, error) {
+	signals, err := telemetry.ParseSignals(r.URL.Query().Get("signal"))
+	if err != nil {
+		return StreamParams{}, err
+	}
+	params := StreamParams{
+		Signals: signals,
+		Service: r.URL.Query().Get("service"),
+	}
+
+	cursor := r.URL.Query().Get("after")
+	if cursor == "" {
+		cursor = r.Header.Get("Last-Event-ID")
+	}
+	if cursor != "" {
+		after, err := strconv.ParseUint(cursor, 10, 64)
+		if err != nil {
+			return params, fmt.Errorf("invalid cursor: %s", cursor)
+		}
+		params.After = &after
+	}
+
+	return params, nil
+}
+
+// handleStream streams the data added to the store as Server-Sent Events. Each event is a batch
+// of a signal (event: traces, metrics or logs) with the cursor of the batch as the event ID.
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	params, err := ParseStreamParams(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondError(w, http.StatusInternalServerError, "Streaming is not supported")
+		return
+	}
+
+	sub := s.store.Subscribe(STREAM_BUFFER_SIZE, params.Signals...)
+	defer sub.Close()
+
+	var cursor uint64
+	if params.After != nil {
+		cursor = *params.After
+	} else {
+		cursor = s.store.Cursor()
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	// send sends the data after the cursor and moves the cursor to the end of the batch
+	send := func(batch telemetry.Batch) error {
+		batch = batch.After(cursor)
+		if batch.Len() == 0 {
+			return nil
+		}
+		cursor = max(cursor, batch.Cursor())
+		return writeStreamEvent(w, cursor, filterBatchByService(batch, params.Service))
+	}
+	resume := func() error {
+		for _, batch := range s.store.Since(cursor, params.Signals) {
+			if err := send(batch); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if params.After != nil {
+		if err := resume(); err != nil {
+			return
+		}
+	}
+	// Tell the client the current cursor so that it can resume even if no data is sent
+	if _, err := fmt.Fprintf(w, "id: %d\nevent: ready\ndata: {}\n\n", cursor); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	keepalive := time.NewTicker(STREAM_KEEPALIVE_INTERVAL)
+	defer keepalive.Stop()
+
+	// NOTE: The store drops the batches instead of waiting for a slow client.
+	//       When some batches are dropped, the missed data is read from the store instead.
+	var dropped uint64
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case batch, ok := <-sub.C():
+			if !ok {
+				return
+			}
+			// Read the missed data first as the dropped batches can be older than this batch
+			if sub.Dropped() > dropped {
+				dropped = sub.Dropped()
+				err = resume()
+			}
+			if err == nil {
+				err = send(batch)
+			}
+		case <-keepalive.C:
+			_, err = fmt.Fprint(w, ": keepalive\n\n")
+		}
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// filterBatchByService returns the data of the service in the batch. An empty service returns the batch as is.
+func filterBatchByService(batch telemetry.Batch, service string) telemetry.Batch {
+	if service == "" {
+		return batch
+	}
+	filtered := telemetry.Batch{Signal: batch.Signal}
+	for _, sd := range batch.Spans {
+		if sd.GetServiceName() == service {
+			filtered.Spans = append(filtered.Spans, sd)
+		}
+	}
+	for _, md := range batch.Metrics {
+		if md.GetServiceName() == service {
+			filtered.Metrics = append(filtered.Metrics, md)
+		}
+	}
+	for _, ld := range batch.Logs {
+		if ld.GetServiceName() == service {
+			filtered.Logs = append(filtered.Logs, ld)
+		}
+	}
+	return filtered
+}
+
+// writeStreamEvent writes the batch as an event. A batch without data of the service is written
+// only with the ID, which moves the cursor of the client without dispatching an event.
+func writeStreamEvent(w http.ResponseWriter, cursor uint64, batch telemetry.Batch) error {
+	if batch.Len() == 0 {
+		_, err := fmt.Fprintf(w, "id: %d\n\n", cursor)
+		return err
+	}
+
+	var data interface{}
+	switch batch.Signal {
+	case telemetry.SignalTraces:
+		spans := make([]SpanJSON, len(batch.Spans))
+		for i, sd := range batch.Spans {
+			spans[i] = SpanDataToJSON(sd)
+		}
+		data = spans
+	case telemetry.SignalMetrics:
+		metrics := make([]MetricJSON, len(batch.Metrics))
+		for i, md := range batch.Metrics {
+			metrics[i] = MetricDataToJSON(md)
+		}
+		data = metrics
+	case telemetry.SignalLogs:
+		logs := make([]LogJSON, len(batch.Logs))
+		for i, ld := range batch.Logs {
+			logs[i] = LogDataToJSON(ld)
+		}
+		data = logs
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", cursor, batch.Signal, b)
+	return err
+}
@@ -0,0 +1,107 @@
+package telemetry
+
+import (
+	"sort"
+	"time"
+)
+
+// TimeBreakdownEntry is the time spent in the spans of a service or a span name in a trace
+type TimeBreakdownEntry struct {
+	ServiceName string
+	// SpanName is empty in the breakdown by service
+	SpanName  string
+	SpanCount int
+	// TotalTime is the sum of the durations of the spans
+	TotalTime time.Duration
+	// SelfTime is the sum of the self times of the spans
+	SelfTime time.Duration
+}
+
+// TraceBreakdown is the breakdown of the time of a trace by service and by span name
+type TraceBreakdown struct {
+	TraceID string
+	// SelfTime is the sum of the self times of all spans
+	SelfTime time.Duration
+	// ByService and BySpanName are sorted by the self time in descending order
+	ByService  []TimeBreakdownEntry
+	BySpanName []TimeBreakdownEntry
+	selfTimes  map[string]time.Duration
+}
+
+// NewTraceBreakdown computes the self time of each span, which is its duration minus the union of
+// its children's intervals, and sums them up by service and by service and span name
+func NewTraceBreakdown(traceID string, spans []*SpanData) *TraceBreakdown {
+	b := &TraceBreakdown{
+		TraceID:    traceID,
+		ByService:  []TimeBreakdownEntry{},
+		BySpanName: []TimeBreakdownEntry{},
+		selfTimes:  make(map[string]time.Duration, len(spans)),
+	}
+
+	bySvc := map[string]*TimeBreakdownEntry{}
+	byName := map[[2]string]*TimeBreakdownEntry{}
+	svcKeys := []string{}
+	nameKeys := [][2]string{}
+
+	var walk func(node *SpanTreeNode)
+	walk = func(node *SpanTreeNode) {
+		self := node.SelfTime()
+		total := node.Duration()
+		sname, name := node.Span.GetServiceName(), node.Span.GetSpanName()
+		b.selfTimes[node.Span.Span.SpanID().String()] = self
+		b.SelfTime += self
+
+		if _, ok := bySvc[sname]; !ok {
+			bySvc[sname] = &TimeBreakdownEntry{ServiceName: sname}
+			svcKeys = append(svcKeys, sname)
+		}
+		key := [2]string{sname, name}
+		if _, ok := byName[key]; !ok {
+			byName[key] = &TimeBreakdownEntry{ServiceName: sname, SpanName: name}
+			nameKeys = append(nameKeys, key)
+		}
+		for _, e := range []*TimeBreakdownEntry{bySvc[sname], byName[key]} {
+			e.SpanCount++
+			e.TotalTime += total
+			e.SelfTime += self
+		}
+
+		for _, c := range node.Children {
+			walk(c)
+		}
+	}
+	for _, root := range NewSpanTree(spans) {
+		walk(root)
+	}
+
+	for _, k := range svcKeys {
+		b.ByService = append(b.ByService, *bySvc[k])
+	}
+	for _, k := range nameKeys {
+		b.BySpanName = append(b.BySpanName, *byName[k])
+	}
+	sortTimeBreakdownEntries(b.ByService)
+	sortTimeBreakdownEntries(b.BySpanName)
+
+	return b
+}
+
+// SpanSelfTime returns the self time of the span in the trace
+func (b *TraceBreakdown) SpanSelfTime(spanID string) (time.Duration, bool) {
+	self, ok := b.selfTimes[spanID]
+	return self, ok
+}
+
+// SelfTimeRatio returns the ratio of the self time of the entry to the self time of the trace
+func (b *TraceBreakdown) SelfTimeRatio(entry TimeBreakdownEntry) float64 {
+	if b.SelfTime == 0 {
+		return 0
+	}
+	return float64(entry.SelfTime) / float64(b.SelfTime)
+}
+
+func sortTimeBreakdownEntries(entries []TimeBreakdownEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].SelfTime > entries[j].SelfTime
+	})
+}
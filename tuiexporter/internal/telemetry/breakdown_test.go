@@ -0,0 +1,46 @@
+package telemetry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTraceBreakdown(t *testing.T) {
+	sds := newTestSpanTreeSpans(t)
+	traceID := sds[0].Span.TraceID().String()
+
+	b := NewTraceBreakdown(traceID, sds)
+
+	assert.Equal(t, traceID, b.TraceID)
+	assert.Equal(t, 130*time.Millisecond, b.SelfTime)
+
+	self, ok := b.SpanSelfTime(sds[2].Span.SpanID().String())
+	assert.True(t, ok)
+	assert.Equal(t, 20*time.Millisecond, self)
+	_, ok = b.SpanSelfTime("unknown")
+	assert.False(t, ok)
+
+	assert.Equal(t, []TimeBreakdownEntry{
+		{ServiceName: "test-service-1", SpanCount: 6, TotalTime: 290 * time.Millisecond, SelfTime: 130 * time.Millisecond},
+	}, b.ByService)
+	assert.Equal(t, 1.0, b.SelfTimeRatio(b.ByService[0]))
+
+	assert.Equal(t, 6, len(b.BySpanName))
+	assert.Equal(t, TimeBreakdownEntry{
+		ServiceName: "test-service-1",
+		SpanName:    "span-0-0-4",
+		SpanCount:   1,
+		TotalTime:   30 * time.Millisecond,
+		SelfTime:    30 * time.Millisecond,
+	}, b.BySpanName[0])
+	assert.Equal(t, "span-0-0-0", b.BySpanName[5].SpanName)
+	assert.Equal(t, 10*time.Millisecond, b.BySpanName[5].SelfTime)
+
+	t.Run("no spans", func(t *testing.T) {
+		b := NewTraceBreakdown(traceID, nil)
+		assert.Empty(t, b.ByService)
+		assert.Equal(t, 0.0, b.SelfTimeRatio(TimeBreakdownEntry{}))
+	})
+}
@@ -1,6 +1,8 @@
 package telemetry
 
 import (
+	"sort"
+
 	"go.opentelemetry.io/collector/pdata/ptrace"
 )
 
@@ -31,6 +33,7 @@ type TraceCache struct {
 	tracesvc2spans    TraceServiceSpanDataMap
 	tracesvc2haserror TraceServiceHasErrorMap
 	tracesvc2parent   TraceServiceParentIDMap
+	topology          *topologyModel
 }
 
 // NewTraceCache returns a new trace cache
@@ -41,12 +44,14 @@ func NewTraceCache() *TraceCache {
 		tracesvc2spans:    TraceServiceSpanDataMap{},
 		tracesvc2haserror: TraceServiceHasErrorMap{},
 		tracesvc2parent:   TraceServiceParentIDMap{},
+		topology:          newTopologyModel(),
 	}
 }
 
 // UpdateCache updates the cache with a new span
 func (c *TraceCache) UpdateCache(sname string, data *SpanData) (newtracesvc bool, replaceSpanID string) {
 	c.spanid2span[data.Span.SpanID().String()] = data
+	c.topology.add(data)
 	traceID := data.Span.TraceID().String()
 	hasError := spanHasError(data.Span)
 	if ts, ok := c.traceid2spans[traceID]; ok {
@@ -92,6 +97,7 @@ func (c *TraceCache) DeleteCache(serviceSpans []*SpanData) {
 		if spans, ok := c.GetSpansByTraceIDAndSvc(ss.Span.TraceID().String(), sname); ok {
 			for _, s := range spans {
 				delete(c.spanid2span, s.Span.SpanID().String())
+				c.topology.remove(s)
 			}
 		}
 		delete(c.tracesvc2spans[traceID], sname)
@@ -114,6 +120,27 @@ func (c *TraceCache) GetSpansByTraceID(traceID string) ([]*SpanData, bool) {
 	return spans, ok
 }
 
+// GetTracesByRootSpanName returns the spans of the traces which have a root span, whose parent is
+// not received, with the given name. The traces are sorted by the trace id.
+func (c *TraceCache) GetTracesByRootSpanName(name string) [][]*SpanData {
+	traceIDs := []string{}
+	for traceID, spans := range c.traceid2spans {
+		for _, sd := range spans {
+			if _, ok := c.spanid2span[sd.Span.ParentSpanID().String()]; !ok && sd.Span.Name() == name {
+				traceIDs = append(traceIDs, traceID)
+				break
+			}
+		}
+	}
+	sort.Strings(traceIDs)
+
+	traces := make([][]*SpanData, len(traceIDs))
+	for i, traceID := range traceIDs {
+		traces[i] = c.traceid2spans[traceID]
+	}
+	return traces
+}
+
 // GetSpansByTraceIDAndSvc returns all spans for a given trace id and service name
 func (c *TraceCache) GetSpansByTraceIDAndSvc(traceID, svc string) ([]*SpanData, bool) {
 	if spans, ok := c.tracesvc2spans[traceID]; ok {
@@ -140,14 +167,11 @@ func (c *TraceCache) GetSpanByID(spanID string) (*SpanData, bool) {
 	return span, ok
 }
 
-func (c *TraceCache) DrawSpanDependencies() (string, error) {
-	return c.spanid2span.getDependencyGraph()
-}
-
 func (c *TraceCache) flush() {
 	c.spanid2span = SpanDataMap{}
 	c.traceid2spans = TraceSpanDataMap{}
 	c.tracesvc2spans = TraceServiceSpanDataMap{}
+	c.topology = newTopologyModel()
 }
 
 func spanHasError(span *ptrace.Span) bool {
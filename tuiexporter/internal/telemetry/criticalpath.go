@@ -0,0 +1,97 @@
+package telemetry
+
+import (
+	"sort"
+	"time"
+)
+
+// CriticalPathSpan is a span on the critical path of a trace
+type CriticalPathSpan struct {
+	Span *SpanData
+	// SelfTime is the exclusive time of the span not covered by any child span
+	SelfTime time.Duration
+	// CriticalTime is the time of the critical path spent in the span itself
+	CriticalTime time.Duration
+}
+
+// CriticalPath is the chain of the spans which determined the end-to-end latency of a trace
+type CriticalPath struct {
+	TraceID string
+	// Duration is the duration of the root span of the critical path
+	Duration time.Duration
+	// Spans are the spans on the critical path sorted by the start time
+	Spans []CriticalPathSpan
+	index map[string]int
+}
+
+// NewCriticalPath computes the critical path of a trace over the span tree of the spans.
+//
+// Starting from the end of the root span, the child span finishing last before the cursor is on
+// the critical path and the cursor moves to its start time, which is repeated recursively. The time
+// between the children on the path is spent in the parent itself. The children are clipped to the
+// parent to tolerate clock skews. If the trace has multiple roots, the root finishing last is used.
+func NewCriticalPath(traceID string, spans []*SpanData) *CriticalPath {
+	cp := &CriticalPath{
+		TraceID: traceID,
+		Spans:   []CriticalPathSpan{},
+		index:   map[string]int{},
+	}
+
+	var root *SpanTreeNode
+	for _, r := range NewSpanTree(spans) {
+		if root == nil || r.EndTime().After(root.EndTime()) {
+			root = r
+		}
+	}
+	if root == nil {
+		return cp
+	}
+	cp.Duration = root.Duration()
+
+	cp.walk(root, root.StartTime(), root.EndTime())
+
+	sort.SliceStable(cp.Spans, func(i, j int) bool {
+		return cp.Spans[i].Span.Span.StartTimestamp().AsTime().Before(cp.Spans[j].Span.Span.StartTimestamp().AsTime())
+	})
+	for i, s := range cp.Spans {
+		cp.index[s.Span.Span.SpanID().String()] = i
+	}
+
+	return cp
+}
+
+// Get returns the span on the critical path
+func (cp *CriticalPath) Get(spanID string) (CriticalPathSpan, bool) {
+	if idx, ok := cp.index[spanID]; ok {
+		return cp.Spans[idx], true
+	}
+	return CriticalPathSpan{}, false
+}
+
+// walk adds the node with the time between start and end, which is the node clipped to its parent
+func (cp *CriticalPath) walk(node *SpanTreeNode, start, end time.Time) {
+	idx := len(cp.Spans)
+	cp.Spans = append(cp.Spans, CriticalPathSpan{
+		Span:     node.Span,
+		SelfTime: node.SelfTime(),
+	})
+
+	children := make([]*SpanTreeNode, len(node.Children))
+	copy(children, node.Children)
+	sort.SliceStable(children, func(i, j int) bool {
+		return children[i].EndTime().After(children[j].EndTime())
+	})
+
+	cursor := end
+	for _, c := range children {
+		cs, ce := maxTime(c.StartTime(), start), minTime(c.EndTime(), end)
+		if ce.After(cursor) || !cs.Before(cursor) || !ce.After(cs) {
+			// overlaps with the child on the path, or out of the parent
+			continue
+		}
+		cp.Spans[idx].CriticalTime += cursor.Sub(ce)
+		cp.walk(c, cs, ce)
+		cursor = cs
+	}
+	cp.Spans[idx].CriticalTime += cursor.Sub(start)
+}
@@ -0,0 +1,128 @@
+package telemetry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/test"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// newTestSpanTreeSpans returns the spans of the following trace (times in ms)
+//
+//	A 0-100
+//	├- B 0-40
+//	│  └- D 10-30
+//	└- C 20-90
+//	   ├- E 30-60
+//	   └- F 50-80
+func newTestSpanTreeSpans(t *testing.T) []*SpanData {
+	t.Helper()
+
+	_, testdata := test.GenerateOTLPTracesPayload(t, 1, 1, []int{1}, [][]int{{6}})
+	base := time.Date(2024, 3, 30, 12, 30, 15, 0, time.UTC)
+	times := [][2]int{{0, 100}, {0, 40}, {20, 90}, {10, 30}, {30, 60}, {50, 80}}
+	parents := []int{-1, 0, 0, 1, 2, 2}
+
+	sds := []*SpanData{}
+	for i, span := range testdata.Spans {
+		span.SetStartTimestamp(pcommon.NewTimestampFromTime(base.Add(time.Duration(times[i][0]) * time.Millisecond)))
+		span.SetEndTimestamp(pcommon.NewTimestampFromTime(base.Add(time.Duration(times[i][1]) * time.Millisecond)))
+		if parents[i] < 0 {
+			span.SetParentSpanID(pcommon.NewSpanIDEmpty())
+		} else {
+			span.SetParentSpanID(testdata.Spans[parents[i]].SpanID())
+		}
+		sds = append(sds, &SpanData{
+			Span:         span,
+			ResourceSpan: testdata.RSpans[0],
+			ScopeSpans:   testdata.SSpans[0],
+		})
+	}
+
+	return sds
+}
+
+func TestNewSpanTree(t *testing.T) {
+	sds := newTestSpanTreeSpans(t)
+
+	// the order of the spans does not matter
+	roots := NewSpanTree([]*SpanData{sds[5], sds[4], sds[3], sds[2], sds[1], sds[0]})
+
+	assert.Equal(t, 1, len(roots))
+	assert.Equal(t, sds[0], roots[0].Span)
+	assert.Equal(t, 2, len(roots[0].Children))
+	assert.Equal(t, sds[1], roots[0].Children[0].Span)
+	assert.Equal(t, sds[3], roots[0].Children[0].Children[0].Span)
+	assert.Equal(t, sds[2], roots[0].Children[1].Span)
+	assert.Equal(t, sds[4], roots[0].Children[1].Children[0].Span)
+	assert.Equal(t, sds[5], roots[0].Children[1].Children[1].Span)
+
+	t.Run("self time", func(t *testing.T) {
+		assert.Equal(t, 10*time.Millisecond, roots[0].SelfTime())
+		assert.Equal(t, 20*time.Millisecond, roots[0].Children[0].SelfTime())
+		assert.Equal(t, 20*time.Millisecond, roots[0].Children[1].SelfTime())
+		assert.Equal(t, 30*time.Millisecond, roots[0].Children[1].Children[1].SelfTime())
+	})
+
+	t.Run("parent not received", func(t *testing.T) {
+		roots := NewSpanTree(sds[1:])
+		assert.Equal(t, 2, len(roots))
+		assert.Equal(t, sds[1], roots[0].Span)
+		assert.Equal(t, sds[2], roots[1].Span)
+	})
+}
+
+func TestNewCriticalPath(t *testing.T) {
+	sds := newTestSpanTreeSpans(t)
+	traceID := sds[0].Span.TraceID().String()
+
+	t.Run("critical path", func(t *testing.T) {
+		cp := NewCriticalPath(traceID, sds)
+
+		assert.Equal(t, traceID, cp.TraceID)
+		assert.Equal(t, 100*time.Millisecond, cp.Duration)
+		assert.Equal(t, []CriticalPathSpan{
+			{Span: sds[0], SelfTime: 10 * time.Millisecond, CriticalTime: 30 * time.Millisecond},
+			{Span: sds[2], SelfTime: 20 * time.Millisecond, CriticalTime: 40 * time.Millisecond},
+			{Span: sds[5], SelfTime: 30 * time.Millisecond, CriticalTime: 30 * time.Millisecond},
+		}, cp.Spans)
+
+		got, ok := cp.Get(sds[2].Span.SpanID().String())
+		assert.True(t, ok)
+		assert.Equal(t, sds[2], got.Span)
+		_, ok = cp.Get(sds[1].Span.SpanID().String())
+		assert.False(t, ok)
+	})
+
+	t.Run("child exceeding the parent", func(t *testing.T) {
+		sds := newTestSpanTreeSpans(t)
+		// F ends after C and A because of a clock skew
+		sds[5].Span.SetEndTimestamp(pcommon.NewTimestampFromTime(sds[0].Span.EndTimestamp().AsTime().Add(50 * time.Millisecond)))
+
+		cp := NewCriticalPath(traceID, sds)
+
+		var total time.Duration
+		for _, s := range cp.Spans {
+			total += s.CriticalTime
+		}
+		assert.Equal(t, cp.Duration, total)
+		assert.Equal(t, 3, len(cp.Spans))
+		assert.Equal(t, 40*time.Millisecond, cp.Spans[2].CriticalTime) // F is clipped to 50-90
+	})
+
+	t.Run("multiple roots", func(t *testing.T) {
+		cp := NewCriticalPath(traceID, sds[1:])
+
+		assert.Equal(t, 70*time.Millisecond, cp.Duration)
+		assert.Equal(t, sds[2], cp.Spans[0].Span)
+	})
+
+	t.Run("no spans", func(t *testing.T) {
+		cp := NewCriticalPath(traceID, nil)
+
+		assert.Empty(t, cp.Spans)
+		assert.Equal(t, time.Duration(0), cp.Duration)
+	})
+}
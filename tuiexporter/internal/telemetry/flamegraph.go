@@ -0,0 +1,148 @@
+package telemetry
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// AggregatedFrame is a frame of the flame graph merged from the spans with the same service and
+// span name under the same path from the root span
+type AggregatedFrame struct {
+	ServiceName string
+	SpanName    string
+	// SpanCount is the number of the spans merged into the frame
+	SpanCount int
+	// TraceCount is the number of the traces which have the frame
+	TraceCount int
+	// TotalTime is the sum of the durations of the spans
+	TotalTime time.Duration
+	// Average and P95 are the average and the 95th percentile of the durations of the spans
+	Average   time.Duration
+	P95       time.Duration
+	Children  []*AggregatedFrame
+	durations []time.Duration
+}
+
+// AggregatedFlameGraph is the flame graph merged from the traces with the same root span name
+type AggregatedFlameGraph struct {
+	RootSpanName string
+	TraceCount   int
+	// Roots are the frames of the root spans, usually only one
+	Roots []*AggregatedFrame
+}
+
+// NewAggregatedFlameGraph merges the span trees of the traces whose root span has the given name.
+// The frames are keyed by the service and span name under their parent frame, and the children are
+// sorted by the total time in descending order. The root spans are the spans whose parent is not in
+// the trace, and the traces without the root span of the name are ignored.
+func NewAggregatedFlameGraph(rootSpanName string, traces [][]*SpanData) *AggregatedFlameGraph {
+	g := &AggregatedFlameGraph{
+		RootSpanName: rootSpanName,
+		Roots:        []*AggregatedFrame{},
+	}
+
+	for _, spans := range traces {
+		seen := map[*AggregatedFrame]bool{}
+		matched := false
+		for _, root := range NewSpanTree(spans) {
+			if root.Span.Span.Name() != rootSpanName {
+				continue
+			}
+			matched = true
+			g.Roots = mergeAggregatedFrame(g.Roots, root, seen)
+		}
+		if matched {
+			g.TraceCount++
+		}
+	}
+
+	finalizeAggregatedFrames(g.Roots)
+
+	return g
+}
+
+// AverageTimePerTrace returns the average time spent in the frame per trace of the flame graph,
+// which is used to size the frame
+func (g *AggregatedFlameGraph) AverageTimePerTrace(frame *AggregatedFrame) time.Duration {
+	if g.TraceCount == 0 {
+		return 0
+	}
+	return frame.TotalTime / time.Duration(g.TraceCount)
+}
+
+func mergeAggregatedFrame(frames []*AggregatedFrame, node *SpanTreeNode, seen map[*AggregatedFrame]bool) []*AggregatedFrame {
+	sname, name := node.Span.GetServiceName(), node.Span.GetSpanName()
+
+	var frame *AggregatedFrame
+	for _, f := range frames {
+		if f.ServiceName == sname && f.SpanName == name {
+			frame = f
+			break
+		}
+	}
+	if frame == nil {
+		frame = &AggregatedFrame{
+			ServiceName: sname,
+			SpanName:    name,
+			Children:    []*AggregatedFrame{},
+		}
+		frames = append(frames, frame)
+	}
+
+	d := node.Duration()
+	frame.SpanCount++
+	frame.TotalTime += d
+	frame.durations = append(frame.durations, d)
+	if !seen[frame] {
+		seen[frame] = true
+		frame.TraceCount++
+	}
+
+	for _, c := range node.Children {
+		frame.Children = mergeAggregatedFrame(frame.Children, c, seen)
+	}
+
+	return frames
+}
+
+func finalizeAggregatedFrames(frames []*AggregatedFrame) {
+	for _, f := range frames {
+		f.Average = f.TotalTime / time.Duration(len(f.durations))
+		f.P95 = percentileDuration(f.durations, 0.95)
+		f.durations = nil
+		finalizeAggregatedFrames(f.Children)
+	}
+	sort.SliceStable(frames, func(i, j int) bool {
+		if frames[i].TotalTime != frames[j].TotalTime {
+			return frames[i].TotalTime > frames[j].TotalTime
+		}
+		if frames[i].ServiceName != frames[j].ServiceName {
+			return frames[i].ServiceName < frames[j].ServiceName
+		}
+		return frames[i].SpanName < frames[j].SpanName
+	})
+}
+
+// percentileDuration returns the percentile of the durations with the nearest-rank method
+func percentileDuration(durations []time.Duration, p float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := make([]time.Duration, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(rank, 0)]
+}
+
+// AggregateFlameGraph returns the flame graph merged from the traces in the store whose root span
+// has the given name
+func (s *Store) AggregateFlameGraph(rootSpanName string) *AggregatedFlameGraph {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	return NewAggregatedFlameGraph(rootSpanName, s.tracecache.GetTracesByRootSpanName(rootSpanName))
+}
@@ -0,0 +1,99 @@
+package telemetry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/test"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+func TestNewAggregatedFlameGraph(t *testing.T) {
+	// A 0-100, B 0-40, C 20-90, D 10-30, E 30-60, F 50-80
+	trace1 := newTestSpanTreeSpans(t)
+	// A 0-200, B 0-40, C 20-190, D 10-30, E 30-60, E 50-80
+	trace2 := newTestSpanTreeSpans(t)
+	base := trace2[0].Span.StartTimestamp().AsTime()
+	trace2[0].Span.SetEndTimestamp(pcommon.NewTimestampFromTime(base.Add(200 * time.Millisecond)))
+	trace2[2].Span.SetEndTimestamp(pcommon.NewTimestampFromTime(base.Add(190 * time.Millisecond)))
+	trace2[5].Span.SetName(trace2[4].Span.Name())
+	// the root span is B
+	trace3 := newTestSpanTreeSpans(t)[1:]
+
+	g := NewAggregatedFlameGraph("span-0-0-0", [][]*SpanData{trace1, trace2, trace3})
+
+	assert.Equal(t, "span-0-0-0", g.RootSpanName)
+	assert.Equal(t, 2, g.TraceCount)
+	assert.Equal(t, 1, len(g.Roots))
+
+	a := g.Roots[0]
+	assert.Equal(t, "test-service-1", a.ServiceName)
+	assert.Equal(t, "span-0-0-0", a.SpanName)
+	assert.Equal(t, 2, a.SpanCount)
+	assert.Equal(t, 2, a.TraceCount)
+	assert.Equal(t, 300*time.Millisecond, a.TotalTime)
+	assert.Equal(t, 150*time.Millisecond, a.Average)
+	assert.Equal(t, 200*time.Millisecond, a.P95)
+	assert.Equal(t, 150*time.Millisecond, g.AverageTimePerTrace(a))
+
+	// the children are sorted by the total time
+	assert.Equal(t, 2, len(a.Children))
+	c := a.Children[0]
+	assert.Equal(t, "span-0-0-2", c.SpanName)
+	assert.Equal(t, 240*time.Millisecond, c.TotalTime)
+	assert.Equal(t, 120*time.Millisecond, c.Average)
+	assert.Equal(t, 170*time.Millisecond, c.P95)
+	assert.Equal(t, "span-0-0-1", a.Children[1].SpanName)
+	assert.Equal(t, 1, len(a.Children[1].Children))
+
+	// the spans with the same name under the same parent are merged
+	assert.Equal(t, 2, len(c.Children))
+	e := c.Children[0]
+	assert.Equal(t, "span-0-0-4", e.SpanName)
+	assert.Equal(t, 3, e.SpanCount)
+	assert.Equal(t, 2, e.TraceCount)
+	assert.Equal(t, 90*time.Millisecond, e.TotalTime)
+	assert.Equal(t, 45*time.Millisecond, g.AverageTimePerTrace(e))
+	f := c.Children[1]
+	assert.Equal(t, "span-0-0-5", f.SpanName)
+	assert.Equal(t, 1, f.SpanCount)
+	assert.Equal(t, 1, f.TraceCount)
+
+	t.Run("no traces", func(t *testing.T) {
+		g := NewAggregatedFlameGraph("span-0-0-0", nil)
+
+		assert.Equal(t, 0, g.TraceCount)
+		assert.Empty(t, g.Roots)
+	})
+}
+
+func TestStoreAggregateFlameGraph(t *testing.T) {
+	store := NewStore(clockwork.NewRealClock())
+	payload, _ := test.GenerateOTLPTracesPayload(t, 1, 1, []int{1}, [][]int{{3}})
+	store.AddSpan(&payload)
+
+	// all spans are roots because their parents are not received
+	g := store.AggregateFlameGraph("span-0-0-1")
+
+	assert.Equal(t, 1, g.TraceCount)
+	assert.Equal(t, 1, len(g.Roots))
+	assert.Equal(t, 200*time.Millisecond, g.Roots[0].Average)
+
+	g = store.AggregateFlameGraph("unknown")
+
+	assert.Equal(t, 0, g.TraceCount)
+	assert.Empty(t, g.Roots)
+}
+
+func TestPercentileDuration(t *testing.T) {
+	durations := []time.Duration{}
+	for i := range 20 {
+		durations = append(durations, time.Duration(20-i)*time.Millisecond)
+	}
+
+	assert.Equal(t, 19*time.Millisecond, percentileDuration(durations, 0.95))
+	assert.Equal(t, 10*time.Millisecond, percentileDuration(durations, 0.5))
+	assert.Equal(t, time.Duration(0), percentileDuration(nil, 0.95))
+}
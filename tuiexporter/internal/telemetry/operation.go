@@ -0,0 +1,116 @@
+package telemetry
+
+import (
+	"sort"
+	"time"
+)
+
+// LatencyBucketBounds are the upper bounds of the buckets of the latency histograms. The last bucket
+// of a histogram counts the durations over the last bound.
+var LatencyBucketBounds = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// OperationStats is the aggregate of the spans with the same service and span name
+type OperationStats struct {
+	ServiceName string
+	SpanName    string
+	Count       int
+	ErrorCount  int
+	P50         time.Duration
+	P90         time.Duration
+	P99         time.Duration
+	// BucketCounts are the numbers of the spans in the buckets of LatencyBucketBounds,
+	// which has one more bucket than the bounds
+	BucketCounts []int
+	durations    []time.Duration
+}
+
+// ErrorRate returns the ratio of the spans with the error status
+func (o *OperationStats) ErrorRate() float64 {
+	if o.Count == 0 {
+		return 0
+	}
+	return float64(o.ErrorCount) / float64(o.Count)
+}
+
+// NewOperationStats aggregates the spans by the service and span name. The result is sorted by the
+// service and span name.
+func NewOperationStats(spans []*SpanData) []*OperationStats {
+	type key struct{ service, name string }
+	byKey := map[key]*OperationStats{}
+	for _, sd := range spans {
+		k := key{sd.GetServiceName(), sd.GetSpanName()}
+		o, ok := byKey[k]
+		if !ok {
+			o = &OperationStats{
+				ServiceName:  k.service,
+				SpanName:     k.name,
+				BucketCounts: make([]int, len(LatencyBucketBounds)+1),
+			}
+			byKey[k] = o
+		}
+		d := sd.Span.EndTimestamp().AsTime().Sub(sd.Span.StartTimestamp().AsTime())
+		o.Count++
+		if spanHasError(sd.Span) {
+			o.ErrorCount++
+		}
+		o.BucketCounts[latencyBucketIndex(d)]++
+		o.durations = append(o.durations, d)
+	}
+
+	stats := make([]*OperationStats, 0, len(byKey))
+	for _, o := range byKey {
+		o.P50 = percentileDuration(o.durations, 0.5)
+		o.P90 = percentileDuration(o.durations, 0.9)
+		o.P99 = percentileDuration(o.durations, 0.99)
+		o.durations = nil
+		stats = append(stats, o)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].ServiceName != stats[j].ServiceName {
+			return stats[i].ServiceName < stats[j].ServiceName
+		}
+		return stats[i].SpanName < stats[j].SpanName
+	})
+
+	return stats
+}
+
+func latencyBucketIndex(d time.Duration) int {
+	return sort.Search(len(LatencyBucketBounds), func(i int) bool {
+		return d <= LatencyBucketBounds[i]
+	})
+}
+
+// getOperationStats returns the aggregates of the spans in the cache by the service and span name.
+// If the service is not empty, only the spans of the service are aggregated.
+// It must be called while the store is locked.
+func (c *TraceCache) getOperationStats(service string) []*OperationStats {
+	spans := []*SpanData{}
+	for _, sd := range c.spanid2span {
+		if service == "" || sd.GetServiceName() == service {
+			spans = append(spans, sd)
+		}
+	}
+	return NewOperationStats(spans)
+}
+
+// OperationStats returns the aggregates of the spans of the service in the store by the span name
+func (s *Store) OperationStats(service string) []*OperationStats {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	return s.tracecache.getOperationStats(service)
+}
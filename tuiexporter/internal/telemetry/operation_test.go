@@ -0,0 +1,81 @@
+package telemetry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/test"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestNewOperationStats(t *testing.T) {
+	// A 0-100, B 0-40, C 20-90, E 10-30, E 30-60, E 50-650 (error)
+	sds := newTestSpanTreeSpans(t)
+	base := sds[0].Span.StartTimestamp().AsTime()
+	sds[3].Span.SetName(sds[4].Span.Name())
+	sds[5].Span.SetName(sds[4].Span.Name())
+	sds[5].Span.SetEndTimestamp(pcommon.NewTimestampFromTime(base.Add(650 * time.Millisecond)))
+	sds[5].Span.Status().SetCode(ptrace.StatusCodeError)
+
+	stats := NewOperationStats(sds)
+
+	// sorted by the service and span name
+	assert.Equal(t, 4, len(stats))
+	assert.Equal(t, "span-0-0-0", stats[0].SpanName)
+	assert.Equal(t, "span-0-0-1", stats[1].SpanName)
+	assert.Equal(t, "span-0-0-2", stats[2].SpanName)
+
+	a := stats[0]
+	assert.Equal(t, "test-service-1", a.ServiceName)
+	assert.Equal(t, 1, a.Count)
+	assert.Equal(t, 0, a.ErrorCount)
+	assert.Equal(t, 0.0, a.ErrorRate())
+	assert.Equal(t, 100*time.Millisecond, a.P50)
+	assert.Equal(t, 100*time.Millisecond, a.P99)
+
+	e := stats[3]
+	assert.Equal(t, "span-0-0-4", e.SpanName)
+	assert.Equal(t, 3, e.Count)
+	assert.Equal(t, 1, e.ErrorCount)
+	assert.InDelta(t, 1.0/3, e.ErrorRate(), 0.0001)
+	assert.Equal(t, 30*time.Millisecond, e.P50)
+	assert.Equal(t, 600*time.Millisecond, e.P90)
+	assert.Equal(t, 600*time.Millisecond, e.P99)
+	// 20ms <= 25ms, 30ms <= 50ms and 600ms <= 1s
+	assert.Equal(t, []int{0, 0, 0, 1, 1, 0, 0, 0, 1, 0, 0, 0, 0}, e.BucketCounts)
+
+	t.Run("no spans", func(t *testing.T) {
+		assert.Empty(t, NewOperationStats(nil))
+	})
+}
+
+func TestLatencyBucketIndex(t *testing.T) {
+	assert.Equal(t, 0, latencyBucketIndex(0))
+	assert.Equal(t, 0, latencyBucketIndex(time.Millisecond))
+	assert.Equal(t, 1, latencyBucketIndex(time.Millisecond+1))
+	assert.Equal(t, len(LatencyBucketBounds)-1, latencyBucketIndex(10*time.Second))
+	assert.Equal(t, len(LatencyBucketBounds), latencyBucketIndex(time.Minute))
+}
+
+func TestStoreOperationStats(t *testing.T) {
+	store := NewStore(clockwork.NewRealClock())
+	payload, _ := test.GenerateOTLPTracesPayload(t, 1, 2, []int{1, 1}, [][]int{{2}, {1}})
+	store.AddSpan(&payload)
+
+	stats := store.OperationStats("")
+
+	assert.Equal(t, 3, len(stats))
+
+	stats = store.OperationStats("test-service-2")
+
+	assert.Equal(t, 1, len(stats))
+	assert.Equal(t, "span-1-0-0", stats[0].SpanName)
+	assert.Equal(t, 1, stats[0].Count)
+	assert.Equal(t, 200*time.Millisecond, stats[0].P50)
+	assert.Equal(t, 1, stats[0].BucketCounts[6])
+
+	assert.Empty(t, store.OperationStats("unknown"))
+}
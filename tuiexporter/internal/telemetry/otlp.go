@@ -0,0 +1,160 @@
+package telemetry
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// Signal is a type of telemetry data
+type Signal string
+
+const (
+	SignalTraces  Signal = "traces"
+	SignalMetrics Signal = "metrics"
+	SignalLogs    Signal = "logs"
+)
+
+// AllSignals is the list of all signals
+var AllSignals = []Signal{SignalTraces, SignalMetrics, SignalLogs}
+
+// ParseSignals parses a comma separated list of signals such as "traces,logs".
+// An empty string is parsed as all signals.
+func ParseSignals(s string) ([]Signal, error) {
+	if s == "" {
+		return AllSignals, nil
+	}
+	signals := []Signal{}
+	for _, v := range strings.Split(s, ",") {
+		switch sig := Signal(strings.TrimSpace(v)); sig {
+		case SignalTraces, SignalMetrics, SignalLogs:
+			signals = append(signals, sig)
+		default:
+			return nil, fmt.Errorf("unknown signal: %s", v)
+		}
+	}
+	return signals, nil
+}
+
+// SpansToTraces converts spans into ptrace.Traces grouped by their resource and scope.
+// The spans are copied so the returned data is safe to use outside the store.
+func SpansToTraces(spans []*SpanData) ptrace.Traces {
+	traces := ptrace.NewTraces()
+	rsmap := map[*ptrace.ResourceSpans]ptrace.ResourceSpans{}
+	ssmap := map[*ptrace.ScopeSpans]ptrace.ScopeSpans{}
+
+	for _, sd := range spans {
+		rs, ok := rsmap[sd.ResourceSpan]
+		if !ok {
+			rs = traces.ResourceSpans().AppendEmpty()
+			sd.ResourceSpan.Resource().CopyTo(rs.Resource())
+			rs.SetSchemaUrl(sd.ResourceSpan.SchemaUrl())
+			rsmap[sd.ResourceSpan] = rs
+		}
+		ss, ok := ssmap[sd.ScopeSpans]
+		if !ok {
+			ss = rs.ScopeSpans().AppendEmpty()
+			sd.ScopeSpans.Scope().CopyTo(ss.Scope())
+			ss.SetSchemaUrl(sd.ScopeSpans.SchemaUrl())
+			ssmap[sd.ScopeSpans] = ss
+		}
+		sd.Span.CopyTo(ss.Spans().AppendEmpty())
+	}
+
+	return traces
+}
+
+// MetricsToPmetric converts metrics into pmetric.Metrics grouped by their resource and scope.
+// The metrics are copied so the returned data is safe to use outside the store.
+func MetricsToPmetric(metrics []*MetricData) pmetric.Metrics {
+	result := pmetric.NewMetrics()
+	rmmap := map[*pmetric.ResourceMetrics]pmetric.ResourceMetrics{}
+	smmap := map[*pmetric.ScopeMetrics]pmetric.ScopeMetrics{}
+
+	for _, md := range metrics {
+		rm, ok := rmmap[md.ResourceMetric]
+		if !ok {
+			rm = result.ResourceMetrics().AppendEmpty()
+			md.ResourceMetric.Resource().CopyTo(rm.Resource())
+			rm.SetSchemaUrl(md.ResourceMetric.SchemaUrl())
+			rmmap[md.ResourceMetric] = rm
+		}
+		sm, ok := smmap[md.ScopeMetric]
+		if !ok {
+			sm = rm.ScopeMetrics().AppendEmpty()
+			md.ScopeMetric.Scope().CopyTo(sm.Scope())
+			sm.SetSchemaUrl(md.ScopeMetric.SchemaUrl())
+			smmap[md.ScopeMetric] = sm
+		}
+		md.Metric.CopyTo(sm.Metrics().AppendEmpty())
+	}
+
+	return result
+}
+
+// LogsToPlog converts logs into plog.Logs grouped by their resource and scope.
+// The logs are copied so the returned data is safe to use outside the store.
+func LogsToPlog(logs []*LogData) plog.Logs {
+	result := plog.NewLogs()
+	rlmap := map[*plog.ResourceLogs]plog.ResourceLogs{}
+	slmap := map[*plog.ScopeLogs]plog.ScopeLogs{}
+
+	for _, ld := range logs {
+		rl, ok := rlmap[ld.ResourceLog]
+		if !ok {
+			rl = result.ResourceLogs().AppendEmpty()
+			ld.ResourceLog.Resource().CopyTo(rl.Resource())
+			rl.SetSchemaUrl(ld.ResourceLog.SchemaUrl())
+			rlmap[ld.ResourceLog] = rl
+		}
+		sl, ok := slmap[ld.ScopeLog]
+		if !ok {
+			sl = rl.ScopeLogs().AppendEmpty()
+			ld.ScopeLog.Scope().CopyTo(sl.Scope())
+			sl.SetSchemaUrl(ld.ScopeLog.SchemaUrl())
+			slmap[ld.ScopeLog] = sl
+		}
+		ld.Log.CopyTo(sl.LogRecords().AppendEmpty())
+	}
+
+	return result
+}
+
+// WriteJSONLines writes the data as OTLP JSON lines, one line per signal, which can be
+// re-imported by the otlpjsonfile receiver (--from-json-file). Empty data is skipped.
+func WriteJSONLines(w io.Writer, traces *ptrace.Traces, metrics *pmetric.Metrics, logs *plog.Logs) error {
+	lines := [][]byte{}
+
+	if traces != nil && traces.SpanCount() > 0 {
+		b, err := (&ptrace.JSONMarshaler{}).MarshalTraces(*traces)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, b)
+	}
+	if metrics != nil && metrics.MetricCount() > 0 {
+		b, err := (&pmetric.JSONMarshaler{}).MarshalMetrics(*metrics)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, b)
+	}
+	if logs != nil && logs.LogRecordCount() > 0 {
+		b, err := (&plog.JSONMarshaler{}).MarshalLogs(*logs)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, b)
+	}
+
+	for _, line := range lines {
+		if _, err := w.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
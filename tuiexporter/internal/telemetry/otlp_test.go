@@ -0,0 +1,83 @@
+package telemetry
+
+import (
+	"testing"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/test"
+)
+
+func TestSpansToTraces(t *testing.T) {
+	store := NewStore(clockwork.NewRealClock())
+	payload, _ := test.GenerateOTLPTracesPayload(t, 1, 2, []int{2, 1}, [][]int{{2, 1}, {1}})
+	store.AddSpan(&payload)
+
+	got := SpansToTraces(store.allSpans())
+
+	assert.Equal(t, payload.SpanCount(), got.SpanCount())
+	assert.Equal(t, 2, got.ResourceSpans().Len())
+	for i := 0; i < got.ResourceSpans().Len(); i++ {
+		rs := got.ResourceSpans().At(i)
+		svc := GetServiceNameFromResource(rs.Resource())
+		for j := 0; j < rs.ScopeSpans().Len(); j++ {
+			ss := rs.ScopeSpans().At(j)
+			for k := 0; k < ss.Spans().Len(); k++ {
+				sd, ok := store.tracecache.GetSpanByID(ss.Spans().At(k).SpanID().String())
+				assert.True(t, ok)
+				assert.Equal(t, svc, sd.GetServiceName())
+				assert.Equal(t, sd.ScopeSpans.Scope().Name(), ss.Scope().Name())
+			}
+		}
+	}
+}
+
+func TestMetricsToPmetric(t *testing.T) {
+	store := NewStore(clockwork.NewRealClock())
+	payload, _ := test.GenerateOTLPGaugeMetricsPayload(t, 2, []int{2, 1}, [][]int{{2, 1}, {1}})
+	store.AddMetric(&payload)
+
+	got := MetricsToPmetric(store.metrics)
+
+	assert.Equal(t, payload.MetricCount(), got.MetricCount())
+	assert.Equal(t, payload.DataPointCount(), got.DataPointCount())
+	assert.Equal(t, 2, got.ResourceMetrics().Len())
+	assert.Equal(t, 2, got.ResourceMetrics().At(0).ScopeMetrics().Len())
+}
+
+func TestLogsToPlog(t *testing.T) {
+	store := NewStore(clockwork.NewRealClock())
+	payload, _ := test.GenerateOTLPLogsPayload(t, 1, 2, []int{2, 1}, [][]int{{2, 1}, {1}})
+	store.AddLog(&payload)
+
+	got := LogsToPlog(store.logs)
+
+	assert.Equal(t, payload.LogRecordCount(), got.LogRecordCount())
+	assert.Equal(t, 2, got.ResourceLogs().Len())
+	assert.Equal(t, 2, got.ResourceLogs().At(0).ScopeLogs().Len())
+}
+
+func TestParseSignals(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []Signal
+		wantErr bool
+	}{
+		{input: "", want: AllSignals},
+		{input: "traces", want: []Signal{SignalTraces}},
+		{input: "logs, metrics", want: []Signal{SignalLogs, SignalMetrics}},
+		{input: "spans", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseSignals(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
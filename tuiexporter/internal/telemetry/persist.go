@@ -0,0 +1,426 @@
+package telemetry
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// MAX_SEGMENT_SIZE is the size of the appended segment to trigger the compaction
+const MAX_SEGMENT_SIZE = 64 << 20
+
+const (
+	segmentExt  = ".seg"
+	snapshotExt = ".snap"
+	tmpExt      = ".tmp"
+)
+
+type recordType byte
+
+const (
+	recordTypeTraces recordType = iota + 1
+	recordTypeMetrics
+	recordTypeLogs
+)
+
+// recordHeaderSize is the size of the record header (1 byte record type + 8 bytes received time
+// in unix nanoseconds + 4 bytes payload length)
+const recordHeaderSize = 13
+
+type segment struct {
+	seq      int
+	snapshot bool
+	path     string
+}
+
+// Persister persists the telemetry data in the store to the data directory so that it can be
+// restored after a restart.
+//
+// The data directory contains a snapshot of the store and segments of the data received after
+// the snapshot. Both are sequences of records consisting of the signal type, the time the data
+// was received, the payload length and the OTLP protobuf payload. The data is restored with the
+// time it was received so that the retention window is kept, and the data already out of the
+// window is skipped. When the appended segment grows larger than MAX_SEGMENT_SIZE, a new
+// snapshot is taken and the older files are removed.
+type Persister struct {
+	mut   sync.Mutex
+	dir   string
+	store *Store
+	seq   int
+	file  *os.File
+	size  int64
+}
+
+// NewPersister creates a new persister for the store writing to the given directory
+func NewPersister(dir string, store *Store) (*Persister, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create the data directory: %w", err)
+	}
+	return &Persister{
+		dir:   dir,
+		store: store,
+	}, nil
+}
+
+// Restore replays the persisted data through the store and compacts it into a new snapshot
+func (p *Persister) Restore() error {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	segments, err := p.listSegments()
+	if err != nil {
+		return err
+	}
+
+	// replay the latest snapshot and the segments appended after it
+	start := 0
+	for i, seg := range segments {
+		if seg.snapshot {
+			start = i
+		}
+	}
+	for _, seg := range segments[start:] {
+		if err := p.replay(seg.path); err != nil {
+			return err
+		}
+	}
+	if len(segments) > 0 {
+		p.seq = segments[len(segments)-1].seq
+	}
+
+	return p.snapshot()
+}
+
+// AddSpan appends the traces to the current segment and adds them to the store.
+// The store is not updated if the traces cannot be persisted so that a retry does not
+// duplicate them.
+func (p *Persister) AddSpan(traces *ptrace.Traces) error {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	payload, err := (&ptrace.ProtoMarshaler{}).MarshalTraces(*traces)
+	if err != nil {
+		return err
+	}
+	receivedAt := p.store.clockwork.Now()
+	if err := p.append(recordTypeTraces, receivedAt, payload); err != nil {
+		return err
+	}
+	// NOTE: The store is updated with the lock held so that a snapshot taken in between
+	//   does not duplicate or lose the data
+	p.store.addSpanAt(traces, receivedAt)
+	p.compact()
+
+	return nil
+}
+
+// AddMetric appends the metrics to the current segment and adds them to the store.
+// The store is not updated if the metrics cannot be persisted.
+func (p *Persister) AddMetric(metrics *pmetric.Metrics) error {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	payload, err := (&pmetric.ProtoMarshaler{}).MarshalMetrics(*metrics)
+	if err != nil {
+		return err
+	}
+	receivedAt := p.store.clockwork.Now()
+	if err := p.append(recordTypeMetrics, receivedAt, payload); err != nil {
+		return err
+	}
+	p.store.addMetricAt(metrics, receivedAt)
+	p.compact()
+
+	return nil
+}
+
+// AddLog appends the logs to the current segment and adds them to the store.
+// The store is not updated if the logs cannot be persisted.
+func (p *Persister) AddLog(logs *plog.Logs) error {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	payload, err := (&plog.ProtoMarshaler{}).MarshalLogs(*logs)
+	if err != nil {
+		return err
+	}
+	receivedAt := p.store.clockwork.Now()
+	if err := p.append(recordTypeLogs, receivedAt, payload); err != nil {
+		return err
+	}
+	p.store.addLogAt(logs, receivedAt)
+	p.compact()
+
+	return nil
+}
+
+// Snapshot writes all data in the store to a new snapshot and removes the older files
+func (p *Persister) Snapshot() error {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	return p.snapshot()
+}
+
+// Close closes the current segment
+func (p *Persister) Close() error {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	return p.closeSegment()
+}
+
+func (p *Persister) append(typ recordType, receivedAt time.Time, payload []byte) error {
+	if p.file == nil {
+		f, err := os.OpenFile(p.segmentPath(p.seq+1, segmentExt), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return err
+		}
+		p.seq++
+		p.file = f
+		p.size = 0
+	}
+
+	n, err := writeRecord(p.file, typ, receivedAt, payload)
+	p.size += int64(n)
+	if err != nil {
+		// NOTE: The next record is appended to a new segment as the partially written record
+		//   ends the replay of this segment
+		_ = p.closeSegment()
+		return err
+	}
+	return nil
+}
+
+// compact takes a new snapshot when the current segment grows larger than MAX_SEGMENT_SIZE.
+// The error is logged instead of returned as the data is already persisted in the segment.
+func (p *Persister) compact() {
+	if p.size <= MAX_SEGMENT_SIZE {
+		return
+	}
+	if err := p.snapshot(); err != nil {
+		log.Printf("failed to compact the data directory: %v", err)
+	}
+}
+
+func (p *Persister) snapshot() error {
+	seq := p.seq + 1
+	tmpPath := p.segmentPath(seq, snapshotExt+tmpExt)
+
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := p.writeSnapshot(f); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, p.segmentPath(seq, snapshotExt)); err != nil {
+		return err
+	}
+
+	if err := p.closeSegment(); err != nil {
+		return err
+	}
+	p.seq = seq
+
+	return p.removeSegmentsBefore(seq)
+}
+
+func (p *Persister) writeSnapshot(f *os.File) error {
+	w := bufio.NewWriter(f)
+
+	for _, traces := range p.store.snapshotTraces() {
+		payload, err := (&ptrace.ProtoMarshaler{}).MarshalTraces(traces.data)
+		if err != nil {
+			return err
+		}
+		if _, err := writeRecord(w, recordTypeTraces, traces.receivedAt, payload); err != nil {
+			return err
+		}
+	}
+	for _, metrics := range p.store.snapshotMetrics() {
+		payload, err := (&pmetric.ProtoMarshaler{}).MarshalMetrics(metrics.data)
+		if err != nil {
+			return err
+		}
+		if _, err := writeRecord(w, recordTypeMetrics, metrics.receivedAt, payload); err != nil {
+			return err
+		}
+	}
+	for _, logs := range p.store.snapshotLogs() {
+		payload, err := (&plog.ProtoMarshaler{}).MarshalLogs(logs.data)
+		if err != nil {
+			return err
+		}
+		if _, err := writeRecord(w, recordTypeLogs, logs.receivedAt, payload); err != nil {
+			return err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+func (p *Persister) closeSegment() error {
+	if p.file == nil {
+		return nil
+	}
+	err := p.file.Close()
+	p.file = nil
+	p.size = 0
+	return err
+}
+
+func (p *Persister) replay(path string) error {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for {
+		typ, receivedAt, payload, err := readRecord(r)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			// NOTE: The last record can be truncated when otel-tui crashed while writing it
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if p.store.isExpired(receivedAt) {
+			continue
+		}
+
+		switch typ {
+		case recordTypeTraces:
+			traces, err := (&ptrace.ProtoUnmarshaler{}).UnmarshalTraces(payload)
+			if err != nil {
+				return fmt.Errorf("failed to read traces in %s: %w", path, err)
+			}
+			p.store.addSpanAt(&traces, receivedAt)
+		case recordTypeMetrics:
+			metrics, err := (&pmetric.ProtoUnmarshaler{}).UnmarshalMetrics(payload)
+			if err != nil {
+				return fmt.Errorf("failed to read metrics in %s: %w", path, err)
+			}
+			p.store.addMetricAt(&metrics, receivedAt)
+		case recordTypeLogs:
+			logs, err := (&plog.ProtoUnmarshaler{}).UnmarshalLogs(payload)
+			if err != nil {
+				return fmt.Errorf("failed to read logs in %s: %w", path, err)
+			}
+			p.store.addLogAt(&logs, receivedAt)
+		default:
+			return fmt.Errorf("unknown record type %d in %s", typ, path)
+		}
+	}
+}
+
+func (p *Persister) listSegments() ([]segment, error) {
+	entries, err := os.ReadDir(p.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	segments := []segment{}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		name := e.Name()
+		var (
+			base     string
+			snapshot bool
+		)
+		if b, ok := strings.CutSuffix(name, segmentExt); ok {
+			base = b
+		} else if b, ok := strings.CutSuffix(name, snapshotExt); ok {
+			base, snapshot = b, true
+		} else {
+			continue
+		}
+		seq, err := strconv.Atoi(base)
+		if err != nil {
+			continue
+		}
+		segments = append(segments, segment{
+			seq:      seq,
+			snapshot: snapshot,
+			path:     filepath.Join(p.dir, name),
+		})
+	}
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].seq < segments[j].seq
+	})
+
+	return segments, nil
+}
+
+func (p *Persister) removeSegmentsBefore(seq int) error {
+	entries, err := os.ReadDir(p.dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !(strings.HasSuffix(name, segmentExt) || strings.HasSuffix(name, snapshotExt) || strings.HasSuffix(name, tmpExt)) {
+			continue
+		}
+		s, err := strconv.Atoi(strings.SplitN(name, ".", 2)[0])
+		if err != nil || s >= seq {
+			continue
+		}
+		if err := os.Remove(filepath.Join(p.dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Persister) segmentPath(seq int, ext string) string {
+	return filepath.Join(p.dir, fmt.Sprintf("%08d%s", seq, ext))
+}
+
+func writeRecord(w io.Writer, typ recordType, receivedAt time.Time, payload []byte) (int, error) {
+	buf := make([]byte, recordHeaderSize+len(payload))
+	buf[0] = byte(typ)
+	binary.BigEndian.PutUint64(buf[1:9], uint64(receivedAt.UnixNano()))
+	binary.BigEndian.PutUint32(buf[9:recordHeaderSize], uint32(len(payload)))
+	copy(buf[recordHeaderSize:], payload)
+	return w.Write(buf)
+}
+
+func readRecord(r io.Reader) (recordType, time.Time, []byte, error) {
+	header := make([]byte, recordHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, time.Time{}, nil, err
+	}
+	receivedAt := time.Unix(0, int64(binary.BigEndian.Uint64(header[1:9])))
+	payload := make([]byte, binary.BigEndian.Uint32(header[9:]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, time.Time{}, nil, err
+	}
+	return recordType(header[0]), receivedAt, payload, nil
+}
@@ -0,0 +1,196 @@
+package telemetry
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/test"
+)
+
+func TestPersisterRestore(t *testing.T) {
+	dir := t.TempDir()
+
+	store := NewStore(clockwork.NewRealClock())
+	p, err := NewPersister(dir, store)
+	require.NoError(t, err)
+	require.NoError(t, p.Restore())
+
+	tp, testdata := test.GenerateOTLPTracesPayload(t, 1, 2, []int{2, 1}, [][]int{{2, 1}, {1}})
+	mp, testmetrics := test.GenerateOTLPGaugeMetricsPayload(t, 2, []int{2, 1}, [][]int{{2, 1}, {1}})
+	lp, testlogs := test.GenerateOTLPLogsPayload(t, 1, 2, []int{2, 1}, [][]int{{2, 1}, {1}})
+	require.NoError(t, p.AddSpan(&tp))
+	require.NoError(t, p.AddMetric(&mp))
+	require.NoError(t, p.AddLog(&lp))
+	// close without taking a snapshot like a crash
+	require.NoError(t, p.Close())
+
+	restored := NewStore(clockwork.NewRealClock())
+	p2, err := NewPersister(dir, restored)
+	require.NoError(t, err)
+	require.NoError(t, p2.Restore())
+	defer p2.Close()
+
+	assert.Equal(t, len(store.svcspans), len(restored.svcspans))
+	assert.Equal(t, len(testdata.Spans), len(restored.tracecache.spanid2span))
+	for _, want := range testdata.Spans {
+		got, ok := restored.tracecache.GetSpanByID(want.SpanID().String())
+		assert.True(t, ok)
+		assert.Equal(t, want.Name(), got.Span.Name())
+		assert.Equal(t, want.TraceID(), got.Span.TraceID())
+	}
+	assert.Equal(t, len(testmetrics.Metrics), len(restored.metrics))
+	assert.Equal(t, len(testlogs.Logs), len(restored.logs))
+	assert.Equal(t, "test-service-1", restored.logs[0].ResourceLog.Resource().Attributes().AsRaw()["service.name"])
+
+	// the restored data is compacted into a snapshot
+	segments, err := p2.listSegments()
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(segments))
+	assert.True(t, segments[0].snapshot)
+}
+
+func TestPersisterRestoreTruncatedRecord(t *testing.T) {
+	dir := t.TempDir()
+
+	store := NewStore(clockwork.NewRealClock())
+	p, err := NewPersister(dir, store)
+	require.NoError(t, err)
+	require.NoError(t, p.Restore())
+
+	tp, _ := test.GenerateOTLPTracesPayload(t, 1, 1, []int{1}, [][]int{{2}})
+	require.NoError(t, p.AddSpan(&tp))
+	// write a header without the payload
+	_, err = p.file.Write([]byte{byte(recordTypeTraces), 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0})
+	require.NoError(t, err)
+	require.NoError(t, p.Close())
+
+	restored := NewStore(clockwork.NewRealClock())
+	p2, err := NewPersister(dir, restored)
+	require.NoError(t, err)
+	require.NoError(t, p2.Restore())
+	defer p2.Close()
+
+	assert.Equal(t, 2, len(restored.tracecache.spanid2span))
+}
+
+func TestPersisterRestoreUnknownRecord(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00000001.seg"), []byte{99, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 0o600))
+
+	p, err := NewPersister(dir, NewStore(clockwork.NewRealClock()))
+	require.NoError(t, err)
+	assert.Error(t, p.Restore())
+}
+
+func TestPersisterSnapshot(t *testing.T) {
+	dir := t.TempDir()
+
+	store := NewStore(clockwork.NewRealClock())
+	store.maxLogCount = 1
+	p, err := NewPersister(dir, store)
+	require.NoError(t, err)
+	require.NoError(t, p.Restore())
+
+	lp, testlogs := test.GenerateOTLPLogsPayload(t, 1, 1, []int{1}, [][]int{{2}})
+	require.NoError(t, p.AddLog(&lp))
+	require.NoError(t, p.Snapshot())
+	require.NoError(t, p.Close())
+
+	segments, err := p.listSegments()
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(segments))
+	assert.True(t, segments[0].snapshot)
+
+	// the rotated logs are not persisted in the snapshot
+	restored := NewStore(clockwork.NewRealClock())
+	p2, err := NewPersister(dir, restored)
+	require.NoError(t, err)
+	require.NoError(t, p2.Restore())
+	defer p2.Close()
+
+	assert.Equal(t, 1, len(restored.logs))
+	assert.Equal(t, testlogs.Logs[3].Body().AsString(), restored.logs[0].Log.Body().AsString())
+}
+
+func TestPersisterRestoreRetention(t *testing.T) {
+	tests := []struct {
+		name     string
+		snapshot bool
+	}{
+		{name: "segment", snapshot: false},
+		{name: "snapshot", snapshot: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			clock := clockwork.NewFakeClockAt(base)
+
+			store := NewStoreWithConfig(clock, StoreConfig{Retention: time.Hour})
+			p, err := NewPersister(dir, store)
+			require.NoError(t, err)
+			require.NoError(t, p.Restore())
+
+			oldLogs, _ := test.GenerateOTLPLogsPayload(t, 1, 1, []int{1}, [][]int{{1}})
+			require.NoError(t, p.AddLog(&oldLogs))
+			clock.Advance(40 * time.Minute)
+			newLogs, testlogs := test.GenerateOTLPLogsPayload(t, 1, 1, []int{1}, [][]int{{2}})
+			require.NoError(t, p.AddLog(&newLogs))
+			if tt.snapshot {
+				require.NoError(t, p.Snapshot())
+			}
+			require.NoError(t, p.Close())
+
+			// the logs received first are out of the window when restored
+			clock.Advance(30 * time.Minute)
+			restored := NewStoreWithConfig(clock, StoreConfig{Retention: time.Hour})
+			p2, err := NewPersister(dir, restored)
+			require.NoError(t, err)
+			require.NoError(t, p2.Restore())
+			defer p2.Close()
+
+			require.Equal(t, len(testlogs.Logs), len(restored.logs))
+			for _, l := range restored.logs {
+				assert.True(t, l.ReceivedAt.Equal(base.Add(40*time.Minute)))
+			}
+		})
+	}
+}
+
+func TestPersisterAddFailure(t *testing.T) {
+	dir := t.TempDir()
+
+	store := NewStore(clockwork.NewRealClock())
+	p, err := NewPersister(dir, store)
+	require.NoError(t, err)
+	require.NoError(t, p.Restore())
+
+	lp, testlogs := test.GenerateOTLPLogsPayload(t, 1, 1, []int{1}, [][]int{{1}})
+	require.NoError(t, p.AddLog(&lp))
+	// make the next append fail
+	require.NoError(t, p.file.Close())
+
+	lp2, testlogs2 := test.GenerateOTLPLogsPayload(t, 1, 1, []int{1}, [][]int{{2}})
+	want := len(testlogs.Logs) + len(testlogs2.Logs)
+	assert.Error(t, p.AddLog(&lp2))
+	assert.Equal(t, len(testlogs.Logs), len(store.logs))
+
+	// the retry is appended to a new segment
+	require.NoError(t, p.AddLog(&lp2))
+	assert.Equal(t, want, len(store.logs))
+	require.NoError(t, p.Close())
+
+	restored := NewStore(clockwork.NewRealClock())
+	p2, err := NewPersister(dir, restored)
+	require.NoError(t, err)
+	require.NoError(t, p2.Restore())
+	defer p2.Close()
+
+	assert.Equal(t, want, len(restored.logs))
+}
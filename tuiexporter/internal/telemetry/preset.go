@@ -0,0 +1,199 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// PRESET_FILE_NAME is the name of the preset file in the otel-tui directory of the user config dir
+const PRESET_FILE_NAME = "presets.json"
+
+// Preset is a named filter of the traces, metrics or logs page
+type Preset struct {
+	Name       string   `json:"name"`
+	Signal     Signal   `json:"signal"`
+	Query      string   `json:"query"`
+	Regex      bool     `json:"regex,omitempty"`
+	IgnoreCase bool     `json:"ignore_case,omitempty"`
+	SortType   SortType `json:"sort_type,omitempty"`
+}
+
+// MatchMode returns the match mode of the query
+func (p Preset) MatchMode() MatchMode {
+	return MatchMode{Regex: p.Regex, IgnoreCase: p.IgnoreCase}
+}
+
+// Validate checks if the preset has a name, a known signal, a valid query and a known sort type
+func (p Preset) Validate() error {
+	if p.Name == "" {
+		return errors.New("preset name is empty")
+	}
+	switch p.Signal {
+	case SignalTraces, SignalMetrics, SignalLogs:
+	default:
+		return fmt.Errorf("unknown signal: %s", p.Signal)
+	}
+	switch p.SortType {
+	case "", SORT_TYPE_NONE:
+	case SORT_TYPE_LATENCY_DESC, SORT_TYPE_LATENCY_ASC:
+		if p.Signal != SignalTraces {
+			return fmt.Errorf("sort type %s is only available for traces", p.SortType)
+		}
+	default:
+		return fmt.Errorf("unknown sort type: %s", p.SortType)
+	}
+	if _, err := ParseQuery(p.Query, p.MatchMode()); err != nil {
+		return fmt.Errorf("invalid query: %w", err)
+	}
+	return nil
+}
+
+// GetSortType returns the sort type of the preset. An empty sort type is SORT_TYPE_NONE.
+func (p Preset) GetSortType() SortType {
+	if p.SortType == "" {
+		return SORT_TYPE_NONE
+	}
+	return p.SortType
+}
+
+type presetFile struct {
+	Presets []Preset `json:"presets"`
+}
+
+// PresetStore keeps the filter presets and saves them to a JSON file so that they can be
+// used across sessions. The presets are identified by the signal and the name.
+type PresetStore struct {
+	mut     sync.RWMutex
+	path    string
+	presets []Preset
+}
+
+// DefaultPresetPath returns the path of the preset file in the user config dir
+// (e.g. ~/.config/otel-tui/presets.json)
+func DefaultPresetPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "otel-tui", PRESET_FILE_NAME), nil
+}
+
+// NewPresetStore loads the presets from the file. A missing file is treated as no presets.
+// If the path is empty, the presets are kept only in memory.
+func NewPresetStore(path string) (*PresetStore, error) {
+	ps := &PresetStore{
+		path:    path,
+		presets: []Preset{},
+	}
+	if path == "" {
+		return ps, nil
+	}
+
+	b, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return ps, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f presetFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("failed to read presets in %s: %w", path, err)
+	}
+	for _, p := range f.Presets {
+		if err := p.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid preset %q in %s: %w", p.Name, path, err)
+		}
+		ps.presets = append(ps.presets, p)
+	}
+
+	return ps, nil
+}
+
+// List returns the presets of the signal sorted by name. An empty signal returns all presets.
+func (ps *PresetStore) List(signal Signal) []Preset {
+	ps.mut.RLock()
+	defer ps.mut.RUnlock()
+
+	presets := []Preset{}
+	for _, p := range ps.presets {
+		if signal == "" || p.Signal == signal {
+			presets = append(presets, p)
+		}
+	}
+	sort.SliceStable(presets, func(i, j int) bool {
+		if presets[i].Signal != presets[j].Signal {
+			return presets[i].Signal < presets[j].Signal
+		}
+		return presets[i].Name < presets[j].Name
+	})
+	return presets
+}
+
+// Get returns the preset of the signal by name
+func (ps *PresetStore) Get(signal Signal, name string) (Preset, bool) {
+	ps.mut.RLock()
+	defer ps.mut.RUnlock()
+
+	for _, p := range ps.presets {
+		if p.Signal == signal && p.Name == name {
+			return p, true
+		}
+	}
+	return Preset{}, false
+}
+
+// Save adds the preset or replaces the one with the same signal and name, and writes
+// the presets to the file
+func (ps *PresetStore) Save(preset Preset) error {
+	if err := preset.Validate(); err != nil {
+		return err
+	}
+
+	ps.mut.Lock()
+	defer ps.mut.Unlock()
+
+	presets := make([]Preset, 0, len(ps.presets)+1)
+	replaced := false
+	for _, p := range ps.presets {
+		if p.Signal == preset.Signal && p.Name == preset.Name {
+			p = preset
+			replaced = true
+		}
+		presets = append(presets, p)
+	}
+	if !replaced {
+		presets = append(presets, preset)
+	}
+
+	if err := ps.write(presets); err != nil {
+		return err
+	}
+	ps.presets = presets
+	return nil
+}
+
+func (ps *PresetStore) write(presets []Preset) error {
+	if ps.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(ps.path), 0o700); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(presetFile{Presets: presets}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// NOTE: Write to a temporary file first so that a crash does not leave a broken file
+	tmpPath := ps.path + tmpExt
+	if err := os.WriteFile(tmpPath, append(b, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, ps.path)
+}
@@ -0,0 +1,98 @@
+package telemetry
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPresetStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "otel-tui", PRESET_FILE_NAME)
+
+	ps, err := NewPresetStore(path)
+	require.NoError(t, err)
+	assert.Empty(t, ps.List(""))
+
+	errorPreset := Preset{Name: "errors", Signal: SignalTraces, Query: "status = error", SortType: SORT_TYPE_LATENCY_DESC}
+	slow := Preset{Name: "slow", Signal: SignalTraces, Query: "duration > 1s"}
+	warn := Preset{Name: "warn", Signal: SignalLogs, Query: "WARN|ERROR", Regex: true, IgnoreCase: true}
+	require.NoError(t, ps.Save(slow))
+	require.NoError(t, ps.Save(errorPreset))
+	require.NoError(t, ps.Save(warn))
+
+	assert.Equal(t, []Preset{errorPreset, slow}, ps.List(SignalTraces))
+	assert.Equal(t, []Preset{warn}, ps.List(SignalLogs))
+	assert.Empty(t, ps.List(SignalMetrics))
+	assert.Equal(t, []Preset{warn, errorPreset, slow}, ps.List(""))
+
+	got, ok := ps.Get(SignalLogs, "warn")
+	assert.True(t, ok)
+	assert.Equal(t, MatchMode{Regex: true, IgnoreCase: true}, got.MatchMode())
+	_, ok = ps.Get(SignalTraces, "warn")
+	assert.False(t, ok)
+
+	// replace the preset with the same name
+	slow.Query = "duration > 2s"
+	require.NoError(t, ps.Save(slow))
+	assert.Equal(t, []Preset{errorPreset, slow}, ps.List(SignalTraces))
+
+	// the presets are restored from the file
+	restored, err := NewPresetStore(path)
+	require.NoError(t, err)
+	assert.Equal(t, ps.List(""), restored.List(""))
+	_, err = os.Stat(path + tmpExt)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestPresetStoreInMemory(t *testing.T) {
+	ps, err := NewPresetStore("")
+	require.NoError(t, err)
+
+	require.NoError(t, ps.Save(Preset{Name: "api", Signal: SignalMetrics, Query: "service.name = api"}))
+	got, ok := ps.Get(SignalMetrics, "api")
+	assert.True(t, ok)
+	assert.Equal(t, SORT_TYPE_NONE, got.GetSortType())
+}
+
+func TestPresetStoreInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), PRESET_FILE_NAME)
+	require.NoError(t, os.WriteFile(path, []byte(`{"presets":[{"name":"bad","signal":"traces","query":"name ="}]}`), 0o600))
+
+	_, err := NewPresetStore(path)
+	assert.ErrorContains(t, err, `invalid preset "bad"`)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{`), 0o600))
+	_, err = NewPresetStore(path)
+	assert.ErrorContains(t, err, "failed to read presets")
+}
+
+func TestPresetValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		preset  Preset
+		wantErr string
+	}{
+		{name: "valid", preset: Preset{Name: "a", Signal: SignalTraces, Query: "status = error", SortType: SORT_TYPE_LATENCY_ASC}},
+		{name: "empty query", preset: Preset{Name: "a", Signal: SignalLogs}},
+		{name: "empty name", preset: Preset{Signal: SignalTraces}, wantErr: "preset name is empty"},
+		{name: "unknown signal", preset: Preset{Name: "a", Signal: "profiles"}, wantErr: "unknown signal: profiles"},
+		{name: "unknown sort type", preset: Preset{Name: "a", Signal: SignalTraces, SortType: "name"}, wantErr: "unknown sort type: name"},
+		{name: "sort type of logs", preset: Preset{Name: "a", Signal: SignalLogs, SortType: SORT_TYPE_LATENCY_DESC}, wantErr: "sort type latency-desc is only available for traces"},
+		{name: "invalid query", preset: Preset{Name: "a", Signal: SignalLogs, Query: "(a"}, wantErr: `invalid query: unexpected end of query at position 3, expected ")"`},
+		{name: "invalid regex", preset: Preset{Name: "a", Signal: SignalLogs, Query: "a(", Regex: true}, wantErr: "invalid query: invalid regex \"a(\": missing closing ): `a(`"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.preset.Validate()
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
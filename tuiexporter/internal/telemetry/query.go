@@ -0,0 +1,777 @@
+package telemetry
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// Query is a parsed filter query such as
+//
+//	service.name = "api" AND http.status_code >= 500 AND duration > 200ms
+//
+// A query consists of comparisons (`field op value`) and free text terms combined with
+// AND, OR, NOT and parentheses. The operators are =, !=, >, >=, <, <=, ~ (contains) and
+// !~ (does not contain). A free text term matches the service name and the span name,
+// metric name or log body with strings.Contains, so a plain text without any query
+// syntax works as a simple substring filter.
+//
+// Fields are looked up in the following order:
+//   - built-in fields of each signal (e.g. name, duration, status, body, severity)
+//   - `resource.<key>` for a resource attribute and `scope.<key>` for a scope attribute
+//   - `trace.<key>` for an attribute of any span in the same trace (spans only)
+//   - the attributes of the span, log or metric data points, then the scope attributes
+//     and the resource attributes
+//
+// With MatchMode, free text terms and the values of ~ and !~ are matched as regular
+// expressions and/or case-insensitively. The regular expressions are compiled once
+// when the query is parsed.
+type Query struct {
+	expr      queryExpr
+	plainText bool
+}
+
+// MatchMode is the mode of matching texts in the filter query
+type MatchMode struct {
+	// Regex makes free text terms and the values of ~ and !~ regular expressions
+	Regex bool
+	// IgnoreCase makes text matching and string equality case-insensitive
+	IgnoreCase bool
+}
+
+// ParseQuery parses the filter query. It returns nil for an empty query.
+func ParseQuery(s string, mode MatchMode) (*Query, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	tokens, err := tokenizeQuery(s)
+	if err != nil && !mode.Regex {
+		return nil, err
+	}
+
+	// NOTE: A text without any query syntax is treated as a substring (or a pattern in
+	//   regex mode) as it is so that the existing filters keep working
+	if err != nil || isPlainTextQuery(tokens, mode.Regex) {
+		matcher, err := newTextMatcher(s, mode)
+		if err != nil {
+			return nil, err
+		}
+		return &Query{expr: &textTerm{matcher: matcher}, plainText: true}, nil
+	}
+
+	p := &queryParser{tokens: tokens, mode: mode}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %s at position %d, expected AND or OR", tok, tok.pos+1)
+	}
+
+	return &Query{expr: expr}, nil
+}
+
+// TraceAttributeCondition returns a query condition matching the traces which have a span
+// with the attribute (e.g. `"trace.http.route" = "/checkout"`)
+func TraceAttributeCondition(key, value string) string {
+	return quoteQueryString("trace."+key) + " = " + quoteQueryString(value)
+}
+
+// OperationCondition returns a query condition matching the spans with the service and span name
+// (e.g. `service.name = "api" AND name = "GET /checkout"`)
+func OperationCondition(service, spanName string) string {
+	return "service.name = " + quoteQueryString(service) + " AND name = " + quoteQueryString(spanName)
+}
+
+// ServiceCondition returns a query condition matching the spans of the service
+// (e.g. `service.name = "api"`)
+func ServiceCondition(service string) string {
+	return "service.name = " + quoteQueryString(service)
+}
+
+// CallCondition returns a query condition matching the spans of the target service called by
+// the source service (e.g. `service.name = "db" AND parent.service.name = "api"`)
+func CallCondition(source, target string) string {
+	return ServiceCondition(target) + " AND parent.service.name = " + quoteQueryString(source)
+}
+
+// AttributeCondition returns a query condition matching the spans with the attribute
+// (e.g. `"db.system" = "postgresql"`)
+func AttributeCondition(key, value string) string {
+	return quoteQueryString(key) + " = " + quoteQueryString(value)
+}
+
+// TextCondition returns the filter input as a query condition which can be combined with other
+// conditions. A plain text is quoted as a single free text term (e.g. `"api GET"`) and an input
+// with query syntax is returned as it is.
+func TextCondition(input string, mode MatchMode) string {
+	if q, err := ParseQuery(input, mode); err == nil && q.isPlainText() {
+		return quoteQueryString(input)
+	}
+	return input
+}
+
+func quoteQueryString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// MatchSpan returns true if the span matches the query
+func (q *Query) MatchSpan(sd *SpanData) bool {
+	if q == nil {
+		return true
+	}
+	return q.expr.eval(spanRecord{sd: sd})
+}
+
+// matchSpanInTrace returns true if the span matches the query. `trace.<key>` fields are
+// looked up in all spans of the trace.
+func (q *Query) matchSpanInTrace(sd *SpanData, trace []*SpanData) bool {
+	if q == nil {
+		return true
+	}
+	return q.expr.eval(spanRecord{sd: sd, trace: trace})
+}
+
+// MatchMetric returns true if the metric matches the query
+func (q *Query) MatchMetric(md *MetricData) bool {
+	if q == nil {
+		return true
+	}
+	return q.expr.eval(metricRecord{md})
+}
+
+// MatchLog returns true if the log matches the query
+func (q *Query) MatchLog(ld *LogData) bool {
+	if q == nil {
+		return true
+	}
+	return q.expr.eval(logRecord{ld})
+}
+
+// isPlainText returns true if the query is a plain text without any query syntax
+func (q *Query) isPlainText() bool {
+	return q != nil && q.plainText
+}
+
+// tokenizer
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOperator
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of query"
+	case tokenString:
+		return strconv.Quote(t.value)
+	default:
+		return fmt.Sprintf("%q", t.value)
+	}
+}
+
+func (t token) isKeyword(keyword string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.value, keyword)
+}
+
+const queryOperatorChars = "=!<>~"
+
+func tokenizeQuery(s string) ([]token, error) {
+	tokens := []token{}
+	runes := []rune(s)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, value: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, value: ")", pos: i})
+			i++
+		case r == '"':
+			start := i
+			var sb strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", start+1)
+			}
+			i++
+			tokens = append(tokens, token{kind: tokenString, value: sb.String(), pos: start})
+		case strings.ContainsRune(queryOperatorChars, r):
+			start := i
+			for i < len(runes) && strings.ContainsRune(queryOperatorChars, runes[i]) {
+				i++
+			}
+			op := string(runes[start:i])
+			switch op {
+			case "=", "==", "!=", ">", ">=", "<", "<=", "~", "!~":
+			default:
+				return nil, fmt.Errorf("unknown operator %q at position %d", op, start+1)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, value: op, pos: start})
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune(queryOperatorChars+`()"`, runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenWord, value: string(runes[start:i]), pos: start})
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+// isPlainTextQuery returns true if the tokens have no query syntax. In regex mode,
+// parentheses and quotes are a part of the pattern.
+func isPlainTextQuery(tokens []token, regex bool) bool {
+	for _, tok := range tokens {
+		switch tok.kind {
+		case tokenOperator:
+			return false
+		case tokenString, tokenLParen, tokenRParen:
+			if !regex {
+				return false
+			}
+		case tokenWord:
+			if tok.isKeyword("AND") || tok.isKeyword("OR") || tok.isKeyword("NOT") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// parser
+
+type queryParser struct {
+	tokens []token
+	pos    int
+	mode   MatchMode
+}
+
+func (p *queryParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *queryParser) parseOr() (queryExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().isKeyword("OR") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (queryExpr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().isKeyword("AND") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &andExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseNot() (queryExpr, error) {
+	if p.peek().isKeyword("NOT") {
+		p.next()
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{expr: expr}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *queryParser) parsePrimary() (queryExpr, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenLParen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, fmt.Errorf("unexpected %s at position %d, expected \")\"", closing, closing.pos+1)
+		}
+		return expr, nil
+	case tokenWord, tokenString:
+		if tok.isKeyword("AND") || tok.isKeyword("OR") {
+			return nil, fmt.Errorf("unexpected %s at position %d, expected a condition", tok, tok.pos+1)
+		}
+		if p.peek().kind != tokenOperator {
+			matcher, err := newTextMatcher(tok.value, p.mode)
+			if err != nil {
+				return nil, fmt.Errorf("%w at position %d", err, tok.pos+1)
+			}
+			return &textTerm{matcher: matcher}, nil
+		}
+		// NOTE: A quoted field name is allowed for keys with spaces (e.g. "span index" = 1)
+		op := p.next()
+		value := p.next()
+		if value.kind != tokenWord && value.kind != tokenString {
+			return nil, fmt.Errorf("unexpected %s at position %d, expected a value after %q", value, value.pos+1, op.value)
+		}
+		c, err := newComparison(tok.value, op.value, value, p.mode)
+		if err != nil {
+			return nil, fmt.Errorf("%w at position %d", err, value.pos+1)
+		}
+		return c, nil
+	default:
+		return nil, fmt.Errorf("unexpected %s at position %d, expected a condition", tok, tok.pos+1)
+	}
+}
+
+// expressions
+
+type queryRecord interface {
+	text() string
+	field(name string) []queryValue
+}
+
+type queryExpr interface {
+	eval(r queryRecord) bool
+}
+
+type andExpr struct {
+	left, right queryExpr
+}
+
+func (e *andExpr) eval(r queryRecord) bool {
+	return e.left.eval(r) && e.right.eval(r)
+}
+
+type orExpr struct {
+	left, right queryExpr
+}
+
+func (e *orExpr) eval(r queryRecord) bool {
+	return e.left.eval(r) || e.right.eval(r)
+}
+
+type notExpr struct {
+	expr queryExpr
+}
+
+func (e *notExpr) eval(r queryRecord) bool {
+	return !e.expr.eval(r)
+}
+
+type textTerm struct {
+	matcher *textMatcher
+}
+
+func (e *textTerm) eval(r queryRecord) bool {
+	return e.matcher.match(r.text(), false)
+}
+
+// textMatcher matches a text by a substring or a compiled regular expression
+type textMatcher struct {
+	text       string
+	re         *regexp.Regexp
+	ignoreCase bool
+}
+
+func newTextMatcher(text string, mode MatchMode) (*textMatcher, error) {
+	m := &textMatcher{text: text, ignoreCase: mode.IgnoreCase}
+	if !mode.Regex {
+		return m, nil
+	}
+	pattern := text
+	if mode.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex %q: %s", text, strings.TrimPrefix(err.Error(), "error parsing regexp: "))
+	}
+	m.re = re
+	return m, nil
+}
+
+// match returns true if s matches. fold forces the case-insensitive substring match.
+func (m *textMatcher) match(s string, fold bool) bool {
+	if m.re != nil {
+		return m.re.MatchString(s)
+	}
+	if m.ignoreCase || fold {
+		return strings.Contains(strings.ToLower(s), strings.ToLower(m.text))
+	}
+	return strings.Contains(s, m.text)
+}
+
+type literalKind int
+
+const (
+	literalString literalKind = iota
+	literalNumber
+	literalDuration
+)
+
+type comparison struct {
+	field      string
+	op         string
+	kind       literalKind
+	str        string
+	num        float64
+	dur        time.Duration
+	matcher    *textMatcher
+	ignoreCase bool
+}
+
+func newComparison(field, op string, value token, mode MatchMode) (*comparison, error) {
+	c := &comparison{field: field, op: op, kind: literalString, str: value.value, ignoreCase: mode.IgnoreCase}
+	switch op {
+	case "==":
+		c.op = "="
+	case "~", "!~":
+		matcher, err := newTextMatcher(value.value, mode)
+		if err != nil {
+			return nil, err
+		}
+		c.matcher = matcher
+		return c, nil
+	}
+	if value.kind == tokenString {
+		return c, nil
+	}
+	if n, err := strconv.ParseFloat(value.value, 64); err == nil {
+		c.kind, c.num = literalNumber, n
+	} else if d, err := time.ParseDuration(value.value); err == nil {
+		c.kind, c.dur = literalDuration, d
+	}
+	return c, nil
+}
+
+func (c *comparison) eval(r queryRecord) bool {
+	values := r.field(c.field)
+	if len(values) == 0 {
+		// a missing field never equals or contains the value
+		return c.op == "!=" || c.op == "!~"
+	}
+	for _, v := range values {
+		if c.match(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *comparison) match(v queryValue) bool {
+	if c.matcher != nil {
+		return c.matcher.match(v.str, v.fold) == (c.op == "~")
+	}
+
+	switch c.kind {
+	case literalDuration:
+		if !v.isDur {
+			return false
+		}
+		return compareOrdered(v.dur, c.dur, c.op)
+	case literalNumber:
+		if v.isNum {
+			return compareOrdered(v.num, c.num, c.op)
+		}
+		if n, err := strconv.ParseFloat(v.str, 64); err == nil {
+			return compareOrdered(n, c.num, c.op)
+		}
+	}
+
+	lhs, rhs := v.str, c.str
+	if c.ignoreCase || v.fold {
+		lhs, rhs = strings.ToLower(lhs), strings.ToLower(rhs)
+	}
+	return compareOrdered(lhs, rhs, c.op)
+}
+
+func compareOrdered[T int64 | float64 | time.Duration | string](a, b T, op string) bool {
+	switch op {
+	case "=":
+		return a == b
+	case "!=":
+		return a != b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	default:
+		return false
+	}
+}
+
+// values
+
+type queryValue struct {
+	str   string
+	num   float64
+	isNum bool
+	dur   time.Duration
+	isDur bool
+	// fold is true for the enumerated fields compared case-insensitively (e.g. status)
+	fold bool
+}
+
+func stringValue(s string) []queryValue {
+	return []queryValue{{str: s}}
+}
+
+func foldValue(s string) []queryValue {
+	return []queryValue{{str: s, fold: true}}
+}
+
+func durationValue(d time.Duration) []queryValue {
+	return []queryValue{{str: d.String(), dur: d, isDur: true}}
+}
+
+func attributeValue(v pcommon.Value) queryValue {
+	switch v.Type() {
+	case pcommon.ValueTypeInt:
+		return queryValue{str: v.AsString(), num: float64(v.Int()), isNum: true}
+	case pcommon.ValueTypeDouble:
+		return queryValue{str: v.AsString(), num: v.Double(), isNum: true}
+	default:
+		return queryValue{str: v.AsString()}
+	}
+}
+
+// lookupAttributes looks up the key in the attributes (e.g. span attributes), then in the
+// scope attributes and the resource attributes. `resource.<key>` and `scope.<key>` are
+// looked up only in the resource and scope attributes respectively.
+func lookupAttributes(name string, attrs []pcommon.Map, scope, resource pcommon.Map) []queryValue {
+	if key, ok := strings.CutPrefix(name, "resource."); ok {
+		if v, ok := resource.Get(key); ok {
+			return []queryValue{attributeValue(v)}
+		}
+	}
+	if key, ok := strings.CutPrefix(name, "scope."); ok {
+		if v, ok := scope.Get(key); ok {
+			return []queryValue{attributeValue(v)}
+		}
+	}
+	values := []queryValue{}
+	for _, m := range attrs {
+		if v, ok := m.Get(name); ok {
+			values = append(values, attributeValue(v))
+		}
+	}
+	if len(values) > 0 {
+		return values
+	}
+	if v, ok := scope.Get(name); ok {
+		return []queryValue{attributeValue(v)}
+	}
+	if v, ok := resource.Get(name); ok {
+		return []queryValue{attributeValue(v)}
+	}
+	return nil
+}
+
+type spanRecord struct {
+	sd *SpanData
+	// trace is all spans in the trace of sd. It is nil when the span is matched alone.
+	trace []*SpanData
+}
+
+func (r spanRecord) text() string {
+	return r.sd.GetServiceName() + " " + r.sd.Span.Name()
+}
+
+func (r spanRecord) field(name string) []queryValue {
+	span := r.sd.Span
+	switch name {
+	case "name", "span.name":
+		return stringValue(span.Name())
+	case "service", "service.name":
+		return stringValue(r.sd.GetServiceName())
+	case "duration":
+		return durationValue(span.EndTimestamp().AsTime().Sub(span.StartTimestamp().AsTime()))
+	case "status", "status.code":
+		return foldValue(span.Status().Code().String())
+	case "status.message":
+		return stringValue(span.Status().Message())
+	case "kind", "span.kind":
+		return foldValue(span.Kind().String())
+	case "trace_id":
+		return stringValue(span.TraceID().String())
+	case "span_id":
+		return stringValue(span.SpanID().String())
+	case "parent_span_id":
+		return stringValue(span.ParentSpanID().String())
+	case "scope.name":
+		return stringValue(r.sd.ScopeSpans.Scope().Name())
+	case "parent.service", "parent.service.name":
+		return r.parentServiceField()
+	}
+	if key, ok := strings.CutPrefix(name, "trace."); ok {
+		return r.traceField(key)
+	}
+	return lookupAttributes(name, []pcommon.Map{span.Attributes()}, r.sd.ScopeSpans.Scope().Attributes(), r.sd.ResourceSpan.Resource().Attributes())
+}
+
+// parentServiceField returns the service name of the parent span if it is in the trace
+func (r spanRecord) parentServiceField() []queryValue {
+	parentID := r.sd.Span.ParentSpanID()
+	for _, sd := range r.trace {
+		if sd.Span.SpanID() == parentID {
+			return stringValue(sd.GetServiceName())
+		}
+	}
+	return []queryValue{}
+}
+
+// traceField looks up the key in the span, scope and resource attributes of all spans in the trace
+func (r spanRecord) traceField(key string) []queryValue {
+	spans := r.trace
+	if spans == nil {
+		spans = []*SpanData{r.sd}
+	}
+	values := []queryValue{}
+	for _, sd := range spans {
+		for _, m := range []pcommon.Map{sd.Span.Attributes(), sd.ScopeSpans.Scope().Attributes(), sd.ResourceSpan.Resource().Attributes()} {
+			if v, ok := m.Get(key); ok {
+				values = append(values, attributeValue(v))
+			}
+		}
+	}
+	return values
+}
+
+type metricRecord struct {
+	md *MetricData
+}
+
+func (r metricRecord) text() string {
+	return r.md.GetServiceName() + " " + r.md.Metric.Name()
+}
+
+func (r metricRecord) field(name string) []queryValue {
+	metric := r.md.Metric
+	switch name {
+	case "name", "metric.name":
+		return stringValue(metric.Name())
+	case "service", "service.name":
+		return stringValue(r.md.GetServiceName())
+	case "type", "metric.type":
+		return foldValue(r.md.GetMetricTypeText())
+	case "unit":
+		return stringValue(metric.Unit())
+	case "description":
+		return stringValue(metric.Description())
+	case "scope.name":
+		return stringValue(r.md.ScopeMetric.Scope().Name())
+	}
+	return lookupAttributes(name, dataPointAttributes(metric), r.md.ScopeMetric.Scope().Attributes(), r.md.ResourceMetric.Resource().Attributes())
+}
+
+func dataPointAttributes(metric *pmetric.Metric) []pcommon.Map {
+	attrs := []pcommon.Map{}
+	switch metric.Type() {
+	case pmetric.MetricTypeGauge:
+		for i := 0; i < metric.Gauge().DataPoints().Len(); i++ {
+			attrs = append(attrs, metric.Gauge().DataPoints().At(i).Attributes())
+		}
+	case pmetric.MetricTypeSum:
+		for i := 0; i < metric.Sum().DataPoints().Len(); i++ {
+			attrs = append(attrs, metric.Sum().DataPoints().At(i).Attributes())
+		}
+	case pmetric.MetricTypeHistogram:
+		for i := 0; i < metric.Histogram().DataPoints().Len(); i++ {
+			attrs = append(attrs, metric.Histogram().DataPoints().At(i).Attributes())
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		for i := 0; i < metric.ExponentialHistogram().DataPoints().Len(); i++ {
+			attrs = append(attrs, metric.ExponentialHistogram().DataPoints().At(i).Attributes())
+		}
+	case pmetric.MetricTypeSummary:
+		for i := 0; i < metric.Summary().DataPoints().Len(); i++ {
+			attrs = append(attrs, metric.Summary().DataPoints().At(i).Attributes())
+		}
+	}
+	return attrs
+}
+
+type logRecord struct {
+	ld *LogData
+}
+
+func (r logRecord) text() string {
+	return r.ld.GetServiceName() + " " + r.ld.Log.Body().AsString()
+}
+
+func (r logRecord) field(name string) []queryValue {
+	log := r.ld.Log
+	switch name {
+	case "body":
+		return stringValue(log.Body().AsString())
+	case "service", "service.name":
+		return stringValue(r.ld.GetServiceName())
+	case "severity":
+		return foldValue(log.SeverityText())
+	case "severity_number":
+		return []queryValue{{str: strconv.Itoa(int(log.SeverityNumber())), num: float64(log.SeverityNumber()), isNum: true}}
+	case "event_name":
+		return stringValue(r.ld.GetEventName())
+	case "trace_id":
+		return stringValue(log.TraceID().String())
+	case "span_id":
+		return stringValue(log.SpanID().String())
+	case "scope.name":
+		return stringValue(r.ld.ScopeLog.Scope().Name())
+	}
+	return lookupAttributes(name, []pcommon.Map{log.Attributes()}, r.ld.ScopeLog.Scope().Attributes(), r.ld.ResourceLog.Resource().Attributes())
+}
@@ -0,0 +1,313 @@
+package telemetry
+
+import (
+	"testing"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/test"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantNil   bool
+		wantPlain bool
+		wantErr   string
+	}{
+		{name: "empty", input: "", wantNil: true},
+		{name: "plain text", input: "GET /users", wantPlain: true},
+		{name: "comparison", input: `service.name = "api"`},
+		{name: "and or not", input: `NOT status = error AND (duration > 200ms OR http.status_code >= 500)`},
+		{name: "lower case keywords", input: `name ~ users and kind = server`},
+		{name: "quoted field", input: `"span index" = 1`},
+		{name: "unterminated string", input: `service.name = "api`, wantErr: "unterminated string at position 16"},
+		{name: "unknown operator", input: `duration => 1s`, wantErr: `unknown operator "=>" at position 10`},
+		{name: "missing value", input: `duration >`, wantErr: `unexpected end of query at position 11, expected a value after ">"`},
+		{name: "missing condition", input: `name = a AND`, wantErr: "unexpected end of query at position 13, expected a condition"},
+		{name: "missing operator", input: `name = a b`, wantErr: `unexpected "b" at position 10, expected AND or OR`},
+		{name: "unclosed paren", input: `(name = a`, wantErr: `unexpected end of query at position 10, expected ")"`},
+		{name: "leading keyword", input: `AND name = a`, wantErr: `unexpected "AND" at position 1, expected a condition`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseQuery(tt.input, MatchMode{})
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			if tt.wantNil {
+				assert.Nil(t, got)
+				return
+			}
+			assert.NotNil(t, got)
+			assert.Equal(t, tt.wantPlain, got.isPlainText())
+		})
+	}
+}
+
+func TestQueryMatchSpan(t *testing.T) {
+	payload, testdata := test.GenerateOTLPTracesPayload(t, 1, 1, []int{1}, [][]int{{1}})
+	testdata.Spans[0].Attributes().PutInt("http.status_code", 503)
+	testdata.Spans[0].Attributes().PutStr("http.route", "/users/{id}")
+	testdata.Spans[0].SetKind(ptrace.SpanKindServer)
+	rs := payload.ResourceSpans().At(0)
+	ss := rs.ScopeSpans().At(0)
+	sd := &SpanData{Span: testdata.Spans[0], ResourceSpan: &rs, ScopeSpans: &ss}
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{query: "test-service-1 span-0-0-0", want: true},
+		{query: "span-0-0-1", want: false},
+		{query: `service.name = "test-service-1"`, want: true},
+		{query: `service.name = test-service-2`, want: false},
+		{query: `http.status_code >= 500`, want: true},
+		{query: `http.status_code < 500`, want: false},
+		{query: `http.status_code = "503"`, want: true},
+		{query: `duration > 100ms AND duration <= 200ms`, want: true},
+		{query: `duration > 1s`, want: false},
+		{query: `status = ok AND kind = SERVER`, want: true},
+		{query: `status != ok OR kind = client`, want: false},
+		{query: `NOT status = error`, want: true},
+		{query: `http.route ~ users`, want: true},
+		{query: `http.route !~ users`, want: false},
+		{query: `"resource attribute" = "resource attribute value"`, want: true},
+		{query: `resource.service.name = "test-service-1"`, want: true},
+		{query: `"scope index" = 0`, want: true},
+		{query: `"scope.scope index" = 0`, want: true},
+		{query: `"scope.resource attribute" = "resource attribute value"`, want: false},
+		{query: `"trace.http.route" = "/users/{id}"`, want: true},
+		{query: `"trace.scope index" = 0 AND "trace.resource index" = 0`, want: true},
+		{query: `trace.http.route = "/checkout"`, want: false},
+		{query: `"span index" = 0 AND "span-0-0-0"`, want: true},
+		{query: `unknown.key = 1`, want: false},
+		{query: `unknown.key != 1`, want: true},
+		{query: `(http.status_code = 404 OR http.status_code = 503) AND name = span-0-0-0`, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := ParseQuery(tt.query, MatchMode{})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, q.MatchSpan(sd))
+		})
+	}
+}
+
+func TestTraceAttributeCondition(t *testing.T) {
+	payload, testdata := test.GenerateOTLPTracesPayload(t, 1, 2, []int{1, 1}, [][]int{{1}, {1}})
+	testdata.Spans[1].Attributes().PutStr("db.statement", `SELECT "name" FROM users WHERE path = 'C:\tmp'`)
+	spans := []*SpanData{}
+	for i, span := range testdata.Spans {
+		rs := payload.ResourceSpans().At(i)
+		ss := rs.ScopeSpans().At(0)
+		spans = append(spans, &SpanData{Span: span, ResourceSpan: &rs, ScopeSpans: &ss})
+	}
+
+	cond := TraceAttributeCondition("db.statement", `SELECT "name" FROM users WHERE path = 'C:\tmp'`)
+	assert.Equal(t, `"trace.db.statement" = "SELECT \"name\" FROM users WHERE path = 'C:\\tmp'"`, cond)
+
+	q, err := ParseQuery(cond, MatchMode{})
+	assert.NoError(t, err)
+	// the attribute of the other span in the trace
+	assert.True(t, q.matchSpanInTrace(spans[0], spans))
+	assert.False(t, q.MatchSpan(spans[0]))
+	assert.True(t, q.MatchSpan(spans[1]))
+}
+
+func TestTextCondition(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		mode  MatchMode
+		want  string
+	}{
+		{
+			name:  "plain text",
+			input: "api GET",
+			want:  `"api GET"`,
+		},
+		{
+			name:  "plain text with quotes",
+			input: `api "GET`,
+			mode:  MatchMode{Regex: true},
+			want:  `"api \"GET"`,
+		},
+		{
+			name:  "query",
+			input: `service.name = "api"`,
+			want:  `service.name = "api"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, TextCondition(tt.input, tt.mode))
+		})
+	}
+
+	t.Run("combined with a condition", func(t *testing.T) {
+		store := NewStore(clockwork.NewRealClock())
+		payload, testdata := test.GenerateOTLPTracesPayload(t, 1, 2, []int{1, 1}, [][]int{{1}, {1}})
+		testdata.Spans[0].Attributes().PutStr("http.route", "/checkout")
+		store.AddSpan(&payload)
+
+		cond := "(" + TextCondition("service-1 span-0", MatchMode{}) + ") AND " + TraceAttributeCondition("http.route", "/checkout")
+		err := store.ApplyFilterTraces(cond, MatchMode{}, SORT_TYPE_NONE)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(*store.GetFilteredSvcSpans()))
+		assert.Equal(t, "test-service-1", (*store.GetFilteredSvcSpans())[0].GetServiceName())
+	})
+}
+
+func TestOperationCondition(t *testing.T) {
+	store := NewStore(clockwork.NewRealClock())
+	payload, _ := test.GenerateOTLPTracesPayload(t, 1, 2, []int{1, 1}, [][]int{{2}, {1}})
+	store.AddSpan(&payload)
+
+	cond := OperationCondition("test-service-1", "span-0-0-1")
+	assert.Equal(t, `service.name = "test-service-1" AND name = "span-0-0-1"`, cond)
+
+	err := store.ApplyFilterTraces(cond, MatchMode{}, SORT_TYPE_NONE)
+	assert.NoError(t, err)
+	// the service span of the trace having the span
+	assert.Equal(t, 1, len(*store.GetFilteredSvcSpans()))
+	assert.Equal(t, "test-service-1", (*store.GetFilteredSvcSpans())[0].GetServiceName())
+}
+
+func TestCallCondition(t *testing.T) {
+	store := NewStore(clockwork.NewRealClock())
+	// test-service-1: span-0-0-0 -> test-service-2: span-1-0-0
+	payload, testdata := test.GenerateOTLPTracesPayload(t, 1, 2, []int{1, 1}, [][]int{{1}, {1}})
+	testdata.Spans[1].SetParentSpanID(testdata.Spans[0].SpanID())
+	store.AddSpan(&payload)
+
+	assert.Equal(t, `service.name = "test-service-1"`, ServiceCondition("test-service-1"))
+
+	cond := CallCondition("test-service-1", "test-service-2")
+	assert.Equal(t, `service.name = "test-service-2" AND parent.service.name = "test-service-1"`, cond)
+
+	err := store.ApplyFilterTraces(cond, MatchMode{}, SORT_TYPE_NONE)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(*store.GetFilteredSvcSpans()))
+	assert.Equal(t, "test-service-2", (*store.GetFilteredSvcSpans())[0].GetServiceName())
+
+	// no calls in the other direction
+	err = store.ApplyFilterTraces(CallCondition("test-service-2", "test-service-1"), MatchMode{}, SORT_TYPE_NONE)
+	assert.NoError(t, err)
+	assert.Empty(t, *store.GetFilteredSvcSpans())
+}
+
+func TestQueryMatchMetric(t *testing.T) {
+	store := NewStore(clockwork.NewRealClock())
+	payload, _ := test.GenerateOTLPGaugeMetricsPayload(t, 2, []int{2, 1}, [][]int{{2, 1}, {1}})
+	store.AddMetric(&payload)
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{query: "metric 0", want: 2},
+		{query: `service.name = test-service-2`, want: 1},
+		{query: `type = gauge AND unit = "test unit"`, want: 3},
+		{query: `"dp index" = 1`, want: 1},
+		{query: `"dp index" >= 0 AND name = "metric 0-1"`, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := ParseQuery(tt.query, MatchMode{})
+			assert.NoError(t, err)
+			got := 0
+			for _, md := range store.metrics {
+				if q.MatchMetric(md) {
+					got++
+				}
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestQueryMatchLog(t *testing.T) {
+	store := NewStore(clockwork.NewRealClock())
+	payload, _ := test.GenerateOTLPLogsPayload(t, 1, 2, []int{2, 1}, [][]int{{2, 1}, {1}})
+	store.AddLog(&payload)
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{query: "log body 1-0-0-0", want: 1},
+		{query: `body ~ "1-0-0" AND severity = info`, want: 3},
+		{query: `severity_number >= 13`, want: 0},
+		{query: `"span index" = 1 OR service.name = test-service-2`, want: 4},
+		{query: `trace_id = "01000000000000000000000000000000"`, want: 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := ParseQuery(tt.query, MatchMode{})
+			assert.NoError(t, err)
+			got := 0
+			for _, ld := range store.logs {
+				if q.MatchLog(ld) {
+					got++
+				}
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestQueryMatchMode(t *testing.T) {
+	payload, testdata := test.GenerateOTLPTracesPayload(t, 1, 1, []int{1}, [][]int{{1}})
+	testdata.Spans[0].SetName("GET /Users/42")
+	testdata.Spans[0].Attributes().PutStr("http.route", "/Users/{id}")
+	rs := payload.ResourceSpans().At(0)
+	ss := rs.ScopeSpans().At(0)
+	sd := &SpanData{Span: testdata.Spans[0], ResourceSpan: &rs, ScopeSpans: &ss}
+
+	regex := MatchMode{Regex: true}
+	ignoreCase := MatchMode{IgnoreCase: true}
+	both := MatchMode{Regex: true, IgnoreCase: true}
+
+	tests := []struct {
+		name    string
+		query   string
+		mode    MatchMode
+		want    bool
+		wantErr string
+	}{
+		{name: "case sensitive by default", query: "get /users", mode: MatchMode{}, want: false},
+		{name: "ignore case", query: "get /users", mode: ignoreCase, want: true},
+		{name: "ignore case equality", query: `http.route = "/users/{id}"`, mode: ignoreCase, want: true},
+		{name: "ignore case contains", query: `http.route ~ users`, mode: ignoreCase, want: true},
+		{name: "regex plain text", query: `^test-service-\d GET /Users/\d+$`, mode: regex, want: true},
+		{name: "regex with parens", query: `(GET|POST) /Users`, mode: regex, want: true},
+		{name: "regex is case sensitive", query: `get /users`, mode: regex, want: false},
+		{name: "regex ignore case", query: `get /users/\d+`, mode: both, want: true},
+		{name: "regex comparison", query: `http.route ~ "^/Users/\{id\}$" AND name !~ POST`, mode: regex, want: true},
+		{name: "regex equality is exact", query: `name = "GET.*"`, mode: regex, want: false},
+		{name: "invalid regex", query: `GET /Users/(\d+`, mode: regex, wantErr: "invalid regex \"GET /Users/(\\\\d+\": missing closing ): `GET /Users/(\\d+`"},
+		{name: "invalid regex in comparison", query: `name ~ "a("`, mode: regex, wantErr: "invalid regex \"a(\": missing closing ): `a(` at position 8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := ParseQuery(tt.query, tt.mode)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, q.MatchSpan(sd))
+		})
+	}
+}
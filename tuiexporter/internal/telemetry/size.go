@@ -0,0 +1,138 @@
+package telemetry
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// Approximate sizes in bytes of the fixed parts of each record.
+// These are rough numbers including the wrapper structs and map entries in the caches,
+// and they are only used to keep the memory usage of the store predictable.
+const (
+	spanOverheadSize      = 256
+	spanEventOverheadSize = 64
+	spanLinkOverheadSize  = 96
+	metricOverheadSize    = 192
+	dataPointOverheadSize = 64
+	logOverheadSize       = 224
+	valueOverheadSize     = 16
+
+	// The entries of the incremental topology model. They are counted as the model changes
+	// because the samples of a span depend on its parent and children.
+	topologySpanOverheadSize         = 128 // the span and children indexes
+	topologyContributionOverheadSize = 96  // how a span is counted
+	topologySampleOverheadSize       = 80  // a sample of a node or an edge
+	topologyAggregateOverheadSize    = 128 // a node, an edge or a received service
+)
+
+// NOTE: Resource and scope are shared among the records in the same batch,
+// so they are not counted in the estimated size of each record.
+
+func (sd *SpanData) estimateSize() int64 {
+	span := sd.Span
+	size := int64(spanOverheadSize)
+	size += int64(len(span.Name()) + len(span.TraceState().AsRaw()) + len(span.Status().Message()))
+	size += estimateMapSize(span.Attributes())
+	for i := 0; i < span.Events().Len(); i++ {
+		event := span.Events().At(i)
+		size += spanEventOverheadSize + int64(len(event.Name())) + estimateMapSize(event.Attributes())
+	}
+	for i := 0; i < span.Links().Len(); i++ {
+		link := span.Links().At(i)
+		size += spanLinkOverheadSize + int64(len(link.TraceState().AsRaw())) + estimateMapSize(link.Attributes())
+	}
+	return size
+}
+
+func (md *MetricData) estimateSize() int64 {
+	metric := md.Metric
+	size := int64(metricOverheadSize)
+	size += int64(len(metric.Name()) + len(metric.Description()) + len(metric.Unit()))
+
+	switch metric.Type() {
+	case pmetric.MetricTypeGauge:
+		size += estimateNumberDataPointsSize(metric.Gauge().DataPoints())
+	case pmetric.MetricTypeSum:
+		size += estimateNumberDataPointsSize(metric.Sum().DataPoints())
+	case pmetric.MetricTypeHistogram:
+		dps := metric.Histogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			size += dataPointOverheadSize + estimateMapSize(dp.Attributes())
+			size += int64(dp.BucketCounts().Len()+dp.ExplicitBounds().Len()) * 8
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		dps := metric.ExponentialHistogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			size += dataPointOverheadSize + estimateMapSize(dp.Attributes())
+			size += int64(dp.Positive().BucketCounts().Len()+dp.Negative().BucketCounts().Len()) * 8
+		}
+	case pmetric.MetricTypeSummary:
+		dps := metric.Summary().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			size += dataPointOverheadSize + estimateMapSize(dp.Attributes())
+			size += int64(dp.QuantileValues().Len()) * 16
+		}
+	}
+	return size
+}
+
+func (l *LogData) estimateSize() int64 {
+	size := int64(logOverheadSize)
+	size += int64(len(l.Log.SeverityText()) + len(l.Log.EventName()))
+	size += estimateValueSize(l.Log.Body())
+	size += estimateMapSize(l.Log.Attributes())
+	return size
+}
+
+func estimateNumberDataPointsSize(dps pmetric.NumberDataPointSlice) int64 {
+	var size int64
+	for i := 0; i < dps.Len(); i++ {
+		size += dataPointOverheadSize + estimateMapSize(dps.At(i).Attributes())
+	}
+	return size
+}
+
+func estimateMapSize(m pcommon.Map) int64 {
+	var size int64
+	m.Range(func(k string, v pcommon.Value) bool {
+		size += int64(len(k)) + estimateValueSize(v)
+		return true
+	})
+	return size
+}
+
+func estimateValueSize(v pcommon.Value) int64 {
+	size := int64(valueOverheadSize)
+	switch v.Type() {
+	case pcommon.ValueTypeStr:
+		size += int64(len(v.Str()))
+	case pcommon.ValueTypeBytes:
+		size += int64(v.Bytes().Len())
+	case pcommon.ValueTypeMap:
+		size += estimateMapSize(v.Map())
+	case pcommon.ValueTypeSlice:
+		for i := 0; i < v.Slice().Len(); i++ {
+			size += estimateValueSize(v.Slice().At(i))
+		}
+	}
+	return size
+}
+
+// FormatBytes returns a human readable text of the given size in bytes (e.g. 12.3 MiB)
+func FormatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,163 @@
+package telemetry
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// The names of the metrics derived from the spans, which are the same as the spanmetrics connector
+// of the OpenTelemetry Collector Contrib
+const (
+	SpanMetricsCallsName    = "traces.span.metrics.calls"
+	SpanMetricsDurationName = "traces.span.metrics.duration"
+	spanMetricsScopeName    = "otel-tui/spanmetrics"
+)
+
+type spanMetricsKey struct {
+	service string
+	name    string
+	kind    ptrace.SpanKind
+	status  ptrace.StatusCode
+}
+
+type spanMetricsValue struct {
+	calls        uint64
+	sum          float64
+	bucketCounts []uint64
+	startTime    time.Time
+}
+
+// spanMetrics derives the RED (rate, errors and duration) metrics from the spans. The metrics are
+// cumulative and grouped by the service, span name, span kind and status code.
+type spanMetrics struct {
+	values map[spanMetricsKey]*spanMetricsValue
+}
+
+func newSpanMetrics() *spanMetrics {
+	return &spanMetrics{
+		values: map[spanMetricsKey]*spanMetricsValue{},
+	}
+}
+
+// derive adds the spans to the cumulative values and returns the data points of all groups of
+// the services of the spans at the time. The data points are grouped into a resource per service,
+// which replaces the previous one of the service.
+func (m *spanMetrics) derive(spans []*SpanData, now time.Time) pmetric.Metrics {
+	services := map[string]bool{}
+	for _, sd := range spans {
+		k := spanMetricsKey{
+			service: sd.GetServiceName(),
+			name:    sd.Span.Name(),
+			kind:    sd.Span.Kind(),
+			status:  sd.Span.Status().Code(),
+		}
+		v, ok := m.values[k]
+		if !ok {
+			v = &spanMetricsValue{
+				bucketCounts: make([]uint64, len(LatencyBucketBounds)+1),
+				startTime:    now,
+			}
+			m.values[k] = v
+		}
+		d := sd.Span.EndTimestamp().AsTime().Sub(sd.Span.StartTimestamp().AsTime())
+		v.calls++
+		v.sum += durationToMillis(d)
+		v.bucketCounts[latencyBucketIndex(d)]++
+		services[k.service] = true
+	}
+
+	keys := []spanMetricsKey{}
+	for k := range m.values {
+		if services[k.service] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].service != keys[j].service {
+			return keys[i].service < keys[j].service
+		}
+		if keys[i].name != keys[j].name {
+			return keys[i].name < keys[j].name
+		}
+		if keys[i].kind != keys[j].kind {
+			return keys[i].kind < keys[j].kind
+		}
+		return keys[i].status < keys[j].status
+	})
+
+	metrics := pmetric.NewMetrics()
+	var (
+		service  string
+		calls    pmetric.Sum
+		duration pmetric.Histogram
+	)
+	for i, k := range keys {
+		if i == 0 || k.service != service {
+			service = k.service
+			calls, duration = appendSpanMetrics(metrics, service)
+		}
+		v := m.values[k]
+		start, ts := pcommon.NewTimestampFromTime(v.startTime), pcommon.NewTimestampFromTime(now)
+
+		cdp := calls.DataPoints().AppendEmpty()
+		cdp.SetStartTimestamp(start)
+		cdp.SetTimestamp(ts)
+		cdp.SetIntValue(int64(v.calls))
+		putSpanMetricsAttributes(cdp.Attributes(), k)
+
+		hdp := duration.DataPoints().AppendEmpty()
+		hdp.SetStartTimestamp(start)
+		hdp.SetTimestamp(ts)
+		hdp.SetCount(v.calls)
+		hdp.SetSum(v.sum)
+		hdp.BucketCounts().FromRaw(v.bucketCounts)
+		for _, b := range LatencyBucketBounds {
+			hdp.ExplicitBounds().Append(durationToMillis(b))
+		}
+		putSpanMetricsAttributes(hdp.Attributes(), k)
+	}
+
+	return metrics
+}
+
+func (m *spanMetrics) flush() {
+	m.values = map[spanMetricsKey]*spanMetricsValue{}
+}
+
+func appendSpanMetrics(metrics pmetric.Metrics, service string) (pmetric.Sum, pmetric.Histogram) {
+	rm := metrics.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", service)
+	sm := rm.ScopeMetrics().AppendEmpty()
+	sm.Scope().SetName(spanMetricsScopeName)
+
+	cm := sm.Metrics().AppendEmpty()
+	cm.SetName(SpanMetricsCallsName)
+	cm.SetDescription("The number of the spans")
+	calls := cm.SetEmptySum()
+	calls.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	calls.SetIsMonotonic(true)
+
+	dm := sm.Metrics().AppendEmpty()
+	dm.SetName(SpanMetricsDurationName)
+	dm.SetDescription("The durations of the spans")
+	dm.SetUnit("ms")
+	duration := dm.SetEmptyHistogram()
+	duration.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+
+	return calls, duration
+}
+
+func putSpanMetricsAttributes(attrs pcommon.Map, k spanMetricsKey) {
+	attrs.PutStr("span.name", k.name)
+	attrs.PutStr("span.kind", "SPAN_KIND_"+strings.ToUpper(k.kind.String()))
+	attrs.PutStr("status.code", "STATUS_CODE_"+strings.ToUpper(k.status.String()))
+}
+
+func durationToMillis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,157 @@
+package telemetry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/test"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+func TestStoreSpanMetrics(t *testing.T) {
+	// traceid: 1
+	//  └- resource: test-service-1
+	//  | └- scope: test-scope-1-1
+	//  |   └- span: span-0-0-0
+	//  |   └- span: span-0-0-1
+	//  └- resource: test-service-2
+	//    └- scope: test-scope-2-1
+	//      └- span: span-1-0-0
+	start := time.Date(2025, 11, 9, 12, 15, 0, 0, time.UTC)
+	clock := clockwork.NewFakeClockAt(start)
+	store := NewStoreWithConfig(clock, StoreConfig{SpanMetrics: true})
+	payload, _ := test.GenerateOTLPTracesPayload(t, 1, 2, []int{1, 1}, [][]int{{2}, {1}})
+	store.AddSpan(&payload)
+
+	// the calls and the duration of each service
+	assert.Equal(t, 4, len(store.metrics))
+	calls := store.metrics[0]
+	assert.True(t, calls.Derived)
+	assert.Equal(t, "test-service-1", calls.GetServiceName())
+	assert.Equal(t, spanMetricsScopeName, calls.ScopeMetric.Scope().Name())
+	assert.Equal(t, SpanMetricsCallsName, calls.GetMetricName())
+	assert.Equal(t, pmetric.AggregationTemporalityCumulative, calls.Metric.Sum().AggregationTemporality())
+	assert.Equal(t, 2, calls.Metric.Sum().DataPoints().Len())
+	cdp := calls.Metric.Sum().DataPoints().At(0)
+	assert.Equal(t, int64(1), cdp.IntValue())
+	assert.Equal(t, map[string]any{
+		"span.name":   "span-0-0-0",
+		"span.kind":   "SPAN_KIND_INTERNAL",
+		"status.code": "STATUS_CODE_OK",
+	}, cdp.Attributes().AsRaw())
+
+	duration := store.metrics[1]
+	assert.Equal(t, SpanMetricsDurationName, duration.GetMetricName())
+	assert.Equal(t, "ms", duration.Metric.Unit())
+	hdp := duration.Metric.Histogram().DataPoints().At(0)
+	assert.Equal(t, uint64(1), hdp.Count())
+	assert.Equal(t, 200.0, hdp.Sum())
+	assert.Equal(t, len(LatencyBucketBounds), hdp.ExplicitBounds().Len())
+	assert.Equal(t, 1.0, hdp.ExplicitBounds().At(0))
+	assert.Equal(t, uint64(1), hdp.BucketCounts().At(6))
+
+	assert.Equal(t, "test-service-2", store.metrics[2].GetServiceName())
+	assert.Equal(t, 1, store.metrics[2].Metric.Sum().DataPoints().Len())
+
+	t.Run("cumulative", func(t *testing.T) {
+		clock.Advance(time.Minute)
+		payload, _ := test.GenerateOTLPTracesPayload(t, 2, 1, []int{1}, [][]int{{1}})
+		store.AddSpan(&payload)
+
+		// the metrics of the service of the new spans are replaced with all of its groups
+		assert.Equal(t, 4, len(store.metrics))
+		assert.Equal(t, "test-service-2", store.metrics[0].GetServiceName())
+		assert.Equal(t, "test-service-1", store.metrics[2].GetServiceName())
+		assert.Equal(t, 2, store.metrics[2].Metric.Sum().DataPoints().Len())
+		cdp := store.metrics[2].Metric.Sum().DataPoints().At(0)
+		assert.Equal(t, int64(2), cdp.IntValue())
+		assert.Equal(t, start, cdp.StartTimestamp().AsTime())
+		assert.Equal(t, start.Add(time.Minute), cdp.Timestamp().AsTime())
+		assert.Equal(t, int64(1), store.metrics[2].Metric.Sum().DataPoints().At(1).IntValue())
+		hdp := store.metrics[3].Metric.Histogram().DataPoints().At(0)
+		assert.Equal(t, uint64(2), hdp.Count())
+		assert.Equal(t, 400.0, hdp.Sum())
+	})
+
+	t.Run("snapshot", func(t *testing.T) {
+		assert.Empty(t, store.snapshotMetrics())
+	})
+
+	t.Run("flush", func(t *testing.T) {
+		store.Flush()
+		payload, _ := test.GenerateOTLPTracesPayload(t, 3, 1, []int{1}, [][]int{{1}})
+		store.AddSpan(&payload)
+
+		assert.Equal(t, 2, len(store.metrics))
+		assert.Equal(t, int64(1), store.metrics[0].Metric.Sum().DataPoints().At(0).IntValue())
+	})
+}
+
+func TestStoreSpanMetricsKeepMetrics(t *testing.T) {
+	store := NewStoreWithConfig(clockwork.NewRealClock(), StoreConfig{SpanMetrics: true})
+	store.maxMetricCount = 2
+	metrics, _ := test.GenerateOTLPGaugeMetricsPayload(t, 1, []int{2}, [][]int{{1, 1}})
+	store.AddMetric(&metrics)
+
+	for i := range 10 {
+		payload, _ := test.GenerateOTLPTracesPayload(t, i+1, 2, []int{1, 1}, [][]int{{1}, {1}})
+		store.AddSpan(&payload)
+	}
+
+	// the derived metrics neither grow with the spans nor rotate the received metrics out
+	assert.Equal(t, 6, len(store.metrics))
+	received := 0
+	for _, md := range store.metrics {
+		if !md.Derived {
+			received++
+		}
+	}
+	assert.Equal(t, 2, received)
+	assert.Equal(t, int64(10), store.metrics[len(store.metrics)-2].Metric.Sum().DataPoints().At(0).IntValue())
+
+	t.Run("rotation", func(t *testing.T) {
+		metrics, testdata := test.GenerateOTLPGaugeMetricsPayload(t, 1, []int{1}, [][]int{{1}})
+		store.AddMetric(&metrics)
+
+		// the oldest received metric is rotated out instead of the derived ones
+		assert.Equal(t, 6, len(store.metrics))
+		assert.False(t, store.metrics[0].Derived)
+		assert.Equal(t, testdata.Metrics[0], store.metrics[5].Metric)
+	})
+}
+
+func TestStoreSpanMetricsEvictByMemory(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	store := NewStoreWithConfig(clock, StoreConfig{SpanMetrics: true})
+	payload, _ := test.GenerateOTLPTracesPayload(t, 1, 2, []int{1, 1}, [][]int{{1}, {1}})
+	store.AddSpan(&payload)
+	assert.Equal(t, 4, len(store.metrics))
+
+	clock.Advance(time.Second)
+	lp, testlogs := test.GenerateOTLPLogsPayload(t, 1, 1, []int{1}, [][]int{{1}})
+	before := store.MemoryUsage()
+	store.AddLog(&lp)
+	logUsage := store.MemoryUsage() - before
+
+	// the derived metrics are evicted with the spans older than the logs
+	store.maxMemoryBytes = logUsage
+	clock.Advance(time.Second)
+	empty := pmetric.NewMetrics()
+	store.AddMetric(&empty)
+
+	assert.Equal(t, logUsage, store.MemoryUsage())
+	assert.Empty(t, store.svcspans)
+	assert.Empty(t, store.metrics)
+	assert.Empty(t, store.metricsFiltered)
+	assert.Equal(t, len(testlogs.Logs), len(store.logs))
+}
+
+func TestStoreSpanMetricsDisabled(t *testing.T) {
+	store := NewStore(clockwork.NewRealClock())
+	payload, _ := test.GenerateOTLPTracesPayload(t, 1, 1, []int{1}, [][]int{{1}})
+	store.AddSpan(&payload)
+
+	assert.Empty(t, store.metrics)
+}
@@ -0,0 +1,110 @@
+package telemetry
+
+import (
+	"sort"
+	"time"
+)
+
+// SpanTreeNode is a node of the tree of the spans in a trace
+type SpanTreeNode struct {
+	Span     *SpanData
+	Children []*SpanTreeNode
+}
+
+// NewSpanTree builds the trees of the spans in a trace. A span whose parent is not in the spans
+// is a root, so a trace can have multiple roots while it is incomplete.
+// The roots and the children are sorted by the start time.
+func NewSpanTree(spans []*SpanData) []*SpanTreeNode {
+	nodes := make(map[string]*SpanTreeNode, len(spans))
+	for _, sd := range spans {
+		nodes[sd.Span.SpanID().String()] = &SpanTreeNode{Span: sd}
+	}
+
+	roots := []*SpanTreeNode{}
+	for _, sd := range spans {
+		node := nodes[sd.Span.SpanID().String()]
+		parent, ok := nodes[sd.Span.ParentSpanID().String()]
+		if !ok || parent == node {
+			roots = append(roots, node)
+			continue
+		}
+		parent.Children = append(parent.Children, node)
+	}
+
+	sortSpanTreeNodes(roots)
+	for _, node := range nodes {
+		sortSpanTreeNodes(node.Children)
+	}
+
+	return roots
+}
+
+// StartTime returns the start time of the span
+func (n *SpanTreeNode) StartTime() time.Time {
+	return n.Span.Span.StartTimestamp().AsTime()
+}
+
+// EndTime returns the end time of the span
+func (n *SpanTreeNode) EndTime() time.Time {
+	return n.Span.Span.EndTimestamp().AsTime()
+}
+
+// Duration returns the duration of the span
+func (n *SpanTreeNode) Duration() time.Duration {
+	return n.EndTime().Sub(n.StartTime())
+}
+
+// SelfTime returns the exclusive time of the span, which is not covered by any child span.
+// The children are clipped to the span, so the self time is never negative.
+func (n *SpanTreeNode) SelfTime() time.Duration {
+	start, end := n.StartTime(), n.EndTime()
+	if !end.After(start) {
+		return 0
+	}
+
+	type interval struct{ start, end time.Time }
+	intervals := make([]interval, 0, len(n.Children))
+	for _, c := range n.Children {
+		cs, ce := maxTime(c.StartTime(), start), minTime(c.EndTime(), end)
+		if ce.After(cs) {
+			intervals = append(intervals, interval{cs, ce})
+		}
+	}
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i].start.Before(intervals[j].start)
+	})
+
+	self := end.Sub(start)
+	var cursor time.Time
+	for _, iv := range intervals {
+		if iv.start.Before(cursor) {
+			iv.start = cursor
+		}
+		if iv.end.After(iv.start) {
+			self -= iv.end.Sub(iv.start)
+			cursor = iv.end
+		}
+	}
+
+	return self
+}
+
+func sortSpanTreeNodes(nodes []*SpanTreeNode) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].StartTime().Before(nodes[j].StartTime())
+	})
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -1,7 +1,10 @@
 package telemetry
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -15,18 +18,23 @@ import (
 	"go.opentelemetry.io/collector/pdata/ptrace"
 )
 
+// Default maximum counts of the data kept in the store
 const (
 	MAX_SERVICE_SPAN_COUNT = 1000
 	MAX_METRIC_COUNT       = 3000
 	MAX_LOG_COUNT          = 1000
 )
 
+// RETENTION_SWEEP_INTERVAL is the interval to evict the data out of the retention window
+const RETENTION_SWEEP_INTERVAL = 1 * time.Second
+
 // SpanData is a struct to represent a span
 type SpanData struct {
 	Span         *ptrace.Span
 	ResourceSpan *ptrace.ResourceSpans
 	ScopeSpans   *ptrace.ScopeSpans
 	ReceivedAt   time.Time
+	Seq          uint64
 }
 
 // IsRoot returns true if the span is a root span
@@ -73,6 +81,10 @@ type MetricData struct {
 	ResourceMetric *pmetric.ResourceMetrics
 	ScopeMetric    *pmetric.ScopeMetrics
 	ReceivedAt     time.Time
+	Seq            uint64
+	// Derived is true if the metric is derived from the spans. The derived metrics are not
+	// persisted as they are derived again when the spans are restored.
+	Derived bool
 }
 
 // HasNumberDatapoints returns whether it has number datapoints
@@ -114,6 +126,7 @@ type LogData struct {
 	ResourceLog *plog.ResourceLogs
 	ScopeLog    *plog.ScopeLogs
 	ReceivedAt  time.Time
+	Seq         uint64
 }
 
 func (l *LogData) GetResolvedBody() string {
@@ -157,50 +170,125 @@ func (l *LogData) GetRawData() string {
 	return l.Log.Body().AsString()
 }
 
+// StoreConfig is a configuration of the store
+// Zero values are replaced with the defaults.
+type StoreConfig struct {
+	MaxServiceSpanCount int
+	MaxMetricCount      int
+	MaxLogCount         int
+	// MaxMemoryBytes is the budget of the estimated memory usage of the data in the store.
+	// When it is exceeded, the oldest data across all signals is evicted. Zero means no limit.
+	MaxMemoryBytes int64
+	// Retention is the time window of the data kept in the store. The data received before
+	// the window is evicted by the retention sweeper. Zero means no limit.
+	Retention time.Duration
+	// SpanMetrics enables the metrics derived from the spans (the request count and the
+	// duration histogram by the service, span name, span kind and status code)
+	SpanMetrics bool
+}
+
+// DefaultStoreConfig returns the default configuration of the store
+func DefaultStoreConfig() StoreConfig {
+	return StoreConfig{
+		MaxServiceSpanCount: MAX_SERVICE_SPAN_COUNT,
+		MaxMetricCount:      MAX_METRIC_COUNT,
+		MaxLogCount:         MAX_LOG_COUNT,
+	}
+}
+
+func (c StoreConfig) withDefaults() StoreConfig {
+	def := DefaultStoreConfig()
+	if c.MaxServiceSpanCount <= 0 {
+		c.MaxServiceSpanCount = def.MaxServiceSpanCount
+	}
+	if c.MaxMetricCount <= 0 {
+		c.MaxMetricCount = def.MaxMetricCount
+	}
+	if c.MaxLogCount <= 0 {
+		c.MaxLogCount = def.MaxLogCount
+	}
+	if c.MaxMemoryBytes < 0 {
+		c.MaxMemoryBytes = 0
+	}
+	if c.Retention < 0 {
+		c.Retention = 0
+	}
+	return c
+}
+
 // Store is a store of trace spans
 type Store struct {
-	mut                 sync.Mutex
-	clockwork           clockwork.Clock
-	filterSvc           string
-	filterMetric        string
-	filterLog           string
-	sortTrace           SortType
-	svcspans            SvcSpans
-	svcspansFiltered    SvcSpans
-	tracecache          *TraceCache
-	metrics             []*MetricData
-	metricsFiltered     []*MetricData
-	metriccache         *MetricCache
-	logs                []*LogData
-	logsFiltered        []*LogData
-	logcache            *LogCache
-	updatedAt           time.Time
-	maxServiceSpanCount int
-	maxMetricCount      int
-	maxLogCount         int
-	onSpanAdded         func()
-	onMetricAdded       func()
-	onLogAdded          func()
-	onFlushed           []func()
-}
-
-// NewStore creates a new store
+	mut                    sync.Mutex
+	clockwork              clockwork.Clock
+	filterSvc              string
+	filterSvcQuery         *Query
+	filterMetric           string
+	filterMetricQuery      *Query
+	filterLog              string
+	filterLogQuery         *Query
+	sortTrace              SortType
+	svcspans               SvcSpans
+	svcspansFiltered       SvcSpans
+	tracecache             *TraceCache
+	traceSummaries         map[string]TraceSummary
+	traceSummariesFiltered []TraceSummary
+	metrics                []*MetricData
+	metricsFiltered        []*MetricData
+	metriccache            *MetricCache
+	logs                   []*LogData
+	logsFiltered           []*LogData
+	logcache               *LogCache
+	updatedAt              time.Time
+	maxServiceSpanCount    int
+	maxMetricCount         int
+	maxLogCount            int
+	maxMemoryBytes         int64
+	memoryUsage            int64
+	retention              time.Duration
+	spanMetrics            *spanMetrics
+	onSpanAdded            func()
+	onMetricAdded          func()
+	onLogAdded             func()
+	onFlushed              []func()
+	subscriptions          map[int]*Subscription
+	subscriptionID         int
+	droppedBatches         uint64
+	seq                    uint64
+}
+
+// NewStore creates a new store with the default configuration
 func NewStore(clock clockwork.Clock) *Store {
+	return NewStoreWithConfig(clock, DefaultStoreConfig())
+}
+
+// NewStoreWithConfig creates a new store with the given configuration
+func NewStoreWithConfig(clock clockwork.Clock, config StoreConfig) *Store {
+	config = config.withDefaults()
+	var sm *spanMetrics
+	if config.SpanMetrics {
+		sm = newSpanMetrics()
+	}
 	return &Store{
-		mut:                 sync.Mutex{},
-		clockwork:           clock,
-		svcspans:            SvcSpans{},
-		svcspansFiltered:    SvcSpans{},
-		tracecache:          NewTraceCache(),
-		metrics:             []*MetricData{},
-		metricsFiltered:     []*MetricData{},
-		metriccache:         NewMetricCache(),
-		logs:                []*LogData{},
-		logsFiltered:        []*LogData{},
-		logcache:            NewLogCache(),
-		maxServiceSpanCount: MAX_SERVICE_SPAN_COUNT, // TODO: make this configurable
-		maxMetricCount:      MAX_METRIC_COUNT,       // TODO: make this configurable
-		maxLogCount:         MAX_LOG_COUNT,          // TODO: make this configurable
+		mut:                    sync.Mutex{},
+		clockwork:              clock,
+		svcspans:               SvcSpans{},
+		svcspansFiltered:       SvcSpans{},
+		tracecache:             NewTraceCache(),
+		traceSummaries:         map[string]TraceSummary{},
+		traceSummariesFiltered: []TraceSummary{},
+		metrics:                []*MetricData{},
+		metricsFiltered:        []*MetricData{},
+		metriccache:            NewMetricCache(),
+		logs:                   []*LogData{},
+		logsFiltered:           []*LogData{},
+		logcache:               NewLogCache(),
+		maxServiceSpanCount:    config.MaxServiceSpanCount,
+		maxMetricCount:         config.MaxMetricCount,
+		maxLogCount:            config.MaxLogCount,
+		maxMemoryBytes:         config.MaxMemoryBytes,
+		retention:              config.Retention,
+		spanMetrics:            sm,
+		subscriptions:          map[int]*Subscription{},
 	}
 }
 
@@ -241,9 +329,50 @@ func (s *Store) GetFilteredLogs() *[]*LogData {
 
 // UpdatedAt returns the last updated time
 func (s *Store) UpdatedAt() time.Time {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
 	return s.updatedAt
 }
 
+// MaxServiceSpanCount returns the maximum number of service root spans kept in the store
+func (s *Store) MaxServiceSpanCount() int {
+	return s.maxServiceSpanCount
+}
+
+// MaxMetricCount returns the maximum number of metrics kept in the store
+func (s *Store) MaxMetricCount() int {
+	return s.maxMetricCount
+}
+
+// MaxLogCount returns the maximum number of logs kept in the store
+func (s *Store) MaxLogCount() int {
+	return s.maxLogCount
+}
+
+// MaxMemoryBytes returns the memory budget of the store in bytes (0 means no limit)
+func (s *Store) MaxMemoryBytes() int64 {
+	return s.maxMemoryBytes
+}
+
+// MemoryUsage returns the estimated memory usage of the data in the store in bytes
+func (s *Store) MemoryUsage() int64 {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	return s.usage()
+}
+
+// usage returns the estimated memory usage of the data and the topology model of the spans
+func (s *Store) usage() int64 {
+	return s.memoryUsage + s.tracecache.topology.size
+}
+
+// Retention returns the time window of the data kept in the store (0 means no limit)
+func (s *Store) Retention() time.Duration {
+	return s.retention
+}
+
 // SetOnSpanAdded sets the callback function to be called when a span is added
 func (s *Store) SetOnSpanAdded(f func()) {
 	s.onSpanAdded = f
@@ -264,77 +393,138 @@ func (s *Store) RegisterOnFlushed(f func()) {
 	s.onFlushed = append(s.onFlushed, f)
 }
 
-// ApplyFilterTraces applies a filter and sort to the traces
-func (s *Store) ApplyFilterTraces(svc string, sortType SortType) {
+// ApplyFilterTraces applies a filter and sort to the traces.
+// It returns an error without changing the current filter if the filter is malformed.
+func (s *Store) ApplyFilterTraces(svc string, mode MatchMode, sortType SortType) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	query, err := ParseQuery(svc, mode)
+	if err != nil {
+		return err
+	}
 	s.filterSvc = svc
+	s.filterSvcQuery = query
 	s.sortTrace = sortType
-	s.svcspansFiltered = []*SpanData{}
+	s.applyFilterTraces()
+	return nil
+}
 
-	if svc == "" {
+func (s *Store) applyFilterTraces() {
+	if s.filterSvcQuery == nil {
 		s.svcspansFiltered = s.svcspans
-		sortSvcSpans(s.svcspansFiltered, sortType)
-		return
+	} else {
+		s.svcspansFiltered = s.filterSvcSpans(s.svcspans, s.filterSvcQuery)
 	}
+	sortSvcSpans(s.svcspansFiltered, s.sortTrace)
+	s.updateTraceSummaries()
+}
+
+func (s *Store) updateFilterService() {
+	s.applyFilterTraces()
+}
 
-	for _, span := range s.svcspans {
-		sname := GetServiceNameFromResource(span.ResourceSpan.Resource())
-		target := sname + " " + span.Span.Name()
-		if strings.Contains(target, svc) {
-			s.svcspansFiltered = append(s.svcspansFiltered, span)
+// FilterSvcSpans returns the service spans matching the query.
+// A service span matches if any span of the trace and service matches the query.
+func (s *Store) FilterSvcSpans(svcspans SvcSpans, query *Query) SvcSpans {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	return s.filterSvcSpans(svcspans, query)
+}
+
+func (s *Store) filterSvcSpans(svcspans SvcSpans, query *Query) SvcSpans {
+	filtered := SvcSpans{}
+	for _, span := range svcspans {
+		if s.matchSvcSpan(span, query) {
+			filtered = append(filtered, span)
 		}
 	}
-
-	sortSvcSpans(s.svcspansFiltered, sortType)
+	return filtered
 }
 
-func (s *Store) updateFilterService() {
-	s.ApplyFilterTraces(s.filterSvc, s.sortTrace)
+func (s *Store) matchSvcSpan(span *SpanData, query *Query) bool {
+	// NOTE: A plain text filter matches only the service root span as before
+	if query == nil || query.isPlainText() {
+		return query.MatchSpan(span)
+	}
+	traceID := span.Span.TraceID().String()
+	spans, _ := s.tracecache.GetSpansByTraceIDAndSvc(traceID, span.GetServiceName())
+	trace, _ := s.tracecache.GetSpansByTraceID(traceID)
+	for _, sp := range spans {
+		if query.matchSpanInTrace(sp, trace) {
+			return true
+		}
+	}
+	return false
 }
 
-// ApplyFilterMetrics applies a filter to the metrics
-func (s *Store) ApplyFilterMetrics(filter string) {
+// ApplyFilterMetrics applies a filter to the metrics.
+// It returns an error without changing the current filter if the filter is malformed.
+func (s *Store) ApplyFilterMetrics(filter string, mode MatchMode) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	query, err := ParseQuery(filter, mode)
+	if err != nil {
+		return err
+	}
 	s.filterMetric = filter
-	s.metricsFiltered = []*MetricData{}
+	s.filterMetricQuery = query
+	s.applyFilterMetrics()
+	return nil
+}
 
-	if filter == "" {
+func (s *Store) applyFilterMetrics() {
+	if s.filterMetricQuery == nil {
 		s.metricsFiltered = s.metrics
 		return
 	}
 
+	s.metricsFiltered = []*MetricData{}
 	for _, metric := range s.metrics {
-		sname := GetServiceNameFromResource(metric.ResourceMetric.Resource())
-		target := sname + " " + metric.Metric.Name()
-		if strings.Contains(target, filter) {
+		if s.filterMetricQuery.MatchMetric(metric) {
 			s.metricsFiltered = append(s.metricsFiltered, metric)
 		}
 	}
 }
 
 func (s *Store) updateFilterMetrics() {
-	s.ApplyFilterMetrics(s.filterMetric)
+	s.applyFilterMetrics()
 }
 
-// ApplyFilterLogs applies a filter to the logs
-func (s *Store) ApplyFilterLogs(filter string) {
+// ApplyFilterLogs applies a filter to the logs.
+// It returns an error without changing the current filter if the filter is malformed.
+func (s *Store) ApplyFilterLogs(filter string, mode MatchMode) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	query, err := ParseQuery(filter, mode)
+	if err != nil {
+		return err
+	}
 	s.filterLog = filter
-	s.logsFiltered = []*LogData{}
+	s.filterLogQuery = query
+	s.applyFilterLogs()
+	return nil
+}
 
-	if filter == "" {
+func (s *Store) applyFilterLogs() {
+	if s.filterLogQuery == nil {
 		s.logsFiltered = s.logs
 		return
 	}
 
+	s.logsFiltered = []*LogData{}
 	for _, log := range s.logs {
-		sname := GetServiceNameFromResource(log.ResourceLog.Resource())
-		target := sname + " " + log.Log.Body().AsString()
-		if strings.Contains(target, filter) {
+		if s.filterLogQuery.MatchLog(log) {
 			s.logsFiltered = append(s.logsFiltered, log)
 		}
 	}
 }
 
 func (s *Store) updateFilterLogs() {
-	s.ApplyFilterLogs(s.filterLog)
+	s.applyFilterLogs()
 }
 
 // GetTraceIDByFilteredIdx returns the trace at the given index
@@ -411,12 +601,18 @@ func (s *Store) GetFilteredLogByIdx(idx int) *LogData {
 
 // AddSpan adds spans to the store
 func (s *Store) AddSpan(traces *ptrace.Traces) {
+	s.addSpanAt(traces, s.clockwork.Now())
+}
+
+// addSpanAt adds spans received at the given time to the store
+func (s *Store) addSpanAt(traces *ptrace.Traces, receivedAt time.Time) {
 	s.mut.Lock()
 	defer func() {
 		s.updatedAt = s.clockwork.Now()
 		s.mut.Unlock()
 	}()
 
+	added := []*SpanData{}
 	for rsi := 0; rsi < traces.ResourceSpans().Len(); rsi++ {
 		rs := traces.ResourceSpans().At(rsi)
 
@@ -430,9 +626,13 @@ func (s *Store) AddSpan(traces *ptrace.Traces) {
 					Span:         &span,
 					ResourceSpan: &rs,
 					ScopeSpans:   &ss,
-					ReceivedAt:   s.clockwork.Now(),
+					ReceivedAt:   receivedAt,
+					Seq:          s.nextSeq(),
 				}
+				added = append(added, sd)
+				s.invalidateTraceSummary(span.TraceID().String())
 				newtracesvc, replaceSpanID := s.tracecache.UpdateCache(sname, sd)
+				s.memoryUsage += sd.estimateSize()
 				if newtracesvc {
 					s.svcspans = append(s.svcspans, sd)
 				} else if len(replaceSpanID) > 0 {
@@ -447,26 +647,71 @@ func (s *Store) AddSpan(traces *ptrace.Traces) {
 	if len(s.svcspans) > s.maxServiceSpanCount {
 		deleteSpans := s.svcspans[:len(s.svcspans)-s.maxServiceSpanCount]
 
-		s.tracecache.DeleteCache(deleteSpans)
+		s.deleteSvcSpans(deleteSpans)
 
 		s.svcspans = s.svcspans[len(s.svcspans)-s.maxServiceSpanCount:]
 	}
+	if s.evictByMemory() {
+		s.updateFilterMetrics()
+		s.updateFilterLogs()
+	}
 
 	s.updateFilterService()
 
 	if s.onSpanAdded != nil {
 		s.onSpanAdded()
 	}
+	s.publish(Batch{Signal: SignalTraces, Spans: added})
+
+	if s.spanMetrics != nil && len(added) > 0 {
+		derived := s.spanMetrics.derive(added, receivedAt)
+		s.replaceDerivedMetrics(&derived, receivedAt)
+	}
+}
+
+// replaceDerivedMetrics replaces the metrics derived from the spans of the services with the new ones
+// so that the derived metrics do not grow with the spans
+func (s *Store) replaceDerivedMetrics(metrics *pmetric.Metrics, receivedAt time.Time) {
+	services := map[string]bool{}
+	for rmi := 0; rmi < metrics.ResourceMetrics().Len(); rmi++ {
+		services[GetServiceNameFromResource(metrics.ResourceMetrics().At(rmi).Resource())] = true
+	}
+
+	kept := make([]*MetricData, 0, len(s.metrics))
+	replaced := []*MetricData{}
+	for _, md := range s.metrics {
+		if md.Derived && services[md.GetServiceName()] {
+			replaced = append(replaced, md)
+		} else {
+			kept = append(kept, md)
+		}
+	}
+	if len(replaced) > 0 {
+		s.deleteMetrics(replaced)
+		s.metrics = kept
+	}
+
+	s.addMetric(metrics, true, receivedAt)
 }
 
 // AddMetric adds metrics to the store
 func (s *Store) AddMetric(metrics *pmetric.Metrics) {
+	s.addMetricAt(metrics, s.clockwork.Now())
+}
+
+// addMetricAt adds metrics received at the given time to the store
+func (s *Store) addMetricAt(metrics *pmetric.Metrics, receivedAt time.Time) {
 	s.mut.Lock()
 	defer func() {
 		s.updatedAt = s.clockwork.Now()
 		s.mut.Unlock()
 	}()
 
+	s.addMetric(metrics, false, receivedAt)
+}
+
+func (s *Store) addMetric(metrics *pmetric.Metrics, derived bool, receivedAt time.Time) {
+	added := []*MetricData{}
 	for rmi := 0; rmi < metrics.ResourceMetrics().Len(); rmi++ {
 		rm := metrics.ResourceMetrics().At(rmi)
 
@@ -480,20 +725,25 @@ func (s *Store) AddMetric(metrics *pmetric.Metrics) {
 					Metric:         &metric,
 					ResourceMetric: &rm,
 					ScopeMetric:    &sm,
-					ReceivedAt:     s.clockwork.Now(),
+					ReceivedAt:     receivedAt,
+					Seq:            s.nextSeq(),
+					Derived:        derived,
 				}
+				added = append(added, sd)
 				s.metrics = append(s.metrics, sd)
 				s.metriccache.UpdateCache(sname, sd)
+				s.memoryUsage += sd.estimateSize()
 			}
 		}
 	}
 
 	// data rotation
 	if len(s.metrics) > s.maxMetricCount {
-		deleteMetrics := s.metrics[:len(s.metrics)-s.maxMetricCount]
-		s.metrics = s.metrics[len(s.metrics)-s.maxMetricCount:]
-
-		s.metriccache.DeleteCache(deleteMetrics)
+		s.rotateMetrics()
+	}
+	if s.evictByMemory() {
+		s.updateFilterService()
+		s.updateFilterLogs()
 	}
 
 	s.updateFilterMetrics()
@@ -501,16 +751,50 @@ func (s *Store) AddMetric(metrics *pmetric.Metrics) {
 	if s.onMetricAdded != nil {
 		s.onMetricAdded()
 	}
+	s.publish(Batch{Signal: SignalMetrics, Metrics: added})
+}
+
+// rotateMetrics deletes the oldest metrics exceeding the maximum count. The metrics derived from
+// the spans are not counted nor deleted as they are replaced instead of added.
+func (s *Store) rotateMetrics() {
+	over := -s.maxMetricCount
+	for _, md := range s.metrics {
+		if !md.Derived {
+			over++
+		}
+	}
+	if over <= 0 {
+		return
+	}
+
+	kept := make([]*MetricData, 0, len(s.metrics)-over)
+	deleteMetrics := []*MetricData{}
+	for _, md := range s.metrics {
+		if !md.Derived && len(deleteMetrics) < over {
+			deleteMetrics = append(deleteMetrics, md)
+		} else {
+			kept = append(kept, md)
+		}
+	}
+	s.metrics = kept
+
+	s.deleteMetrics(deleteMetrics)
 }
 
 // AddLog adds logs to the store
 func (s *Store) AddLog(logs *plog.Logs) {
+	s.addLogAt(logs, s.clockwork.Now())
+}
+
+// addLogAt adds logs received at the given time to the store
+func (s *Store) addLogAt(logs *plog.Logs, receivedAt time.Time) {
 	s.mut.Lock()
 	defer func() {
 		s.updatedAt = s.clockwork.Now()
 		s.mut.Unlock()
 	}()
 
+	added := []*LogData{}
 	for rli := 0; rli < logs.ResourceLogs().Len(); rli++ {
 		rl := logs.ResourceLogs().At(rli)
 
@@ -523,10 +807,13 @@ func (s *Store) AddLog(logs *plog.Logs) {
 					Log:         &lr,
 					ResourceLog: &rl,
 					ScopeLog:    &sl,
-					ReceivedAt:  s.clockwork.Now(),
+					ReceivedAt:  receivedAt,
+					Seq:         s.nextSeq(),
 				}
+				added = append(added, ld)
 				s.logs = append(s.logs, ld)
 				s.logcache.UpdateCache(ld)
+				s.memoryUsage += ld.estimateSize()
 			}
 		}
 	}
@@ -536,7 +823,11 @@ func (s *Store) AddLog(logs *plog.Logs) {
 		deleteLogs := s.logs[:len(s.logs)-s.maxLogCount]
 		s.logs = s.logs[len(s.logs)-s.maxLogCount:]
 
-		s.logcache.DeleteCache(deleteLogs)
+		s.deleteLogs(deleteLogs)
+	}
+	if s.evictByMemory() {
+		s.updateFilterService()
+		s.updateFilterMetrics()
 	}
 
 	s.updateFilterLogs()
@@ -544,6 +835,345 @@ func (s *Store) AddLog(logs *plog.Logs) {
 	if s.onLogAdded != nil {
 		s.onLogAdded()
 	}
+	s.publish(Batch{Signal: SignalLogs, Logs: added})
+}
+
+func (s *Store) deleteSvcSpans(serviceSpans []*SpanData) {
+	for _, ss := range serviceSpans {
+		s.invalidateTraceSummary(ss.Span.TraceID().String())
+		sname := GetServiceNameFromResource(ss.ResourceSpan.Resource())
+		if spans, ok := s.tracecache.GetSpansByTraceIDAndSvc(ss.Span.TraceID().String(), sname); ok {
+			for _, sd := range spans {
+				s.memoryUsage -= sd.estimateSize()
+			}
+		}
+	}
+	s.tracecache.DeleteCache(serviceSpans)
+}
+
+func (s *Store) deleteMetrics(metrics []*MetricData) {
+	for _, m := range metrics {
+		s.memoryUsage -= m.estimateSize()
+	}
+	s.metriccache.DeleteCache(metrics)
+}
+
+func (s *Store) deleteLogs(logs []*LogData) {
+	for _, l := range logs {
+		s.memoryUsage -= l.estimateSize()
+	}
+	s.logcache.DeleteCache(logs)
+}
+
+// evictByMemory evicts the oldest data across all signals until the estimated memory usage
+// fits in the budget. It returns true if any data is evicted.
+func (s *Store) evictByMemory() (evicted bool) {
+	if s.maxMemoryBytes <= 0 {
+		return false
+	}
+	for s.usage() > s.maxMemoryBytes {
+		var oldest time.Time
+		target := ""
+		if len(s.svcspans) > 0 {
+			oldest, target = s.svcspans[0].ReceivedAt, "span"
+		}
+		// NOTE: The metrics derived from the spans are evicted as well as they are counted in
+		//   the usage. They are derived again with the next spans of the service.
+		if len(s.metrics) > 0 && (target == "" || s.metrics[0].ReceivedAt.Before(oldest)) {
+			oldest, target = s.metrics[0].ReceivedAt, "metric"
+		}
+		if len(s.logs) > 0 && (target == "" || s.logs[0].ReceivedAt.Before(oldest)) {
+			target = "log"
+		}
+
+		switch target {
+		case "span":
+			s.deleteSvcSpans(s.svcspans[:1])
+			s.svcspans = s.svcspans[1:]
+		case "metric":
+			s.deleteMetrics(s.metrics[:1])
+			s.metrics = s.metrics[1:]
+		case "log":
+			s.deleteLogs(s.logs[:1])
+			s.logs = s.logs[1:]
+		default:
+			// nothing left to evict
+			return evicted
+		}
+		evicted = true
+	}
+	return evicted
+}
+
+// received is the data received at the same time
+type received[T any] struct {
+	receivedAt time.Time
+	data       T
+}
+
+// groupByReceivedAt groups the items by the time they were received in the order of the time
+func groupByReceivedAt[T any](items []T, receivedAt func(T) time.Time) [][]T {
+	groups := map[time.Time][]T{}
+	times := []time.Time{}
+	for _, item := range items {
+		t := receivedAt(item)
+		if _, ok := groups[t]; !ok {
+			times = append(times, t)
+		}
+		groups[t] = append(groups[t], item)
+	}
+	slices.SortFunc(times, func(a, b time.Time) int { return a.Compare(b) })
+
+	result := make([][]T, 0, len(times))
+	for _, t := range times {
+		result = append(result, groups[t])
+	}
+	return result
+}
+
+// snapshotTraces returns a copy of all spans in the store grouped by the time they were received
+func (s *Store) snapshotTraces() []received[ptrace.Traces] {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	result := []received[ptrace.Traces]{}
+	for _, spans := range groupByReceivedAt(s.allSpans(), func(sd *SpanData) time.Time { return sd.ReceivedAt }) {
+		result = append(result, received[ptrace.Traces]{receivedAt: spans[0].ReceivedAt, data: SpansToTraces(spans)})
+	}
+	return result
+}
+
+// snapshotMetrics returns a copy of all metrics in the store except the metrics derived from the spans
+// grouped by the time they were received
+func (s *Store) snapshotMetrics() []received[pmetric.Metrics] {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	metrics := []*MetricData{}
+	for _, md := range s.metrics {
+		if !md.Derived {
+			metrics = append(metrics, md)
+		}
+	}
+	result := []received[pmetric.Metrics]{}
+	for _, mds := range groupByReceivedAt(metrics, func(md *MetricData) time.Time { return md.ReceivedAt }) {
+		result = append(result, received[pmetric.Metrics]{receivedAt: mds[0].ReceivedAt, data: MetricsToPmetric(mds)})
+	}
+	return result
+}
+
+// snapshotLogs returns a copy of all logs in the store grouped by the time they were received
+func (s *Store) snapshotLogs() []received[plog.Logs] {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	result := []received[plog.Logs]{}
+	for _, lds := range groupByReceivedAt(s.logs, func(ld *LogData) time.Time { return ld.ReceivedAt }) {
+		result = append(result, received[plog.Logs]{receivedAt: lds[0].ReceivedAt, data: LogsToPlog(lds)})
+	}
+	return result
+}
+
+// ExportJSONLines writes the data of the given signals in the store as OTLP JSON lines.
+// If filtered is true, only the data matching the current filters is written.
+func (s *Store) ExportJSONLines(w io.Writer, signals []Signal, filtered bool) error {
+	s.mut.Lock()
+	var (
+		traces  *ptrace.Traces
+		metrics *pmetric.Metrics
+		logs    *plog.Logs
+	)
+	for _, sig := range signals {
+		switch sig {
+		case SignalTraces:
+			svcspans := s.svcspans
+			if filtered {
+				svcspans = s.svcspansFiltered
+			}
+			t := SpansToTraces(s.spansOf(svcspans))
+			traces = &t
+		case SignalMetrics:
+			ms := s.metrics
+			if filtered {
+				ms = s.metricsFiltered
+			}
+			m := MetricsToPmetric(ms)
+			metrics = &m
+		case SignalLogs:
+			ls := s.logs
+			if filtered {
+				ls = s.logsFiltered
+			}
+			l := LogsToPlog(ls)
+			logs = &l
+		}
+	}
+	s.mut.Unlock()
+
+	return WriteJSONLines(w, traces, metrics, logs)
+}
+
+// ExportTrace encodes all spans of the trace in the given format.
+// It returns false if the trace is not found.
+func (s *Store) ExportTrace(traceID string, format TraceFormat) ([]byte, bool, error) {
+	spans, ok := s.SpansByTraceID(traceID)
+	if !ok {
+		return nil, false, nil
+	}
+	b, err := MarshalTrace(spans, format)
+	return b, true, err
+}
+
+// SpansByTraceID returns a copy of the spans of the trace so that they can be used after
+// the store is unlocked. It returns false if the trace is not found.
+func (s *Store) SpansByTraceID(traceID string) ([]*SpanData, bool) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	return s.copySpansByTraceID(traceID)
+}
+
+// SpansByTraceIDs returns a copy of the spans of each trace read under a single lock so that
+// the traces are consistent with each other. The spans of a trace not found are nil.
+func (s *Store) SpansByTraceIDs(traceIDs ...string) [][]*SpanData {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	spans := make([][]*SpanData, len(traceIDs))
+	for i, traceID := range traceIDs {
+		spans[i], _ = s.copySpansByTraceID(traceID)
+	}
+	return spans
+}
+
+func (s *Store) copySpansByTraceID(traceID string) ([]*SpanData, bool) {
+	spans, ok := s.tracecache.GetSpansByTraceID(traceID)
+	if !ok {
+		return nil, false
+	}
+	return append([]*SpanData{}, spans...), true
+}
+
+func (s *Store) allSpans() []*SpanData {
+	return s.spansOf(s.svcspans)
+}
+
+// spansOf returns all spans in the same trace and service as the given service spans
+func (s *Store) spansOf(svcspans SvcSpans) []*SpanData {
+	spans := []*SpanData{}
+	for _, ss := range svcspans {
+		sname := GetServiceNameFromResource(ss.ResourceSpan.Resource())
+		if sds, ok := s.tracecache.GetSpansByTraceIDAndSvc(ss.Span.TraceID().String(), sname); ok {
+			spans = append(spans, sds...)
+		}
+	}
+	return spans
+}
+
+// RunRetentionSweeper evicts the expired data periodically until the context is done.
+// It returns immediately if the retention is not configured.
+func (s *Store) RunRetentionSweeper(ctx context.Context) {
+	if s.retention <= 0 {
+		return
+	}
+
+	ticker := s.clockwork.NewTicker(RETENTION_SWEEP_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.Chan():
+			s.EvictExpired()
+		}
+	}
+}
+
+// EvictExpired evicts the data received before the retention window.
+// Service spans are kept as long as any span in the same trace and service is in the window.
+func (s *Store) EvictExpired() {
+	if s.retention <= 0 {
+		return
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	now := s.clockwork.Now()
+	cutoff := now.Add(-s.retention)
+	evicted := false
+
+	svcspans := make(SvcSpans, 0, len(s.svcspans))
+	expiredSpans := []*SpanData{}
+	for _, ss := range s.svcspans {
+		if s.isSvcSpansExpired(ss, cutoff) {
+			expiredSpans = append(expiredSpans, ss)
+		} else {
+			svcspans = append(svcspans, ss)
+		}
+	}
+	if len(expiredSpans) > 0 {
+		s.deleteSvcSpans(expiredSpans)
+		s.svcspans = svcspans
+		s.updateFilterService()
+		evicted = true
+	}
+
+	metrics := make([]*MetricData, 0, len(s.metrics))
+	expiredMetrics := []*MetricData{}
+	for _, m := range s.metrics {
+		if m.ReceivedAt.Before(cutoff) {
+			expiredMetrics = append(expiredMetrics, m)
+		} else {
+			metrics = append(metrics, m)
+		}
+	}
+	if len(expiredMetrics) > 0 {
+		s.deleteMetrics(expiredMetrics)
+		s.metrics = metrics
+		s.updateFilterMetrics()
+		evicted = true
+	}
+
+	logs := make([]*LogData, 0, len(s.logs))
+	expiredLogs := []*LogData{}
+	for _, l := range s.logs {
+		if l.ReceivedAt.Before(cutoff) {
+			expiredLogs = append(expiredLogs, l)
+		} else {
+			logs = append(logs, l)
+		}
+	}
+	if len(expiredLogs) > 0 {
+		s.deleteLogs(expiredLogs)
+		s.logs = logs
+		s.updateFilterLogs()
+		evicted = true
+	}
+
+	if evicted {
+		s.updatedAt = now
+	}
+}
+
+// isExpired returns true if the data received at the given time is out of the retention window
+func (s *Store) isExpired(receivedAt time.Time) bool {
+	return s.retention > 0 && receivedAt.Before(s.clockwork.Now().Add(-s.retention))
+}
+
+func (s *Store) isSvcSpansExpired(ss *SpanData, cutoff time.Time) bool {
+	sname := GetServiceNameFromResource(ss.ResourceSpan.Resource())
+	spans, ok := s.tracecache.GetSpansByTraceIDAndSvc(ss.Span.TraceID().String(), sname)
+	if !ok {
+		return ss.ReceivedAt.Before(cutoff)
+	}
+	for _, sd := range spans {
+		if !sd.ReceivedAt.Before(cutoff) {
+			return false
+		}
+	}
+	return true
 }
 
 // Flush clears the store including the cache
@@ -557,12 +1187,18 @@ func (s *Store) Flush() {
 	s.svcspans = SvcSpans{}
 	s.svcspansFiltered = SvcSpans{}
 	s.tracecache.flush()
+	s.traceSummaries = map[string]TraceSummary{}
+	s.traceSummariesFiltered = []TraceSummary{}
 	s.metrics = []*MetricData{}
 	s.metricsFiltered = []*MetricData{}
 	s.metriccache.flush()
 	s.logs = []*LogData{}
 	s.logsFiltered = []*LogData{}
 	s.logcache.flush()
+	if s.spanMetrics != nil {
+		s.spanMetrics.flush()
+	}
+	s.memoryUsage = 0
 	s.updatedAt = s.clockwork.Now()
 
 	for _, f := range s.onFlushed {
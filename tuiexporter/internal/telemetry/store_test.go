@@ -1,12 +1,18 @@
 package telemetry
 
 import (
+	"bytes"
+	"context"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/jonboulle/clockwork"
 	"github.com/stretchr/testify/assert"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/test"
 	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/ptrace"
 )
 
@@ -53,6 +59,65 @@ func TestStoreGetter(t *testing.T) {
 	assert.Equal(t, store.updatedAt, store.UpdatedAt())
 }
 
+func TestNewStoreWithConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config StoreConfig
+		want   StoreConfig
+	}{
+		{
+			name:   "zero values fall back to the defaults",
+			config: StoreConfig{},
+			want:   DefaultStoreConfig(),
+		},
+		{
+			name: "custom values",
+			config: StoreConfig{
+				MaxServiceSpanCount: 10,
+				MaxMetricCount:      20,
+				MaxLogCount:         30,
+				MaxMemoryBytes:      1024,
+				Retention:           time.Minute,
+			},
+			want: StoreConfig{
+				MaxServiceSpanCount: 10,
+				MaxMetricCount:      20,
+				MaxLogCount:         30,
+				MaxMemoryBytes:      1024,
+				Retention:           time.Minute,
+			},
+		},
+		{
+			name: "negative values fall back to the defaults",
+			config: StoreConfig{
+				MaxServiceSpanCount: -1,
+				MaxMetricCount:      20,
+				MaxLogCount:         -1,
+				MaxMemoryBytes:      -1,
+				Retention:           -time.Minute,
+			},
+			want: StoreConfig{
+				MaxServiceSpanCount: MAX_SERVICE_SPAN_COUNT,
+				MaxMetricCount:      20,
+				MaxLogCount:         MAX_LOG_COUNT,
+				MaxMemoryBytes:      0,
+				Retention:           0,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewStoreWithConfig(clockwork.NewRealClock(), tt.config)
+			assert.Equal(t, tt.want.MaxServiceSpanCount, store.MaxServiceSpanCount())
+			assert.Equal(t, tt.want.MaxMetricCount, store.MaxMetricCount())
+			assert.Equal(t, tt.want.MaxLogCount, store.MaxLogCount())
+			assert.Equal(t, tt.want.MaxMemoryBytes, store.MaxMemoryBytes())
+			assert.Equal(t, tt.want.Retention, store.Retention())
+		})
+	}
+}
+
 func TestStoreSpanFilters(t *testing.T) {
 	// traceid: 1
 	//  └- resource: test-service-1
@@ -71,9 +136,10 @@ func TestStoreSpanFilters(t *testing.T) {
 	payload, testdata := test.GenerateOTLPTracesPayload(t, 1, 3, []int{2, 1, 1}, [][]int{{2, 1}, {1}, {1}})
 	traceID := testdata.Spans[0].TraceID().String()
 	testdata.RSpans[2].Resource().Attributes().Clear()
+	testdata.Spans[3].Attributes().PutStr("http.route", "/checkout")
 	store.AddSpan(&payload)
 
-	store.ApplyFilterTraces("0-0", SORT_TYPE_NONE)
+	store.ApplyFilterTraces("0-0", MatchMode{}, SORT_TYPE_NONE)
 	assert.Equal(t, 3, len(store.svcspansFiltered))
 	assert.Equal(t, traceID, store.GetTraceIDByFilteredIdx(0))
 	assert.Equal(t, traceID, store.GetTraceIDByFilteredIdx(1))
@@ -83,7 +149,7 @@ func TestStoreSpanFilters(t *testing.T) {
 	assert.Equal(t, "span-0-0-1", store.GetFilteredServiceSpansByIdx(0)[1].Span.Name())
 	// spans in test-service-2
 	assert.Equal(t, "span-1-0-0", store.GetFilteredServiceSpansByIdx(1)[0].Span.Name())
-	store.ApplyFilterTraces("service-2", SORT_TYPE_NONE)
+	store.ApplyFilterTraces("service-2", MatchMode{}, SORT_TYPE_NONE)
 	assert.Equal(t, 1, len(store.svcspansFiltered))
 	assert.Equal(t, traceID, store.GetTraceIDByFilteredIdx(0))
 	assert.Equal(t, "", store.GetTraceIDByFilteredIdx(1))
@@ -125,8 +191,61 @@ func TestStoreSpanFilters(t *testing.T) {
 	}
 
 	// spans in unknown service
-	store.ApplyFilterTraces("unknown", SORT_TYPE_NONE)
+	store.ApplyFilterTraces("unknown", MatchMode{}, SORT_TYPE_NONE)
 	assert.Equal(t, "span-2-0-0", store.GetFilteredServiceSpansByIdx(0)[0].Span.Name())
+
+	// query matching any span of the service
+	err := store.ApplyFilterTraces(`name = span-0-1-0 OR service.name = test-service-2`, MatchMode{}, SORT_TYPE_NONE)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(store.svcspansFiltered))
+	assert.Equal(t, "test-service-1", store.svcspansFiltered[0].GetServiceName())
+	assert.Equal(t, "test-service-2", store.svcspansFiltered[1].GetServiceName())
+
+	// malformed query keeps the current filter
+	err = store.ApplyFilterTraces(`name = `, MatchMode{}, SORT_TYPE_NONE)
+	assert.Error(t, err)
+	assert.Equal(t, 2, len(store.svcspansFiltered))
+
+	// scope attributes
+	err = store.ApplyFilterTraces(`"scope index" = 1`, MatchMode{}, SORT_TYPE_NONE)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(store.svcspansFiltered))
+	assert.Equal(t, "test-service-1", store.svcspansFiltered[0].GetServiceName())
+
+	// attribute of any span in the trace
+	err = store.ApplyFilterTraces(TraceAttributeCondition("http.route", "/checkout"), MatchMode{}, SORT_TYPE_NONE)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(store.svcspansFiltered))
+	err = store.ApplyFilterTraces(`http.route = "/checkout"`, MatchMode{}, SORT_TYPE_NONE)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(store.svcspansFiltered))
+	assert.Equal(t, "test-service-2", store.svcspansFiltered[0].GetServiceName())
+}
+
+func TestStoreSpanFiltersWhileAddingSpans(t *testing.T) {
+	// run with -race to detect the filter reading the cache while the spans are added
+	store := NewStore(clockwork.NewRealClock())
+	query := `name = span-0-0-1 OR service.name = test-service-2`
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			payload, _ := test.GenerateOTLPTracesPayload(t, i, 2, []int{1, 1}, [][]int{{2}, {1}})
+			store.AddSpan(&payload)
+		}
+	}()
+
+	for adding := true; adding; {
+		select {
+		case <-done:
+			adding = false
+		default:
+		}
+		assert.NoError(t, store.ApplyFilterTraces(query, MatchMode{}, SORT_TYPE_NONE))
+	}
+
+	assert.Equal(t, 200, len(store.svcspansFiltered))
 }
 
 func TestStoreMetricFilters(t *testing.T) {
@@ -147,9 +266,9 @@ func TestStoreMetricFilters(t *testing.T) {
 	payload, testdata := test.GenerateOTLPGaugeMetricsPayload(t, 2, []int{2, 1}, [][]int{{2, 1}, {1}})
 	store.AddMetric(&payload)
 
-	store.ApplyFilterMetrics("service-2")
+	store.ApplyFilterMetrics("service-2", MatchMode{})
 	assert.Equal(t, 1, len(store.metricsFiltered))
-	store.ApplyFilterMetrics("metric 0")
+	store.ApplyFilterMetrics("metric 0", MatchMode{})
 	assert.Equal(t, 2, len(store.metricsFiltered))
 
 	tests := []struct {
@@ -210,9 +329,9 @@ func TestStoreLogFilters(t *testing.T) {
 	payload, testdata := test.GenerateOTLPLogsPayload(t, 1, 2, []int{2, 1}, [][]int{{2, 1}, {1}})
 	store.AddLog(&payload)
 
-	store.ApplyFilterLogs("service-2")
+	store.ApplyFilterLogs("service-2", MatchMode{})
 	assert.Equal(t, 2, len(store.logsFiltered))
-	store.ApplyFilterLogs("log body 1-0-0-0")
+	store.ApplyFilterLogs("log body 1-0-0-0", MatchMode{})
 	assert.Equal(t, 1, len(store.logsFiltered))
 
 	tests := []struct {
@@ -675,6 +794,218 @@ func TestStoreAddLogWithRotation(t *testing.T) {
 	}
 }
 
+func TestStoreEvictByMemory(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	store := NewStore(clock)
+
+	lp, testlogs := test.GenerateOTLPLogsPayload(t, 1, 2, []int{2, 1}, [][]int{{2, 1}, {1}})
+	store.AddLog(&lp)
+	logUsage := store.MemoryUsage()
+	assert.Greater(t, logUsage, int64(0))
+
+	clock.Advance(time.Second)
+	tp, _ := test.GenerateOTLPTracesPayload(t, 1, 2, []int{2, 1}, [][]int{{2, 1}, {1}})
+	store.AddSpan(&tp)
+	spanUsage := store.MemoryUsage() - logUsage
+
+	clock.Advance(time.Second)
+	mp1, _ := test.GenerateOTLPGaugeMetricsPayload(t, 2, []int{2, 1}, [][]int{{2, 1}, {1}})
+	store.AddMetric(&mp1)
+	metricUsage := store.MemoryUsage() - logUsage - spanUsage
+
+	// the budget can keep all data except logs, so only logs (the oldest) are evicted
+	store.maxMemoryBytes = spanUsage + metricUsage*2
+
+	clock.Advance(time.Second)
+	mp2, _ := test.GenerateOTLPGaugeMetricsPayload(t, 2, []int{2, 1}, [][]int{{2, 1}, {1}})
+	store.AddMetric(&mp2)
+
+	assert.Equal(t, spanUsage+metricUsage*2, store.MemoryUsage())
+	assert.Equal(t, 0, len(store.logs))
+	assert.Equal(t, 0, len(store.logsFiltered))
+	assert.Equal(t, 0, len(store.logcache.traceid2logs[testlogs.Logs[0].TraceID().String()]))
+	assert.Equal(t, 2, len(store.svcspans))
+	assert.Equal(t, 2, len(store.svcspansFiltered))
+	assert.Equal(t, 6, len(store.metrics))
+	assert.Equal(t, 6, len(store.metricsFiltered))
+
+	// the spans are evicted next
+	clock.Advance(time.Second)
+	store.maxMemoryBytes = metricUsage * 2
+	empty := pmetric.NewMetrics()
+	store.AddMetric(&empty)
+
+	assert.Equal(t, metricUsage*2, store.MemoryUsage())
+	assert.Equal(t, 0, len(store.svcspans))
+	assert.Equal(t, 0, len(store.svcspansFiltered))
+	assert.Equal(t, 0, len(store.tracecache.spanid2span))
+	assert.Equal(t, 0, len(store.tracecache.traceid2spans))
+	assert.Equal(t, 6, len(store.metrics))
+}
+
+func TestStoreEvictExpired(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	store := NewStoreWithConfig(clock, StoreConfig{Retention: time.Minute})
+
+	// old data
+	tp1, _ := test.GenerateOTLPTracesPayload(t, 1, 1, []int{1}, [][]int{{1}})
+	mp1, _ := test.GenerateOTLPGaugeMetricsPayload(t, 1, []int{1}, [][]int{{1}})
+	lp1, _ := test.GenerateOTLPLogsPayload(t, 1, 1, []int{1}, [][]int{{1}})
+	store.AddSpan(&tp1)
+	store.AddMetric(&mp1)
+	store.AddLog(&lp1)
+
+	// trace 2 has a span received before the window and one in the window
+	tp2, _ := test.GenerateOTLPTracesPayload(t, 2, 1, []int{1}, [][]int{{1}})
+	store.AddSpan(&tp2)
+
+	clock.Advance(50 * time.Second)
+	tp3, testdata3 := test.GenerateOTLPTracesPayload(t, 2, 1, []int{1}, [][]int{{2}})
+	store.AddSpan(&tp3)
+	mp2, testmetrics2 := test.GenerateOTLPGaugeMetricsPayload(t, 1, []int{1}, [][]int{{1}})
+	store.AddMetric(&mp2)
+	lp2, testlogs2 := test.GenerateOTLPLogsPayload(t, 2, 1, []int{1}, [][]int{{1}})
+	store.AddLog(&lp2)
+
+	// nothing is expired yet
+	store.EvictExpired()
+	assert.Equal(t, 2, len(store.svcspans))
+	assert.Equal(t, 2, len(store.metrics))
+	assert.Equal(t, 4, len(store.logs))
+
+	clock.Advance(20 * time.Second)
+	before := store.updatedAt
+	store.EvictExpired()
+
+	assert.True(t, before.Before(store.updatedAt))
+
+	// assert traces
+	assert.Equal(t, 1, len(store.svcspans))
+	assert.Equal(t, 1, len(store.svcspansFiltered))
+	assert.Equal(t, testdata3.Spans[0].TraceID(), store.svcspans[0].Span.TraceID())
+	_, ok := store.tracecache.GetSpansByTraceID(tp1.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).TraceID().String())
+	assert.False(t, ok)
+
+	// assert metrics
+	assert.Equal(t, 1, len(store.metrics))
+	assert.Equal(t, 1, len(store.metricsFiltered))
+	assert.Equal(t, testmetrics2.Metrics[0], store.metrics[0].Metric)
+
+	// assert logs
+	assert.Equal(t, 2, len(store.logs))
+	assert.Equal(t, 2, len(store.logsFiltered))
+	assert.Equal(t, testlogs2.Logs[0], store.logs[0].Log)
+}
+
+func TestStoreRunRetentionSweeper(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	store := NewStoreWithConfig(clock, StoreConfig{Retention: time.Minute})
+
+	tp, _ := test.GenerateOTLPTracesPayload(t, 1, 1, []int{1}, [][]int{{1}})
+	store.AddSpan(&tp)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		store.RunRetentionSweeper(ctx)
+		close(done)
+	}()
+
+	assert.NoError(t, clock.BlockUntilContext(ctx, 1))
+	clock.Advance(time.Minute + RETENTION_SWEEP_INTERVAL)
+
+	assert.Eventually(t, func() bool {
+		store.mut.Lock()
+		defer store.mut.Unlock()
+		return len(store.svcspans) == 0
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+}
+
+func TestStoreExportJSONLines(t *testing.T) {
+	store := NewStore(clockwork.NewRealClock())
+	tp, _ := test.GenerateOTLPTracesPayload(t, 1, 2, []int{2, 1}, [][]int{{2, 1}, {1}})
+	mp, _ := test.GenerateOTLPGaugeMetricsPayload(t, 2, []int{2, 1}, [][]int{{2, 1}, {1}})
+	lp, _ := test.GenerateOTLPLogsPayload(t, 1, 2, []int{2, 1}, [][]int{{2, 1}, {1}})
+	store.AddSpan(&tp)
+	store.AddMetric(&mp)
+	store.AddLog(&lp)
+
+	t.Run("all signals", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, store.ExportJSONLines(&buf, AllSignals, false))
+
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		assert.Equal(t, 3, len(lines))
+
+		traces, err := (&ptrace.JSONUnmarshaler{}).UnmarshalTraces([]byte(lines[0]))
+		assert.NoError(t, err)
+		assert.Equal(t, tp.SpanCount(), traces.SpanCount())
+		metrics, err := (&pmetric.JSONUnmarshaler{}).UnmarshalMetrics([]byte(lines[1]))
+		assert.NoError(t, err)
+		assert.Equal(t, mp.DataPointCount(), metrics.DataPointCount())
+		logs, err := (&plog.JSONUnmarshaler{}).UnmarshalLogs([]byte(lines[2]))
+		assert.NoError(t, err)
+		assert.Equal(t, lp.LogRecordCount(), logs.LogRecordCount())
+	})
+
+	t.Run("filtered traces", func(t *testing.T) {
+		store.ApplyFilterTraces("test-service-2", MatchMode{}, SORT_TYPE_NONE)
+		defer store.ApplyFilterTraces("", MatchMode{}, SORT_TYPE_NONE)
+
+		var buf bytes.Buffer
+		assert.NoError(t, store.ExportJSONLines(&buf, []Signal{SignalTraces}, true))
+
+		traces, err := (&ptrace.JSONUnmarshaler{}).UnmarshalTraces(bytes.TrimSpace(buf.Bytes()))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, traces.SpanCount())
+		assert.Equal(t, "test-service-2", GetServiceNameFromResource(traces.ResourceSpans().At(0).Resource()))
+	})
+
+	t.Run("empty signals are skipped", func(t *testing.T) {
+		store.ApplyFilterLogs("no-such-log", MatchMode{})
+		defer store.ApplyFilterLogs("", MatchMode{})
+
+		var buf bytes.Buffer
+		assert.NoError(t, store.ExportJSONLines(&buf, []Signal{SignalLogs}, true))
+		assert.Equal(t, 0, buf.Len())
+	})
+}
+
+func TestStoreSpansByTraceID(t *testing.T) {
+	store := NewStore(clockwork.NewRealClock())
+	payload, _ := test.GenerateOTLPTracesPayload(t, 1, 1, []int{1}, [][]int{{2}})
+	store.AddSpan(&payload)
+
+	spans, ok := store.SpansByTraceID("01000000000000000000000000000000")
+	assert.True(t, ok)
+	assert.Equal(t, 2, len(spans))
+
+	// the returned spans are a copy
+	spans[0] = nil
+	spans, _ = store.SpansByTraceID("01000000000000000000000000000000")
+	assert.NotNil(t, spans[0])
+
+	_, ok = store.SpansByTraceID("unknown")
+	assert.False(t, ok)
+}
+
+func TestStoreSpansByTraceIDs(t *testing.T) {
+	store := NewStore(clockwork.NewRealClock())
+	payload, _ := test.GenerateOTLPTracesPayload(t, 1, 1, []int{1}, [][]int{{2}})
+	store.AddSpan(&payload)
+	payload, _ = test.GenerateOTLPTracesPayload(t, 2, 1, []int{1}, [][]int{{1}})
+	store.AddSpan(&payload)
+
+	spans := store.SpansByTraceIDs("01000000000000000000000000000000", "unknown", "02000000000000000000000000000000")
+	assert.Equal(t, 3, len(spans))
+	assert.Equal(t, 2, len(spans[0]))
+	assert.Nil(t, spans[1])
+	assert.Equal(t, 1, len(spans[2]))
+}
+
 func TestStoreFlush(t *testing.T) {
 	// traceid: 1
 	//  └- resource: test-service-1
@@ -747,6 +1078,8 @@ func TestStoreFlush(t *testing.T) {
 	assert.Equal(t, 0, len(store.metrics))
 	assert.Equal(t, 0, len(store.metricsFiltered))
 	assert.Equal(t, 0, len(store.metriccache.svcmetric2metrics))
+
+	assert.Equal(t, int64(0), store.MemoryUsage())
 }
 
 func TestLogDataGetResolvedBody(t *testing.T) {
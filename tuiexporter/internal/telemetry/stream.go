@@ -0,0 +1,227 @@
+package telemetry
+
+import (
+	"slices"
+	"sort"
+	"sync/atomic"
+)
+
+// Batch is the data added to the store by a single AddSpan, AddMetric or AddLog call.
+// Only the slice of the signal is set.
+type Batch struct {
+	Signal  Signal
+	Spans   []*SpanData
+	Metrics []*MetricData
+	Logs    []*LogData
+}
+
+// Len returns the number of the data in the batch
+func (b Batch) Len() int {
+	return len(b.Spans) + len(b.Metrics) + len(b.Logs)
+}
+
+// Cursor returns the largest sequence number in the batch, which can be passed to
+// Store.Since to get the data added after the batch
+func (b Batch) Cursor() uint64 {
+	var cursor uint64
+	for _, sd := range b.Spans {
+		cursor = max(cursor, sd.Seq)
+	}
+	for _, md := range b.Metrics {
+		cursor = max(cursor, md.Seq)
+	}
+	for _, ld := range b.Logs {
+		cursor = max(cursor, ld.Seq)
+	}
+	return cursor
+}
+
+// After returns the batch without the data whose sequence number is less than or equal to the cursor
+func (b Batch) After(cursor uint64) Batch {
+	after := Batch{Signal: b.Signal}
+	for _, sd := range b.Spans {
+		if sd.Seq > cursor {
+			after.Spans = append(after.Spans, sd)
+		}
+	}
+	for _, md := range b.Metrics {
+		if md.Seq > cursor {
+			after.Metrics = append(after.Metrics, md)
+		}
+	}
+	for _, ld := range b.Logs {
+		if ld.Seq > cursor {
+			after.Logs = append(after.Logs, ld)
+		}
+	}
+	return after
+}
+
+// DEFAULT_SUBSCRIPTION_BUFFER_SIZE is the default number of the batches buffered for a subscriber
+const DEFAULT_SUBSCRIPTION_BUFFER_SIZE = 256
+
+// Subscription receives the batches of the data added to the store.
+// The batches are buffered up to the buffer size. When the buffer is full, the new batches are
+// dropped and counted instead of blocking the store, so a slow subscriber can catch up with
+// Store.Since after it notices the drops.
+type Subscription struct {
+	store        *Store
+	id           int
+	signals      []Signal
+	ch           chan Batch
+	closed       bool
+	dropped      atomic.Uint64
+	droppedItems atomic.Uint64
+}
+
+// Subscribe creates a subscription to the data of the signals added to the store.
+// No signals means all signals. A buffer size less than 1 is replaced with the default.
+func (s *Store) Subscribe(bufferSize int, signals ...Signal) *Subscription {
+	if bufferSize < 1 {
+		bufferSize = DEFAULT_SUBSCRIPTION_BUFFER_SIZE
+	}
+	if len(signals) == 0 {
+		signals = AllSignals
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	s.subscriptionID++
+	sub := &Subscription{
+		store:   s,
+		id:      s.subscriptionID,
+		signals: signals,
+		ch:      make(chan Batch, bufferSize),
+	}
+	s.subscriptions[sub.id] = sub
+
+	return sub
+}
+
+// C returns the channel of the batches. It is closed when the subscription is closed.
+func (sub *Subscription) C() <-chan Batch {
+	return sub.ch
+}
+
+// Dropped returns the number of the batches dropped because the buffer was full
+func (sub *Subscription) Dropped() uint64 {
+	return sub.dropped.Load()
+}
+
+// DroppedItems returns the number of the spans, metrics and logs in the dropped batches
+func (sub *Subscription) DroppedItems() uint64 {
+	return sub.droppedItems.Load()
+}
+
+// Close stops the subscription and closes the channel. It is safe to call it more than once.
+func (sub *Subscription) Close() {
+	sub.store.mut.Lock()
+	defer sub.store.mut.Unlock()
+
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(sub.store.subscriptions, sub.id)
+	close(sub.ch)
+}
+
+// publish sends the batch without blocking. It must be called while the store is locked.
+func (sub *Subscription) publish(batch Batch) bool {
+	if !slices.Contains(sub.signals, batch.Signal) {
+		return true
+	}
+	select {
+	case sub.ch <- batch:
+		return true
+	default:
+		sub.dropped.Add(1)
+		sub.droppedItems.Add(uint64(batch.Len()))
+		return false
+	}
+}
+
+// SubscriberCount returns the number of the active subscriptions
+func (s *Store) SubscriberCount() int {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	return len(s.subscriptions)
+}
+
+// DroppedBatches returns the total number of the batches dropped by all subscriptions
+// including the closed ones
+func (s *Store) DroppedBatches() uint64 {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	return s.droppedBatches
+}
+
+// Since returns the data of the signals in the store whose sequence number is greater than the
+// cursor as batches sorted by the sequence number. The data of a signal added between the data
+// of another signal is split into separate batches, so the cursor of each batch can be sent to the
+// client in order. The data rotated out of the store is not included.
+func (s *Store) Since(cursor uint64, signals []Signal) []Batch {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	// split the data into the batches of a single item to merge them in the order of the sequence number
+	items := []Batch{}
+	for _, signal := range signals {
+		switch signal {
+		case SignalTraces:
+			for _, sd := range s.allSpans() {
+				items = append(items, Batch{Signal: signal, Spans: []*SpanData{sd}})
+			}
+		case SignalMetrics:
+			for _, md := range s.metrics {
+				items = append(items, Batch{Signal: signal, Metrics: []*MetricData{md}})
+			}
+		case SignalLogs:
+			for _, ld := range s.logs {
+				items = append(items, Batch{Signal: signal, Logs: []*LogData{ld}})
+			}
+		}
+	}
+	items = slices.DeleteFunc(items, func(b Batch) bool { return b.Cursor() <= cursor })
+	sort.Slice(items, func(i, j int) bool { return items[i].Cursor() < items[j].Cursor() })
+
+	batches := []Batch{}
+	for _, item := range items {
+		if n := len(batches); n > 0 && batches[n-1].Signal == item.Signal {
+			last := &batches[n-1]
+			last.Spans = append(last.Spans, item.Spans...)
+			last.Metrics = append(last.Metrics, item.Metrics...)
+			last.Logs = append(last.Logs, item.Logs...)
+			continue
+		}
+		batches = append(batches, item)
+	}
+	return batches
+}
+
+// Cursor returns the sequence number of the data added last
+func (s *Store) Cursor() uint64 {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	return s.seq
+}
+
+func (s *Store) nextSeq() uint64 {
+	s.seq++
+	return s.seq
+}
+
+func (s *Store) publish(batch Batch) {
+	if batch.Len() == 0 {
+		return
+	}
+	for _, sub := range s.subscriptions {
+		if !sub.publish(batch) {
+			s.droppedBatches++
+		}
+	}
+}
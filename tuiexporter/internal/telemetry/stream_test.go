@@ -0,0 +1,160 @@
+package telemetry
+
+import (
+	"testing"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/test"
+)
+
+func TestStoreSubscribe(t *testing.T) {
+	store := NewStore(clockwork.NewRealClock())
+
+	all := store.Subscribe(0)
+	logs := store.Subscribe(0, SignalLogs)
+	assert.Equal(t, 2, store.SubscriberCount())
+
+	traces, _ := test.GenerateOTLPTracesPayload(t, 1, 1, []int{1}, [][]int{{2}})
+	store.AddSpan(&traces)
+	metrics, _ := test.GenerateOTLPGaugeMetricsPayload(t, 1, []int{1}, [][]int{{1}})
+	store.AddMetric(&metrics)
+	logPayload, _ := test.GenerateOTLPLogsPayload(t, 1, 1, []int{1}, [][]int{{1}})
+	store.AddLog(&logPayload)
+
+	got := <-all.C()
+	assert.Equal(t, SignalTraces, got.Signal)
+	assert.Equal(t, 2, got.Len())
+	assert.Equal(t, uint64(2), got.Cursor())
+	got = <-all.C()
+	assert.Equal(t, SignalMetrics, got.Signal)
+	assert.Equal(t, uint64(3), got.Cursor())
+	got = <-all.C()
+	assert.Equal(t, SignalLogs, got.Signal)
+	assert.Equal(t, uint64(5), got.Cursor())
+
+	got = <-logs.C()
+	assert.Equal(t, SignalLogs, got.Signal)
+	assert.Equal(t, 2, got.Len())
+	assert.Equal(t, 0, len(logs.C()))
+
+	all.Close()
+	all.Close()
+	_, ok := <-all.C()
+	assert.False(t, ok)
+	assert.Equal(t, 1, store.SubscriberCount())
+	assert.Equal(t, uint64(5), store.Cursor())
+}
+
+func TestStoreSubscribeDrop(t *testing.T) {
+	store := NewStore(clockwork.NewRealClock())
+	slow := store.Subscribe(1, SignalTraces)
+	fast := store.Subscribe(3, SignalTraces)
+
+	// AddSpan never waits for the subscribers
+	for i := range 3 {
+		traces, _ := test.GenerateOTLPTracesPayload(t, i+1, 1, []int{1}, [][]int{{2}})
+		store.AddSpan(&traces)
+	}
+
+	assert.Equal(t, 1, len(slow.C()))
+	assert.Equal(t, uint64(2), slow.Dropped())
+	assert.Equal(t, uint64(4), slow.DroppedItems())
+	assert.Equal(t, 3, len(fast.C()))
+	assert.Equal(t, uint64(0), fast.Dropped())
+	assert.Equal(t, uint64(2), store.DroppedBatches())
+
+	// the dropped data can be read from the store
+	got := <-slow.C()
+	missed := store.Since(got.Cursor(), []Signal{SignalTraces})
+	assert.Equal(t, 1, len(missed))
+	assert.Equal(t, 4, missed[0].Len())
+
+	slow.Close()
+	assert.Equal(t, uint64(2), store.DroppedBatches())
+}
+
+func TestStoreSince(t *testing.T) {
+	store := NewStore(clockwork.NewRealClock())
+
+	traces1, _ := test.GenerateOTLPTracesPayload(t, 1, 1, []int{1}, [][]int{{2}}) // seq 1, 2
+	store.AddSpan(&traces1)
+	logs, _ := test.GenerateOTLPLogsPayload(t, 1, 1, []int{1}, [][]int{{1}}) // seq 3, 4
+	store.AddLog(&logs)
+	traces2, _ := test.GenerateOTLPTracesPayload(t, 2, 1, []int{1}, [][]int{{1}}) // seq 5
+	store.AddSpan(&traces2)
+
+	t.Run("all signals", func(t *testing.T) {
+		// the batches are in the order of the sequence number even if the signals are interleaved
+		got := store.Since(1, AllSignals)
+		assert.Equal(t, 3, len(got))
+		assert.Equal(t, SignalTraces, got[0].Signal)
+		assert.Equal(t, 1, got[0].Len())
+		assert.Equal(t, uint64(2), got[0].Cursor())
+		assert.Equal(t, SignalLogs, got[1].Signal)
+		assert.Equal(t, []uint64{3, 4}, []uint64{got[1].Logs[0].Seq, got[1].Logs[1].Seq})
+		assert.Equal(t, SignalTraces, got[2].Signal)
+		assert.Equal(t, 1, got[2].Len())
+		assert.Equal(t, uint64(5), got[2].Cursor())
+	})
+
+	t.Run("resume by the cursor of each batch", func(t *testing.T) {
+		// a client moving its cursor to each batch receives all data
+		var cursor uint64
+		seqs := []uint64{}
+		for _, batch := range store.Since(0, AllSignals) {
+			batch = batch.After(cursor)
+			for _, sd := range batch.Spans {
+				seqs = append(seqs, sd.Seq)
+			}
+			for _, ld := range batch.Logs {
+				seqs = append(seqs, ld.Seq)
+			}
+			cursor = max(cursor, batch.Cursor())
+		}
+		assert.Equal(t, []uint64{1, 2, 3, 4, 5}, seqs)
+	})
+
+	t.Run("selected signals", func(t *testing.T) {
+		got := store.Since(0, []Signal{SignalLogs, SignalMetrics})
+		assert.Equal(t, 1, len(got))
+		assert.Equal(t, SignalLogs, got[0].Signal)
+	})
+
+	t.Run("up to date", func(t *testing.T) {
+		assert.Empty(t, store.Since(store.Cursor(), AllSignals))
+	})
+}
+
+func TestBatchAfter(t *testing.T) {
+	batch := Batch{
+		Signal: SignalTraces,
+		Spans:  []*SpanData{{Seq: 3}, {Seq: 4}, {Seq: 5}},
+	}
+
+	got := batch.After(3)
+	assert.Equal(t, SignalTraces, got.Signal)
+	assert.Equal(t, 2, got.Len())
+	assert.Equal(t, uint64(5), got.Cursor())
+	assert.Equal(t, 0, batch.After(5).Len())
+}
+
+func TestStoreSeq(t *testing.T) {
+	store := NewStore(clockwork.NewRealClock())
+	store.maxLogCount = 2
+
+	logs1, _ := test.GenerateOTLPLogsPayload(t, 1, 1, []int{1}, [][]int{{1}}) // seq 1, 2
+	store.AddLog(&logs1)
+	logs2, _ := test.GenerateOTLPLogsPayload(t, 2, 1, []int{1}, [][]int{{1}}) // seq 3, 4
+	store.AddLog(&logs2)
+
+	// the sequence numbers are kept through the rotation
+	assert.Equal(t, []uint64{3, 4}, []uint64{store.logs[0].Seq, store.logs[1].Seq})
+
+	// and never reused after the flush
+	store.Flush()
+	traces, _ := test.GenerateOTLPTracesPayload(t, 1, 1, []int{1}, [][]int{{1}})
+	store.AddSpan(&traces)
+	assert.Equal(t, uint64(5), store.svcspans[0].Seq)
+	assert.Equal(t, uint64(5), store.Cursor())
+}
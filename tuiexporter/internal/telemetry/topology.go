@@ -0,0 +1,580 @@
+package telemetry
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ymtdzzz/mermaid-ascii/pkg/drawer"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// externalPeerAttributes are the attributes of the client and producer spans naming the peer,
+// in the order of the priority
+var externalPeerAttributes = []string{"peer.service", "db.system", "messaging.system", "server.address"}
+
+// TopologyStats is the aggregate of the spans of a node or an edge of the topology
+type TopologyStats struct {
+	Count      int
+	ErrorCount int
+	Average    time.Duration
+	P95        time.Duration
+	durations  []time.Duration
+}
+
+// ErrorRate returns the ratio of the spans with the error status
+func (s *TopologyStats) ErrorRate() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return float64(s.ErrorCount) / float64(s.Count)
+}
+
+func (s *TopologyStats) add(sample topologySample) {
+	s.Count++
+	if sample.hasError {
+		s.ErrorCount++
+	}
+	s.durations = append(s.durations, sample.end.Sub(sample.start))
+}
+
+func (s *TopologyStats) finish() {
+	if len(s.durations) > 0 {
+		var sum time.Duration
+		for _, d := range s.durations {
+			sum += d
+		}
+		s.Average = sum / time.Duration(len(s.durations))
+		s.P95 = percentileDuration(s.durations, 0.95)
+	}
+	s.durations = nil
+}
+
+// TopologyNode is a service of the topology. The requests of the service are the spans whose
+// parent span is not received or belongs to another service.
+//
+// An external node is a peer which does not send spans, such as a database, a message queue or
+// a third-party API. It is inferred from the attribute of the client and producer spans calling
+// it, and its requests are those spans.
+type TopologyNode struct {
+	Service string
+	// Depth is the number of the calls from the nearest service which is not called by any service
+	Depth int
+	// External is true if the node is inferred from the attribute and no span of it is received
+	External bool
+	// Attribute is the key of the attribute naming the external node (e.g. db.system)
+	Attribute string
+	TopologyStats
+}
+
+// TopologyEdge is the calls from a service to another. A call is a span whose parent span belongs
+// to the source service, and the latency and the status of the call are those of the span. A call
+// to an external node is the client or producer span of the source service.
+type TopologyEdge struct {
+	Source string
+	Target string
+	TopologyStats
+	attribute string
+}
+
+// Condition returns the query condition matching the requests of the node
+func (n *TopologyNode) Condition() string {
+	if n.Attribute != "" {
+		return AttributeCondition(n.Attribute, n.Service)
+	}
+	return ServiceCondition(n.Service)
+}
+
+// Condition returns the query condition matching the calls of the edge
+func (e *TopologyEdge) Condition() string {
+	if e.attribute != "" {
+		return ServiceCondition(e.Source) + " AND " + AttributeCondition(e.attribute, e.Target)
+	}
+	return CallCondition(e.Source, e.Target)
+}
+
+// Topology is the dependency graph of the services with the statistics of the nodes and edges
+type Topology struct {
+	Nodes []*TopologyNode
+	Edges []*TopologyEdge
+	// Window is the period from the start of the first request or call to the end of the last one
+	Window time.Duration
+}
+
+// NewTopology builds the topology of the spans. The nodes are sorted by the service and the edges
+// are sorted by the source and target.
+func NewTopology(spans []*SpanData) *Topology {
+	m := newTopologyModel()
+	for _, sd := range spans {
+		m.add(sd)
+	}
+	return m.snapshot(time.Time{})
+}
+
+type topologyEdgeKey struct{ source, target string }
+
+// topologySample is a span counted as a request of a node or a call of an edge
+type topologySample struct {
+	start    time.Time
+	end      time.Time
+	hasError bool
+}
+
+// topologyAggregate is the samples of a node or an edge by the span id
+type topologyAggregate struct {
+	samples map[string]topologySample
+	// attribute is the key of the attribute naming the external node
+	attribute string
+}
+
+// topologyContribution is how a span is counted in the topology
+type topologyContribution struct {
+	sample topologySample
+	// request is true if the span is a request of its service
+	request bool
+	// caller is the service of the parent span if it is another service
+	caller string
+	// peer is the external node called by the span and attribute is the key naming it
+	peer      string
+	attribute string
+}
+
+// topologyModel keeps the topology up to date as the spans are added and deleted. Only the spans
+// related to the added or deleted span (the span itself, its parent and its children) are
+// evaluated again, and the statistics are computed from the samples on a snapshot.
+//
+// The external nodes are kept apart from the services so that a peer named after a service does
+// not count as the requests of the service.
+type topologyModel struct {
+	spans    SpanDataMap
+	children map[string]SpanDataMap
+	contribs map[string]*topologyContribution
+	received map[string]int
+	nodes    map[string]*topologyAggregate
+	peers    map[string]*topologyAggregate
+	edges    map[topologyEdgeKey]*topologyAggregate
+	// size is the estimated size in bytes of the entries above
+	size int64
+}
+
+func newTopologyModel() *topologyModel {
+	return &topologyModel{
+		spans:    SpanDataMap{},
+		children: map[string]SpanDataMap{},
+		contribs: map[string]*topologyContribution{},
+		received: map[string]int{},
+		nodes:    map[string]*topologyAggregate{},
+		peers:    map[string]*topologyAggregate{},
+		edges:    map[topologyEdgeKey]*topologyAggregate{},
+	}
+}
+
+func (m *topologyModel) add(sd *SpanData) {
+	spanID, parentID := sd.Span.SpanID().String(), sd.Span.ParentSpanID().String()
+	if old, ok := m.spans[spanID]; ok {
+		m.remove(old)
+	}
+	m.spans[spanID] = sd
+	if _, ok := m.children[parentID]; !ok {
+		m.children[parentID] = SpanDataMap{}
+	}
+	m.children[parentID][spanID] = sd
+	m.size += topologySpanOverheadSize
+	svc := sd.GetServiceName()
+	if m.received[svc] == 0 {
+		m.size += topologyAggregateOverheadSize + int64(len(svc))
+	}
+	m.received[svc]++
+
+	m.evaluate(sd)
+	m.evaluateRelatives(spanID, parentID)
+}
+
+func (m *topologyModel) remove(sd *SpanData) {
+	spanID, parentID := sd.Span.SpanID().String(), sd.Span.ParentSpanID().String()
+	if _, ok := m.spans[spanID]; !ok {
+		return
+	}
+	m.retract(spanID)
+	delete(m.spans, spanID)
+	delete(m.children[parentID], spanID)
+	if len(m.children[parentID]) == 0 {
+		delete(m.children, parentID)
+	}
+	m.size -= topologySpanOverheadSize
+	svc := sd.GetServiceName()
+	m.received[svc]--
+	if m.received[svc] <= 0 {
+		delete(m.received, svc)
+		m.size -= topologyAggregateOverheadSize + int64(len(svc))
+	}
+
+	m.evaluateRelatives(spanID, parentID)
+}
+
+// evaluateRelatives evaluates the parent, whose external peer depends on the children, and the
+// children, whose caller depends on the parent, of the span again
+func (m *topologyModel) evaluateRelatives(spanID, parentID string) {
+	if parent, ok := m.spans[parentID]; ok {
+		m.evaluate(parent)
+	}
+	for _, child := range m.children[spanID] {
+		m.evaluate(child)
+	}
+}
+
+func (m *topologyModel) evaluate(sd *SpanData) {
+	spanID := sd.Span.SpanID().String()
+	m.retract(spanID)
+
+	svc := sd.GetServiceName()
+	c := &topologyContribution{
+		sample: topologySample{
+			start:    sd.Span.StartTimestamp().AsTime(),
+			end:      sd.Span.EndTimestamp().AsTime(),
+			hasError: spanHasError(sd.Span),
+		},
+	}
+	children := make([]*SpanData, 0, len(m.children[spanID]))
+	for _, child := range m.children[spanID] {
+		children = append(children, child)
+	}
+	if peer, attr, ok := externalPeer(sd, children); ok && peer != svc {
+		c.peer, c.attribute = peer, attr
+	}
+	parent, ok := m.spans[sd.Span.ParentSpanID().String()]
+	if !ok || parent.GetServiceName() != svc {
+		c.request = true
+	}
+	if ok && parent.GetServiceName() != svc {
+		c.caller = parent.GetServiceName()
+	}
+
+	m.contribs[spanID] = c
+	m.size += topologyContributionOverheadSize
+	if c.request {
+		m.addSample(m.node(svc), spanID, c.sample)
+	}
+	if c.caller != "" {
+		m.addSample(m.edge(c.caller, svc), spanID, c.sample)
+	}
+	if c.peer != "" {
+		pn := m.peer(c.peer)
+		m.addSample(pn, spanID, c.sample)
+		if pn.attribute == "" {
+			pn.attribute = c.attribute
+		}
+		pe := m.edge(svc, c.peer)
+		m.addSample(pe, spanID, c.sample)
+		pe.attribute = c.attribute
+	}
+}
+
+// retract removes the samples of the span from the nodes and edges
+func (m *topologyModel) retract(spanID string) {
+	c, ok := m.contribs[spanID]
+	if !ok {
+		return
+	}
+	delete(m.contribs, spanID)
+	m.size -= topologyContributionOverheadSize
+
+	svc := m.spans[spanID].GetServiceName()
+	if c.request {
+		m.deleteNodeSample(svc, spanID)
+	}
+	if c.caller != "" {
+		m.deleteEdgeSample(c.caller, svc, spanID)
+	}
+	if c.peer != "" {
+		m.deletePeerSample(c.peer, spanID)
+		m.deleteEdgeSample(svc, c.peer, spanID)
+	}
+}
+
+func (m *topologyModel) node(service string) *topologyAggregate {
+	n, ok := m.nodes[service]
+	if !ok {
+		n = &topologyAggregate{samples: map[string]topologySample{}}
+		m.nodes[service] = n
+		m.size += topologyAggregateOverheadSize + int64(len(service))
+	}
+	return n
+}
+
+func (m *topologyModel) peer(name string) *topologyAggregate {
+	p, ok := m.peers[name]
+	if !ok {
+		p = &topologyAggregate{samples: map[string]topologySample{}}
+		m.peers[name] = p
+		m.size += topologyAggregateOverheadSize + int64(len(name))
+	}
+	return p
+}
+
+func (m *topologyModel) edge(source, target string) *topologyAggregate {
+	k := topologyEdgeKey{source, target}
+	e, ok := m.edges[k]
+	if !ok {
+		e = &topologyAggregate{samples: map[string]topologySample{}}
+		m.edges[k] = e
+		m.size += topologyAggregateOverheadSize + int64(len(source)+len(target))
+	}
+	return e
+}
+
+func (m *topologyModel) addSample(agg *topologyAggregate, spanID string, sample topologySample) {
+	if _, ok := agg.samples[spanID]; !ok {
+		m.size += topologySampleOverheadSize
+	}
+	agg.samples[spanID] = sample
+}
+
+// deleteSample deletes the sample of the span from the node or edge and returns true if the node
+// or edge has no sample left
+func (m *topologyModel) deleteSample(agg *topologyAggregate, spanID string) bool {
+	if _, ok := agg.samples[spanID]; ok {
+		delete(agg.samples, spanID)
+		m.size -= topologySampleOverheadSize
+	}
+	return len(agg.samples) == 0
+}
+
+func (m *topologyModel) deleteNodeSample(service, spanID string) {
+	if n, ok := m.nodes[service]; ok && m.deleteSample(n, spanID) {
+		delete(m.nodes, service)
+		m.size -= topologyAggregateOverheadSize + int64(len(service))
+	}
+}
+
+func (m *topologyModel) deletePeerSample(name, spanID string) {
+	if p, ok := m.peers[name]; ok && m.deleteSample(p, spanID) {
+		delete(m.peers, name)
+		m.size -= topologyAggregateOverheadSize + int64(len(name))
+	}
+}
+
+func (m *topologyModel) deleteEdgeSample(source, target, spanID string) {
+	k := topologyEdgeKey{source, target}
+	if e, ok := m.edges[k]; ok && m.deleteSample(e, spanID) {
+		delete(m.edges, k)
+		m.size -= topologyAggregateOverheadSize + int64(len(source)+len(target))
+	}
+}
+
+// snapshot returns the topology of the samples. If since is not zero, only the samples ending
+// after it are included, and the nodes and edges without samples are omitted.
+func (m *topologyModel) snapshot(since time.Time) *Topology {
+	var start, end time.Time
+	stats := func(agg *topologyAggregate) TopologyStats {
+		var s TopologyStats
+		for _, sample := range agg.samples {
+			if !since.IsZero() && sample.end.Before(since) {
+				continue
+			}
+			if start.IsZero() || sample.start.Before(start) {
+				start = sample.start
+			}
+			if sample.end.After(end) {
+				end = sample.end
+			}
+			s.add(sample)
+		}
+		s.finish()
+		return s
+	}
+
+	topo := &Topology{
+		Nodes: make([]*TopologyNode, 0, len(m.nodes)+len(m.peers)),
+		Edges: make([]*TopologyEdge, 0, len(m.edges)),
+	}
+	for svc, agg := range m.nodes {
+		n := &TopologyNode{Service: svc, TopologyStats: stats(agg)}
+		if n.Count == 0 {
+			continue
+		}
+		topo.Nodes = append(topo.Nodes, n)
+	}
+	for peer, agg := range m.peers {
+		// the peer sending spans is the node of the service, whose requests are its own spans
+		if m.received[peer] > 0 {
+			continue
+		}
+		n := &TopologyNode{Service: peer, External: true, Attribute: agg.attribute, TopologyStats: stats(agg)}
+		if n.Count == 0 {
+			continue
+		}
+		topo.Nodes = append(topo.Nodes, n)
+	}
+	for k, agg := range m.edges {
+		e := &TopologyEdge{Source: k.source, Target: k.target, TopologyStats: stats(agg)}
+		if e.Count == 0 {
+			continue
+		}
+		if m.received[k.target] == 0 {
+			e.attribute = agg.attribute
+		}
+		topo.Edges = append(topo.Edges, e)
+	}
+	if !start.IsZero() {
+		topo.Window = end.Sub(start)
+	}
+	sort.Slice(topo.Nodes, func(i, j int) bool {
+		return topo.Nodes[i].Service < topo.Nodes[j].Service
+	})
+	sort.Slice(topo.Edges, func(i, j int) bool {
+		if topo.Edges[i].Source != topo.Edges[j].Source {
+			return topo.Edges[i].Source < topo.Edges[j].Source
+		}
+		return topo.Edges[i].Target < topo.Edges[j].Target
+	})
+	topo.updateDepth()
+
+	return topo
+}
+
+// updateDepth sets the depth of the nodes by the breadth-first search from the nodes which are
+// not called by any service. The nodes only in a cycle are at the depth 0.
+func (t *Topology) updateDepth() {
+	called := map[string]bool{}
+	callees := map[string][]string{}
+	for _, e := range t.Edges {
+		called[e.Target] = true
+		callees[e.Source] = append(callees[e.Source], e.Target)
+	}
+
+	depth := map[string]int{}
+	queue := []string{}
+	for _, n := range t.Nodes {
+		if !called[n.Service] {
+			depth[n.Service] = 0
+			queue = append(queue, n.Service)
+		}
+	}
+	for len(queue) > 0 {
+		svc := queue[0]
+		queue = queue[1:]
+		for _, callee := range callees[svc] {
+			if _, ok := depth[callee]; ok {
+				continue
+			}
+			depth[callee] = depth[svc] + 1
+			queue = append(queue, callee)
+		}
+	}
+	for _, n := range t.Nodes {
+		n.Depth = depth[n.Service]
+	}
+}
+
+// Rate returns the number of the spans per second in the window of the topology. The window is
+// at least a second so that a few short spans do not make a huge rate.
+func (t *Topology) Rate(s *TopologyStats) float64 {
+	window := max(t.Window, time.Second)
+	return float64(s.Count) / window.Seconds()
+}
+
+// Neighbors returns the topology of the service and the services calling it or called by it
+func (t *Topology) Neighbors(service string) *Topology {
+	services := map[string]bool{service: true}
+	topo := &Topology{Window: t.Window}
+	for _, e := range t.Edges {
+		if e.Source == service || e.Target == service {
+			services[e.Source], services[e.Target] = true, true
+			topo.Edges = append(topo.Edges, e)
+		}
+	}
+	for _, n := range t.Nodes {
+		if services[n.Service] {
+			topo.Nodes = append(topo.Nodes, n)
+		}
+	}
+	return topo
+}
+
+// CollapseLeaves returns the topology without the leaf services, which are called by other
+// services but do not call any service, and the edges to them
+func (t *Topology) CollapseLeaves() *Topology {
+	callers, callees := map[string]bool{}, map[string]bool{}
+	for _, e := range t.Edges {
+		callers[e.Source], callees[e.Target] = true, true
+	}
+	isLeaf := func(service string) bool {
+		return callees[service] && !callers[service]
+	}
+
+	topo := &Topology{Window: t.Window}
+	for _, n := range t.Nodes {
+		if !isLeaf(n.Service) {
+			topo.Nodes = append(topo.Nodes, n)
+		}
+	}
+	for _, e := range t.Edges {
+		if !isLeaf(e.Target) {
+			topo.Edges = append(topo.Edges, e)
+		}
+	}
+	return topo
+}
+
+// Draw draws the graph of the topology as ASCII art with the call counts on the edges
+func (t *Topology) Draw() (string, error) {
+	props, err := drawer.MermaidFileToMap(t.mermaid(), "cli")
+	if err != nil {
+		return "", err
+	}
+	return drawer.DrawMap(props), nil
+}
+
+func (t *Topology) mermaid() string {
+	var sb strings.Builder
+	sb.WriteString("graph LR\n")
+	connected := map[string]bool{}
+	for _, e := range t.Edges {
+		connected[e.Source], connected[e.Target] = true, true
+		sb.WriteString(fmt.Sprintf("%s -->|%d| %s\n", e.Source, e.Count, e.Target))
+	}
+	for _, n := range t.Nodes {
+		if !connected[n.Service] {
+			sb.WriteString(fmt.Sprintf("%s\n", n.Service))
+		}
+	}
+	return sb.String()
+}
+
+// externalPeer returns the name of the peer called by the client or producer span and the key of
+// the attribute naming it, if no span of the peer is received as a child of the span
+func externalPeer(sd *SpanData, children []*SpanData) (string, string, bool) {
+	if kind := sd.Span.Kind(); kind != ptrace.SpanKindClient && kind != ptrace.SpanKindProducer {
+		return "", "", false
+	}
+	for _, c := range children {
+		kind := c.Span.Kind()
+		if kind == ptrace.SpanKindServer || kind == ptrace.SpanKindConsumer || c.GetServiceName() != sd.GetServiceName() {
+			return "", "", false
+		}
+	}
+	for _, key := range externalPeerAttributes {
+		if v, ok := sd.Span.Attributes().Get(key); ok && v.AsString() != "" {
+			return v.AsString(), key, true
+		}
+	}
+	return "", "", false
+}
+
+// getTopology returns the topology of the spans in the cache, which is kept up to date as the spans
+// are added and deleted. If since is not zero, only the spans ending after it are included.
+// It must be called while the store is locked.
+func (c *TraceCache) getTopology(since time.Time) *Topology {
+	return c.topology.snapshot(since)
+}
+
+// Topology returns the topology of the spans in the store. If since is not zero, only the spans
+// ending after it are included.
+func (s *Store) Topology(since time.Time) *Topology {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	return s.tracecache.getTopology(since)
+}
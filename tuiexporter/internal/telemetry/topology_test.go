@@ -0,0 +1,378 @@
+package telemetry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/test"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func newTestTopologyStore(t *testing.T) *Store {
+	t.Helper()
+
+	// traceid: 1
+	//  └- test-service-1: span-0-0-0 0-1000ms
+	//    └- test-service-2: span-1-0-0 100-600ms (error)
+	//    | └- test-service-2: span-1-0-1 200-400ms
+	//    |   └- test-service-3: span-2-0-0 250-350ms
+	//    └- test-service-2: span-1-0-2 700-900ms
+	payload, testdata := test.GenerateOTLPTracesPayload(t, 1, 3, []int{1, 1, 1}, [][]int{{1}, {3}, {1}})
+	spans := testdata.Spans
+	base := time.Date(2024, 3, 30, 12, 30, 15, 0, time.UTC)
+	times := [][2]int{{0, 1000}, {100, 600}, {200, 400}, {700, 900}, {250, 350}}
+	parents := []int{-1, 0, 1, 0, 2}
+	for i, span := range spans {
+		span.SetStartTimestamp(pcommon.NewTimestampFromTime(base.Add(time.Duration(times[i][0]) * time.Millisecond)))
+		span.SetEndTimestamp(pcommon.NewTimestampFromTime(base.Add(time.Duration(times[i][1]) * time.Millisecond)))
+		if parents[i] < 0 {
+			span.SetParentSpanID(pcommon.NewSpanIDEmpty())
+		} else {
+			span.SetParentSpanID(spans[parents[i]].SpanID())
+		}
+	}
+	spans[1].Status().SetCode(ptrace.StatusCodeError)
+	store := NewStore(clockwork.NewRealClock())
+	store.AddSpan(&payload)
+
+	return store
+}
+
+func TestStoreTopology(t *testing.T) {
+	topo := newTestTopologyStore(t).Topology(time.Time{})
+
+	assert.Equal(t, time.Second, topo.Window)
+
+	assert.Equal(t, 3, len(topo.Nodes))
+	n1, n2, n3 := topo.Nodes[0], topo.Nodes[1], topo.Nodes[2]
+	assert.Equal(t, "test-service-1", n1.Service)
+	assert.Equal(t, 1, n1.Count)
+	assert.Equal(t, time.Second, n1.Average)
+	// the span called by the span of the same service is not a request
+	assert.Equal(t, "test-service-2", n2.Service)
+	assert.Equal(t, 2, n2.Count)
+	assert.Equal(t, 1, n2.ErrorCount)
+	assert.Equal(t, 0.5, n2.ErrorRate())
+	assert.Equal(t, 2.0, topo.Rate(&n2.TopologyStats))
+	assert.Equal(t, "test-service-3", n3.Service)
+	assert.Equal(t, 1, n3.Count)
+	assert.Equal(t, 0, n1.Depth)
+	assert.Equal(t, 1, n2.Depth)
+	assert.Equal(t, 2, n3.Depth)
+
+	assert.Equal(t, 2, len(topo.Edges))
+	e1, e2 := topo.Edges[0], topo.Edges[1]
+	assert.Equal(t, "test-service-1", e1.Source)
+	assert.Equal(t, "test-service-2", e1.Target)
+	assert.Equal(t, 2, e1.Count)
+	assert.Equal(t, 1, e1.ErrorCount)
+	assert.Equal(t, 350*time.Millisecond, e1.Average)
+	assert.Equal(t, 500*time.Millisecond, e1.P95)
+	assert.Equal(t, "test-service-2", e2.Source)
+	assert.Equal(t, "test-service-3", e2.Target)
+	assert.Equal(t, 1, e2.Count)
+	assert.Equal(t, 0, e2.ErrorCount)
+	assert.Equal(t, 100*time.Millisecond, e2.P95)
+
+	t.Run("no spans", func(t *testing.T) {
+		topo := NewTopology(nil)
+
+		assert.Empty(t, topo.Nodes)
+		assert.Empty(t, topo.Edges)
+		assert.Equal(t, time.Duration(0), topo.Window)
+	})
+
+	t.Run("rate of short window", func(t *testing.T) {
+		topo := &Topology{Window: 100 * time.Millisecond}
+
+		assert.Equal(t, 3.0, topo.Rate(&TopologyStats{Count: 3}))
+	})
+}
+
+func TestTopologyNeighbors(t *testing.T) {
+	topo := newTestTopologyStore(t).Topology(time.Time{})
+
+	got := topo.Neighbors("test-service-3")
+
+	assert.Equal(t, 2, len(got.Nodes))
+	assert.Equal(t, "test-service-2", got.Nodes[0].Service)
+	assert.Equal(t, "test-service-3", got.Nodes[1].Service)
+	assert.Equal(t, 1, len(got.Edges))
+	assert.Equal(t, "test-service-2", got.Edges[0].Source)
+	assert.Equal(t, topo.Window, got.Window)
+
+	got = topo.Neighbors("test-service-2")
+
+	assert.Equal(t, 3, len(got.Nodes))
+	assert.Equal(t, 2, len(got.Edges))
+
+	got = topo.Neighbors("unknown")
+
+	assert.Empty(t, got.Nodes)
+	assert.Empty(t, got.Edges)
+}
+
+func TestTopologyCollapseLeaves(t *testing.T) {
+	topo := newTestTopologyStore(t).Topology(time.Time{})
+
+	got := topo.CollapseLeaves()
+
+	assert.Equal(t, 2, len(got.Nodes))
+	assert.Equal(t, "test-service-1", got.Nodes[0].Service)
+	assert.Equal(t, "test-service-2", got.Nodes[1].Service)
+	assert.Equal(t, 1, len(got.Edges))
+	assert.Equal(t, "test-service-2", got.Edges[0].Target)
+
+	t.Run("isolated service", func(t *testing.T) {
+		topo := &Topology{Nodes: []*TopologyNode{{Service: "a"}}}
+
+		assert.Equal(t, 1, len(topo.CollapseLeaves().Nodes))
+	})
+}
+
+func TestTopologyMermaid(t *testing.T) {
+	topo := newTestTopologyStore(t).Topology(time.Time{})
+	topo.Nodes = append(topo.Nodes, &TopologyNode{Service: "test-service-4"})
+
+	want := `graph LR
+test-service-1 -->|2| test-service-2
+test-service-2 -->|1| test-service-3
+test-service-4
+`
+	assert.Equal(t, want, topo.mermaid())
+}
+
+func TestStoreTopologySince(t *testing.T) {
+	store := newTestTopologyStore(t)
+	base := time.Date(2024, 3, 30, 12, 30, 15, 0, time.UTC)
+
+	// the spans ending at or after 700ms
+	topo := store.Topology(base.Add(700 * time.Millisecond))
+
+	assert.Equal(t, 2, len(topo.Nodes))
+	assert.Equal(t, 1, topo.Nodes[1].Count)
+	assert.Equal(t, 1, len(topo.Edges))
+	assert.Equal(t, 1, topo.Edges[0].Count)
+	assert.Equal(t, 0, topo.Edges[0].ErrorCount)
+	assert.Equal(t, time.Second, topo.Window)
+
+	topo = store.Topology(time.Time{})
+
+	assert.Equal(t, 3, len(topo.Nodes))
+}
+
+func TestTopologyExternalNodes(t *testing.T) {
+	// traceid: 1
+	//  └- test-service-1: span-0-0-0 (server)
+	//    └- span-0-0-1 (client, db.system: postgresql, error)
+	//    └- span-0-0-2 (client, server.address: api.example.com)
+	//    | └- test-service-2: span-1-0-0 (server)
+	//    └- span-0-0-3 (producer, messaging.system: kafka, peer.service: orders)
+	payload, testdata := test.GenerateOTLPTracesPayload(t, 1, 2, []int{1, 1}, [][]int{{4}, {1}})
+	spans := testdata.Spans
+	spans[0].SetKind(ptrace.SpanKindServer)
+	spans[0].SetParentSpanID(pcommon.NewSpanIDEmpty())
+	for _, span := range spans[1:4] {
+		span.SetKind(ptrace.SpanKindClient)
+		span.SetParentSpanID(spans[0].SpanID())
+	}
+	spans[1].Attributes().PutStr("db.system", "postgresql")
+	spans[1].Status().SetCode(ptrace.StatusCodeError)
+	spans[2].Attributes().PutStr("server.address", "api.example.com")
+	spans[3].SetKind(ptrace.SpanKindProducer)
+	spans[3].Attributes().PutStr("messaging.system", "kafka")
+	spans[3].Attributes().PutStr("peer.service", "orders")
+	spans[4].SetKind(ptrace.SpanKindServer)
+	spans[4].SetParentSpanID(spans[2].SpanID())
+	store := NewStore(clockwork.NewRealClock())
+	store.AddSpan(&payload)
+
+	topo := store.Topology(time.Time{})
+
+	// the peer of span-0-0-2 sends spans
+	assert.Equal(t, 4, len(topo.Nodes))
+	orders, postgres := topo.Nodes[0], topo.Nodes[1]
+	assert.Equal(t, "orders", orders.Service)
+	assert.True(t, orders.External)
+	assert.Equal(t, "peer.service", orders.Attribute)
+	assert.Equal(t, `"peer.service" = "orders"`, orders.Condition())
+	assert.Equal(t, "postgresql", postgres.Service)
+	assert.True(t, postgres.External)
+	assert.Equal(t, 1, postgres.Count)
+	assert.Equal(t, 1, postgres.ErrorCount)
+	assert.Equal(t, "test-service-1", topo.Nodes[2].Service)
+	assert.False(t, topo.Nodes[2].External)
+	assert.Equal(t, `service.name = "test-service-1"`, topo.Nodes[2].Condition())
+	assert.Equal(t, "test-service-2", topo.Nodes[3].Service)
+
+	assert.Equal(t, 3, len(topo.Edges))
+	assert.Equal(t, "orders", topo.Edges[0].Target)
+	assert.Equal(t, `service.name = "test-service-1" AND "peer.service" = "orders"`, topo.Edges[0].Condition())
+	assert.Equal(t, "postgresql", topo.Edges[1].Target)
+	assert.Equal(t, 1, topo.Edges[1].Count)
+	assert.Equal(t, 1, topo.Edges[1].ErrorCount)
+	assert.Equal(t, "test-service-2", topo.Edges[2].Target)
+	assert.Equal(t, CallCondition("test-service-1", "test-service-2"), topo.Edges[2].Condition())
+}
+
+func TestTopologyExternalNodeNamedAfterService(t *testing.T) {
+	// traceid: 1
+	//  └- test-service-1: span-0-0-0 (server)
+	//    └- span-0-0-1 (client, peer.service: test-service-2, no span of the peer received)
+	//  └- test-service-2: span-1-0-0 (server)
+	payload, testdata := test.GenerateOTLPTracesPayload(t, 1, 2, []int{1, 1}, [][]int{{2}, {1}})
+	spans := testdata.Spans
+	spans[0].SetKind(ptrace.SpanKindServer)
+	spans[0].SetParentSpanID(pcommon.NewSpanIDEmpty())
+	spans[1].SetKind(ptrace.SpanKindClient)
+	spans[1].SetParentSpanID(spans[0].SpanID())
+	spans[1].Attributes().PutStr("peer.service", "test-service-2")
+	spans[2].SetKind(ptrace.SpanKindServer)
+	spans[2].SetParentSpanID(pcommon.NewSpanIDEmpty())
+	store := NewStore(clockwork.NewRealClock())
+	store.AddSpan(&payload)
+
+	topo := store.Topology(time.Time{})
+
+	// the call to the peer does not count as the request of the service
+	assert.Equal(t, 2, len(topo.Nodes))
+	svc2 := topo.Nodes[1]
+	assert.Equal(t, "test-service-2", svc2.Service)
+	assert.False(t, svc2.External)
+	assert.Equal(t, 1, svc2.Count)
+	assert.Equal(t, 1, len(topo.Edges))
+	assert.Equal(t, "test-service-1", topo.Edges[0].Source)
+	assert.Equal(t, "test-service-2", topo.Edges[0].Target)
+	assert.Equal(t, 1, topo.Edges[0].Count)
+
+	t.Run("the service is deleted", func(t *testing.T) {
+		store.tracecache.DeleteCache([]*SpanData{store.svcspans[1]})
+		topo := store.Topology(time.Time{})
+
+		assert.Equal(t, 2, len(topo.Nodes))
+		assert.True(t, topo.Nodes[1].External)
+		assert.Equal(t, 1, topo.Nodes[1].Count)
+		assert.Equal(t, `"peer.service" = "test-service-2"`, topo.Nodes[1].Condition())
+	})
+}
+
+func TestTopologyModel(t *testing.T) {
+	// test-service-1: span-0-0-0
+	//  └- test-service-2: span-1-0-0 (client, db.system: redis)
+	_, testdata := test.GenerateOTLPTracesPayload(t, 1, 2, []int{1, 1}, [][]int{{1}, {1}})
+	testdata.Spans[1].SetParentSpanID(testdata.Spans[0].SpanID())
+	testdata.Spans[1].SetKind(ptrace.SpanKindClient)
+	testdata.Spans[1].Attributes().PutStr("db.system", "redis")
+	parent := &SpanData{Span: testdata.Spans[0], ResourceSpan: testdata.RSpans[0], ScopeSpans: testdata.SSpans[0]}
+	child := &SpanData{Span: testdata.Spans[1], ResourceSpan: testdata.RSpans[1], ScopeSpans: testdata.SSpans[1]}
+	m := newTopologyModel()
+
+	// the child arrives before the parent
+	m.add(child)
+	topo := m.snapshot(time.Time{})
+	childSize := m.size
+
+	assert.Equal(t, topologyModelSize(m), m.size)
+
+	assert.Equal(t, 2, len(topo.Nodes))
+	assert.Equal(t, "redis", topo.Nodes[0].Service)
+	assert.True(t, topo.Nodes[0].External)
+	assert.Equal(t, 1, topo.Nodes[0].Depth)
+	assert.Equal(t, "test-service-2", topo.Nodes[1].Service)
+	assert.Equal(t, 0, topo.Nodes[1].Depth)
+	assert.Equal(t, 1, len(topo.Edges))
+
+	m.add(parent)
+	topo = m.snapshot(time.Time{})
+
+	// the edge to the child is counted as well as the parent
+	assert.Equal(t, topologyModelSize(m), m.size)
+	assert.Greater(t, m.size-childSize, int64(topologySpanOverheadSize+topologyContributionOverheadSize))
+
+	assert.Equal(t, 3, len(topo.Nodes))
+	assert.Equal(t, 2, topo.Nodes[0].Depth)
+	assert.Equal(t, "test-service-1", topo.Nodes[1].Service)
+	assert.Equal(t, 0, topo.Nodes[1].Depth)
+	assert.Equal(t, 1, topo.Nodes[2].Count)
+	assert.Equal(t, 1, topo.Nodes[2].Depth)
+	assert.Equal(t, 2, len(topo.Edges))
+	assert.Equal(t, "test-service-1", topo.Edges[0].Source)
+	assert.Equal(t, "test-service-2", topo.Edges[0].Target)
+
+	// the same span again does not count twice
+	m.add(child)
+
+	assert.Equal(t, 1, m.snapshot(time.Time{}).Nodes[2].Count)
+	assert.Equal(t, topologyModelSize(m), m.size)
+
+	m.remove(child)
+	topo = m.snapshot(time.Time{})
+
+	assert.Equal(t, topologyModelSize(m), m.size)
+
+	assert.Equal(t, 1, len(topo.Nodes))
+	assert.Equal(t, "test-service-1", topo.Nodes[0].Service)
+	assert.Empty(t, topo.Edges)
+
+	m.remove(parent)
+
+	assert.Empty(t, m.spans)
+	assert.Empty(t, m.children)
+	assert.Empty(t, m.contribs)
+	assert.Empty(t, m.received)
+	assert.Empty(t, m.nodes)
+	assert.Empty(t, m.peers)
+	assert.Empty(t, m.edges)
+	assert.Equal(t, int64(0), m.size)
+}
+
+// topologyModelSize counts the estimated size of the entries of the model
+func topologyModelSize(m *topologyModel) int64 {
+	size := int64(len(m.spans)*topologySpanOverheadSize + len(m.contribs)*topologyContributionOverheadSize)
+	for svc := range m.received {
+		size += topologyAggregateOverheadSize + int64(len(svc))
+	}
+	for svc, n := range m.nodes {
+		size += topologyAggregateOverheadSize + int64(len(svc)+len(n.samples)*topologySampleOverheadSize)
+	}
+	for peer, p := range m.peers {
+		size += topologyAggregateOverheadSize + int64(len(peer)+len(p.samples)*topologySampleOverheadSize)
+	}
+	for k, e := range m.edges {
+		size += topologyAggregateOverheadSize + int64(len(k.source)+len(k.target)+len(e.samples)*topologySampleOverheadSize)
+	}
+	return size
+}
+
+func TestTraceCacheTopology(t *testing.T) {
+	// traceid: 1
+	//  └- test-service-1: span-0-0-0
+	//    └- test-service-2: span-1-0-0
+	_, testdata := test.GenerateOTLPTracesPayload(t, 1, 2, []int{1, 1}, [][]int{{1}, {1}})
+	testdata.Spans[1].SetParentSpanID(testdata.Spans[0].SpanID())
+	cache := NewTraceCache()
+	sds := []*SpanData{
+		{Span: testdata.Spans[0], ResourceSpan: testdata.RSpans[0], ScopeSpans: testdata.SSpans[0]},
+		{Span: testdata.Spans[1], ResourceSpan: testdata.RSpans[1], ScopeSpans: testdata.SSpans[1]},
+	}
+	for _, sd := range sds {
+		cache.UpdateCache(sd.GetServiceName(), sd)
+	}
+
+	assert.Equal(t, 1, len(cache.getTopology(time.Time{}).Edges))
+
+	cache.DeleteCache([]*SpanData{sds[0]})
+	topo := cache.getTopology(time.Time{})
+
+	// the span of test-service-2 is a request without the caller
+	assert.Equal(t, 1, len(topo.Nodes))
+	assert.Equal(t, "test-service-2", topo.Nodes[0].Service)
+	assert.Empty(t, topo.Edges)
+
+	cache.flush()
+
+	assert.Empty(t, cache.getTopology(time.Time{}).Nodes)
+}
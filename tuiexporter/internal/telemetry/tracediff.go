@@ -0,0 +1,167 @@
+package telemetry
+
+import (
+	"sort"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// AttributeDiff is a span attribute whose values differ in two traces
+type AttributeDiff struct {
+	Key string
+	// ValueA and ValueB are empty if the attribute is only in the other span
+	ValueA string
+	ValueB string
+}
+
+// SpanDiff is a pair of the spans aligned by the service and span name in two traces
+type SpanDiff struct {
+	ServiceName string
+	SpanName    string
+	// A and B are the spans in the trace A and B, and nil if the span is only in the other trace
+	A *SpanData
+	B *SpanData
+	// DurationDelta is the duration of B minus the duration of A
+	DurationDelta time.Duration
+	StatusChanged bool
+	// ChangedAttributes are sorted by the key
+	ChangedAttributes []AttributeDiff
+	Children          []*SpanDiff
+}
+
+// TraceDiff is the comparison of two traces
+type TraceDiff struct {
+	TraceIDA  string
+	TraceIDB  string
+	DurationA time.Duration
+	DurationB time.Duration
+	Roots     []*SpanDiff
+	// SpansOnlyInA, SpansOnlyInB and SpansChanged are the numbers of the spans only in the trace A,
+	// only in the trace B and in both traces with the different status or attributes
+	SpansOnlyInA int
+	SpansOnlyInB int
+	SpansChanged int
+}
+
+// NewTraceDiff compares two traces by aligning their span trees. The spans under the aligned parents
+// (or the root spans) are aligned by the service and span name, and the spans with the same name are
+// aligned in the order of the start time.
+func NewTraceDiff(traceIDA string, spansA []*SpanData, traceIDB string, spansB []*SpanData) *TraceDiff {
+	d := &TraceDiff{
+		TraceIDA:  traceIDA,
+		TraceIDB:  traceIDB,
+		DurationA: SummarizeTrace(traceIDA, spansA).Duration,
+		DurationB: SummarizeTrace(traceIDB, spansB).Duration,
+	}
+	d.Roots = diffSpanTreeNodes(NewSpanTree(spansA), NewSpanTree(spansB))
+
+	var count func(diffs []*SpanDiff)
+	count = func(diffs []*SpanDiff) {
+		for _, sd := range diffs {
+			switch {
+			case sd.OnlyInA():
+				d.SpansOnlyInA++
+			case sd.OnlyInB():
+				d.SpansOnlyInB++
+			case sd.StatusChanged || len(sd.ChangedAttributes) > 0:
+				d.SpansChanged++
+			}
+			count(sd.Children)
+		}
+	}
+	count(d.Roots)
+
+	return d
+}
+
+// DurationDelta returns the duration of the trace B minus the duration of the trace A
+func (d *TraceDiff) DurationDelta() time.Duration {
+	return d.DurationB - d.DurationA
+}
+
+// OnlyInA returns true if the span is only in the trace A
+func (sd *SpanDiff) OnlyInA() bool {
+	return sd.B == nil
+}
+
+// OnlyInB returns true if the span is only in the trace B
+func (sd *SpanDiff) OnlyInB() bool {
+	return sd.A == nil
+}
+
+// HasDifference returns true if the span is only in one trace, or the status or attributes differ
+func (sd *SpanDiff) HasDifference() bool {
+	return sd.OnlyInA() || sd.OnlyInB() || sd.StatusChanged || len(sd.ChangedAttributes) > 0
+}
+
+func diffSpanTreeNodes(as, bs []*SpanTreeNode) []*SpanDiff {
+	diffs := []*SpanDiff{}
+	used := make([]bool, len(bs))
+	for _, a := range as {
+		var b *SpanTreeNode
+		for j, candidate := range bs {
+			if !used[j] && candidate.Span.GetServiceName() == a.Span.GetServiceName() &&
+				candidate.Span.GetSpanName() == a.Span.GetSpanName() {
+				used[j] = true
+				b = candidate
+				break
+			}
+		}
+		diffs = append(diffs, newSpanDiff(a, b))
+	}
+	for j, b := range bs {
+		if !used[j] {
+			diffs = append(diffs, newSpanDiff(nil, b))
+		}
+	}
+	return diffs
+}
+
+func newSpanDiff(a, b *SpanTreeNode) *SpanDiff {
+	sd := &SpanDiff{}
+	var childrenA, childrenB []*SpanTreeNode
+	if a != nil {
+		sd.A = a.Span
+		sd.ServiceName, sd.SpanName = a.Span.GetServiceName(), a.Span.GetSpanName()
+		childrenA = a.Children
+	}
+	if b != nil {
+		sd.B = b.Span
+		sd.ServiceName, sd.SpanName = b.Span.GetServiceName(), b.Span.GetSpanName()
+		childrenB = b.Children
+	}
+	if a != nil && b != nil {
+		sd.DurationDelta = b.Duration() - a.Duration()
+		sd.StatusChanged = a.Span.Span.Status().Code() != b.Span.Span.Status().Code()
+		sd.ChangedAttributes = diffAttributes(a.Span.Span.Attributes(), b.Span.Span.Attributes())
+	}
+	sd.Children = diffSpanTreeNodes(childrenA, childrenB)
+
+	return sd
+}
+
+func diffAttributes(a, b pcommon.Map) []AttributeDiff {
+	diffs := []AttributeDiff{}
+	a.Range(func(k string, va pcommon.Value) bool {
+		vb, ok := b.Get(k)
+		if !ok || va.AsString() != vb.AsString() {
+			diff := AttributeDiff{Key: k, ValueA: va.AsString()}
+			if ok {
+				diff.ValueB = vb.AsString()
+			}
+			diffs = append(diffs, diff)
+		}
+		return true
+	})
+	b.Range(func(k string, vb pcommon.Value) bool {
+		if _, ok := a.Get(k); !ok {
+			diffs = append(diffs, AttributeDiff{Key: k, ValueB: vb.AsString()})
+		}
+		return true
+	})
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Key < diffs[j].Key
+	})
+	return diffs
+}
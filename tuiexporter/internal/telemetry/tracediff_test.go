@@ -0,0 +1,92 @@
+package telemetry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestNewTraceDiff(t *testing.T) {
+	// A 0-100, B 0-40, C 20-90, D 10-30, E 30-60, F 50-80
+	sdsA := newTestSpanTreeSpans(t)
+	// A 0-130, B 0-40, C 20-120, E 30-60 (error), span-0-0-9 50-80
+	sdsB := newTestSpanTreeSpans(t)
+	base := sdsB[0].Span.StartTimestamp().AsTime()
+	sdsB[0].Span.SetEndTimestamp(pcommon.NewTimestampFromTime(base.Add(130 * time.Millisecond)))
+	sdsB[2].Span.SetEndTimestamp(pcommon.NewTimestampFromTime(base.Add(120 * time.Millisecond)))
+	sdsB[4].Span.Status().SetCode(ptrace.StatusCodeError)
+	sdsB[4].Span.Attributes().PutStr("error.type", "timeout")
+	sdsB[5].Span.SetName("span-0-0-9")
+	sdsB = append(sdsB[:3], sdsB[4:]...)
+
+	d := NewTraceDiff("a", sdsA, "b", sdsB)
+
+	assert.Equal(t, "a", d.TraceIDA)
+	assert.Equal(t, "b", d.TraceIDB)
+	assert.Equal(t, 100*time.Millisecond, d.DurationA)
+	assert.Equal(t, 130*time.Millisecond, d.DurationB)
+	assert.Equal(t, 30*time.Millisecond, d.DurationDelta())
+	assert.Equal(t, 2, d.SpansOnlyInA)
+	assert.Equal(t, 1, d.SpansOnlyInB)
+	assert.Equal(t, 1, d.SpansChanged)
+
+	assert.Equal(t, 1, len(d.Roots))
+	root := d.Roots[0]
+	assert.Equal(t, "span-0-0-0", root.SpanName)
+	assert.Equal(t, sdsA[0], root.A)
+	assert.Equal(t, sdsB[0], root.B)
+	assert.Equal(t, 30*time.Millisecond, root.DurationDelta)
+	assert.False(t, root.HasDifference())
+
+	// D is only in A
+	assert.Equal(t, 2, len(root.Children))
+	assert.Equal(t, 1, len(root.Children[0].Children))
+	dd := root.Children[0].Children[0]
+	assert.Equal(t, "span-0-0-3", dd.SpanName)
+	assert.True(t, dd.OnlyInA())
+	assert.True(t, dd.HasDifference())
+
+	c := root.Children[1]
+	assert.Equal(t, "span-0-0-2", c.SpanName)
+	assert.Equal(t, 30*time.Millisecond, c.DurationDelta)
+	assert.Equal(t, 3, len(c.Children))
+
+	e := c.Children[0]
+	assert.Equal(t, "span-0-0-4", e.SpanName)
+	assert.True(t, e.StatusChanged)
+	assert.Equal(t, []AttributeDiff{{Key: "error.type", ValueB: "timeout"}}, e.ChangedAttributes)
+	assert.Equal(t, time.Duration(0), e.DurationDelta)
+
+	assert.Equal(t, "span-0-0-5", c.Children[1].SpanName)
+	assert.True(t, c.Children[1].OnlyInA())
+	assert.Equal(t, "span-0-0-9", c.Children[2].SpanName)
+	assert.True(t, c.Children[2].OnlyInB())
+	assert.Nil(t, c.Children[2].A)
+
+	t.Run("same traces", func(t *testing.T) {
+		d := NewTraceDiff("a", sdsA, "a", sdsA)
+
+		assert.Equal(t, 0, d.SpansOnlyInA+d.SpansOnlyInB+d.SpansChanged)
+		assert.Equal(t, time.Duration(0), d.DurationDelta())
+	})
+}
+
+func TestDiffAttributes(t *testing.T) {
+	a := pcommon.NewMap()
+	a.PutStr("same", "v")
+	a.PutInt("changed", 1)
+	a.PutStr("only.a", "x")
+	b := pcommon.NewMap()
+	b.PutStr("same", "v")
+	b.PutInt("changed", 2)
+	b.PutBool("only.b", true)
+
+	assert.Equal(t, []AttributeDiff{
+		{Key: "changed", ValueA: "1", ValueB: "2"},
+		{Key: "only.a", ValueA: "x"},
+		{Key: "only.b", ValueB: "true"},
+	}, diffAttributes(a, b))
+}
@@ -0,0 +1,311 @@
+package telemetry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// TraceFormat is a format of the exported trace
+type TraceFormat string
+
+const (
+	TRACE_FORMAT_OTLP   TraceFormat = "otlp"
+	TRACE_FORMAT_JAEGER TraceFormat = "jaeger"
+	TRACE_FORMAT_ZIPKIN TraceFormat = "zipkin"
+)
+
+// ParseTraceFormat parses the name of the trace format. An empty string is parsed as OTLP.
+func ParseTraceFormat(s string) (TraceFormat, error) {
+	switch f := TraceFormat(strings.ToLower(s)); f {
+	case "":
+		return TRACE_FORMAT_OTLP, nil
+	case TRACE_FORMAT_OTLP, TRACE_FORMAT_JAEGER, TRACE_FORMAT_ZIPKIN:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown trace format: %s", s)
+	}
+}
+
+// TraceExportFileName returns the default file name of the exported trace
+func TraceExportFileName(traceID string, format TraceFormat) string {
+	return fmt.Sprintf("otel-tui-trace-%s-%s.json", traceID, format)
+}
+
+// MarshalTrace encodes the spans of a trace in the given format.
+//   - OTLP: OTLP JSON which can be re-imported with --from-json-file
+//   - Jaeger: JSON which can be uploaded to Jaeger UI
+//   - Zipkin: Zipkin v2 JSON
+func MarshalTrace(spans []*SpanData, format TraceFormat) ([]byte, error) {
+	switch format {
+	case TRACE_FORMAT_OTLP:
+		return (&ptrace.JSONMarshaler{}).MarshalTraces(SpansToTraces(spans))
+	case TRACE_FORMAT_JAEGER:
+		return json.Marshal(toJaegerTrace(spans))
+	case TRACE_FORMAT_ZIPKIN:
+		return json.Marshal(toZipkinSpans(spans))
+	default:
+		return nil, fmt.Errorf("unknown trace format: %s", format)
+	}
+}
+
+type jaegerTraces struct {
+	Data []jaegerTrace `json:"data"`
+}
+
+type jaegerTrace struct {
+	TraceID   string                   `json:"traceID"`
+	Spans     []jaegerSpan             `json:"spans"`
+	Processes map[string]jaegerProcess `json:"processes"`
+}
+
+type jaegerSpan struct {
+	TraceID       string            `json:"traceID"`
+	SpanID        string            `json:"spanID"`
+	OperationName string            `json:"operationName"`
+	References    []jaegerReference `json:"references"`
+	StartTime     uint64            `json:"startTime"`
+	Duration      uint64            `json:"duration"`
+	Tags          []jaegerKeyValue  `json:"tags"`
+	Logs          []jaegerLog       `json:"logs"`
+	ProcessID     string            `json:"processID"`
+}
+
+type jaegerReference struct {
+	RefType string `json:"refType"`
+	TraceID string `json:"traceID"`
+	SpanID  string `json:"spanID"`
+}
+
+type jaegerKeyValue struct {
+	Key   string `json:"key"`
+	Type  string `json:"type"`
+	Value any    `json:"value"`
+}
+
+type jaegerLog struct {
+	Timestamp uint64           `json:"timestamp"`
+	Fields    []jaegerKeyValue `json:"fields"`
+}
+
+type jaegerProcess struct {
+	ServiceName string           `json:"serviceName"`
+	Tags        []jaegerKeyValue `json:"tags"`
+}
+
+func toJaegerTrace(spans []*SpanData) jaegerTraces {
+	trace := jaegerTrace{
+		Spans:     []jaegerSpan{},
+		Processes: map[string]jaegerProcess{},
+	}
+	processIDs := map[*ptrace.ResourceSpans]string{}
+
+	for _, sd := range spans {
+		span := sd.Span
+		trace.TraceID = span.TraceID().String()
+
+		pid, ok := processIDs[sd.ResourceSpan]
+		if !ok {
+			pid = fmt.Sprintf("p%d", len(processIDs)+1)
+			processIDs[sd.ResourceSpan] = pid
+			tags := []jaegerKeyValue{}
+			sd.ResourceSpan.Resource().Attributes().Range(func(k string, v pcommon.Value) bool {
+				if k != "service.name" {
+					tags = append(tags, toJaegerKeyValue(k, v))
+				}
+				return true
+			})
+			trace.Processes[pid] = jaegerProcess{
+				ServiceName: sd.GetServiceName(),
+				Tags:        tags,
+			}
+		}
+
+		refs := []jaegerReference{}
+		if !span.ParentSpanID().IsEmpty() {
+			refs = append(refs, jaegerReference{
+				RefType: "CHILD_OF",
+				TraceID: span.TraceID().String(),
+				SpanID:  span.ParentSpanID().String(),
+			})
+		}
+		for i := 0; i < span.Links().Len(); i++ {
+			link := span.Links().At(i)
+			if link.SpanID().IsEmpty() {
+				continue
+			}
+			refs = append(refs, jaegerReference{
+				RefType: "FOLLOWS_FROM",
+				TraceID: link.TraceID().String(),
+				SpanID:  link.SpanID().String(),
+			})
+		}
+
+		tags := []jaegerKeyValue{}
+		span.Attributes().Range(func(k string, v pcommon.Value) bool {
+			tags = append(tags, toJaegerKeyValue(k, v))
+			return true
+		})
+		if kind := spanKindName(span.Kind()); kind != "" {
+			tags = append(tags, jaegerKeyValue{Key: "span.kind", Type: "string", Value: strings.ToLower(kind)})
+		}
+		if name := sd.ScopeSpans.Scope().Name(); name != "" {
+			tags = append(tags, jaegerKeyValue{Key: "otel.scope.name", Type: "string", Value: name})
+		}
+		if span.Status().Code() != ptrace.StatusCodeUnset {
+			tags = append(tags, jaegerKeyValue{Key: "otel.status_code", Type: "string", Value: strings.ToUpper(span.Status().Code().String())})
+		}
+		if span.Status().Code() == ptrace.StatusCodeError {
+			tags = append(tags, jaegerKeyValue{Key: "error", Type: "bool", Value: true})
+			if msg := span.Status().Message(); msg != "" {
+				tags = append(tags, jaegerKeyValue{Key: "otel.status_description", Type: "string", Value: msg})
+			}
+		}
+
+		logs := []jaegerLog{}
+		for i := 0; i < span.Events().Len(); i++ {
+			event := span.Events().At(i)
+			fields := []jaegerKeyValue{{Key: "event", Type: "string", Value: event.Name()}}
+			event.Attributes().Range(func(k string, v pcommon.Value) bool {
+				fields = append(fields, toJaegerKeyValue(k, v))
+				return true
+			})
+			logs = append(logs, jaegerLog{
+				Timestamp: toMicroseconds(event.Timestamp()),
+				Fields:    fields,
+			})
+		}
+
+		trace.Spans = append(trace.Spans, jaegerSpan{
+			TraceID:       span.TraceID().String(),
+			SpanID:        span.SpanID().String(),
+			OperationName: span.Name(),
+			References:    refs,
+			StartTime:     toMicroseconds(span.StartTimestamp()),
+			Duration:      toMicroseconds(span.EndTimestamp()) - toMicroseconds(span.StartTimestamp()),
+			Tags:          tags,
+			Logs:          logs,
+			ProcessID:     pid,
+		})
+	}
+
+	return jaegerTraces{Data: []jaegerTrace{trace}}
+}
+
+func toJaegerKeyValue(k string, v pcommon.Value) jaegerKeyValue {
+	switch v.Type() {
+	case pcommon.ValueTypeBool:
+		return jaegerKeyValue{Key: k, Type: "bool", Value: v.Bool()}
+	case pcommon.ValueTypeInt:
+		return jaegerKeyValue{Key: k, Type: "int64", Value: v.Int()}
+	case pcommon.ValueTypeDouble:
+		return jaegerKeyValue{Key: k, Type: "float64", Value: v.Double()}
+	case pcommon.ValueTypeBytes:
+		return jaegerKeyValue{Key: k, Type: "binary", Value: base64.StdEncoding.EncodeToString(v.Bytes().AsRaw())}
+	default:
+		return jaegerKeyValue{Key: k, Type: "string", Value: v.AsString()}
+	}
+}
+
+type zipkinSpan struct {
+	TraceID       string             `json:"traceId"`
+	ID            string             `json:"id"`
+	ParentID      string             `json:"parentId,omitempty"`
+	Name          string             `json:"name"`
+	Kind          string             `json:"kind,omitempty"`
+	Timestamp     uint64             `json:"timestamp"`
+	Duration      uint64             `json:"duration"`
+	LocalEndpoint zipkinEndpoint     `json:"localEndpoint"`
+	Annotations   []zipkinAnnotation `json:"annotations,omitempty"`
+	Tags          map[string]string  `json:"tags,omitempty"`
+}
+
+type zipkinEndpoint struct {
+	ServiceName string `json:"serviceName"`
+}
+
+type zipkinAnnotation struct {
+	Timestamp uint64 `json:"timestamp"`
+	Value     string `json:"value"`
+}
+
+func toZipkinSpans(spans []*SpanData) []zipkinSpan {
+	result := make([]zipkinSpan, 0, len(spans))
+
+	for _, sd := range spans {
+		span := sd.Span
+
+		tags := map[string]string{}
+		sd.ResourceSpan.Resource().Attributes().Range(func(k string, v pcommon.Value) bool {
+			if k != "service.name" {
+				tags[k] = v.AsString()
+			}
+			return true
+		})
+		span.Attributes().Range(func(k string, v pcommon.Value) bool {
+			tags[k] = v.AsString()
+			return true
+		})
+		if name := sd.ScopeSpans.Scope().Name(); name != "" {
+			tags["otel.scope.name"] = name
+		}
+		if span.Status().Code() != ptrace.StatusCodeUnset {
+			tags["otel.status_code"] = strings.ToUpper(span.Status().Code().String())
+		}
+		if span.Status().Code() == ptrace.StatusCodeError {
+			tags["error"] = span.Status().Message()
+		}
+
+		annotations := []zipkinAnnotation{}
+		for i := 0; i < span.Events().Len(); i++ {
+			event := span.Events().At(i)
+			annotations = append(annotations, zipkinAnnotation{
+				Timestamp: toMicroseconds(event.Timestamp()),
+				Value:     event.Name(),
+			})
+		}
+
+		zs := zipkinSpan{
+			TraceID:       span.TraceID().String(),
+			ID:            span.SpanID().String(),
+			Name:          span.Name(),
+			Kind:          spanKindName(span.Kind()),
+			Timestamp:     toMicroseconds(span.StartTimestamp()),
+			Duration:      toMicroseconds(span.EndTimestamp()) - toMicroseconds(span.StartTimestamp()),
+			LocalEndpoint: zipkinEndpoint{ServiceName: sd.GetServiceName()},
+			Annotations:   annotations,
+			Tags:          tags,
+		}
+		if !span.ParentSpanID().IsEmpty() {
+			zs.ParentID = span.ParentSpanID().String()
+		}
+		result = append(result, zs)
+	}
+
+	return result
+}
+
+// spanKindName returns the name of the span kind in upper case (e.g. SERVER).
+// It returns an empty string for internal and unspecified spans.
+func spanKindName(kind ptrace.SpanKind) string {
+	switch kind {
+	case ptrace.SpanKindServer:
+		return "SERVER"
+	case ptrace.SpanKindClient:
+		return "CLIENT"
+	case ptrace.SpanKindProducer:
+		return "PRODUCER"
+	case ptrace.SpanKindConsumer:
+		return "CONSUMER"
+	default:
+		return ""
+	}
+}
+
+func toMicroseconds(ts pcommon.Timestamp) uint64 {
+	return uint64(ts) / 1000
+}
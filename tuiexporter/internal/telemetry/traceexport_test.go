@@ -0,0 +1,114 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/test"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestParseTraceFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    TraceFormat
+		wantErr bool
+	}{
+		{name: "empty", input: "", want: TRACE_FORMAT_OTLP},
+		{name: "otlp", input: "otlp", want: TRACE_FORMAT_OTLP},
+		{name: "jaeger", input: "Jaeger", want: TRACE_FORMAT_JAEGER},
+		{name: "zipkin", input: "zipkin", want: TRACE_FORMAT_ZIPKIN},
+		{name: "unknown", input: "xml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTraceFormat(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMarshalTrace(t *testing.T) {
+	store := NewStore(clockwork.NewRealClock())
+	payload, _ := test.GenerateOTLPTracesPayload(t, 1, 2, []int{2, 1}, [][]int{{2, 1}, {1}})
+	store.AddSpan(&payload)
+	spans, ok := store.tracecache.GetSpansByTraceID("01000000000000000000000000000000")
+	require.True(t, ok)
+
+	t.Run("otlp", func(t *testing.T) {
+		b, err := MarshalTrace(spans, TRACE_FORMAT_OTLP)
+		require.NoError(t, err)
+
+		got, err := (&ptrace.JSONUnmarshaler{}).UnmarshalTraces(b)
+		require.NoError(t, err)
+		assert.Equal(t, 4, got.SpanCount())
+	})
+
+	t.Run("jaeger", func(t *testing.T) {
+		b, err := MarshalTrace(spans, TRACE_FORMAT_JAEGER)
+		require.NoError(t, err)
+
+		var got jaegerTraces
+		require.NoError(t, json.Unmarshal(b, &got))
+		require.Equal(t, 1, len(got.Data))
+		trace := got.Data[0]
+		assert.Equal(t, "01000000000000000000000000000000", trace.TraceID)
+		assert.Equal(t, 4, len(trace.Spans))
+		assert.Equal(t, 2, len(trace.Processes))
+
+		span := trace.Spans[0]
+		assert.Equal(t, "span-0-0-0", span.OperationName)
+		assert.Equal(t, uint64(200000), span.Duration)
+		assert.Equal(t, []jaegerReference{
+			{RefType: "CHILD_OF", TraceID: trace.TraceID, SpanID: "2122232425262728"},
+		}, span.References)
+		assert.Contains(t, span.Tags, jaegerKeyValue{Key: "otel.status_code", Type: "string", Value: "OK"})
+		assert.Equal(t, 1, len(span.Logs))
+		assert.Equal(t, "test-service-1", trace.Processes[span.ProcessID].ServiceName)
+	})
+
+	t.Run("zipkin", func(t *testing.T) {
+		b, err := MarshalTrace(spans, TRACE_FORMAT_ZIPKIN)
+		require.NoError(t, err)
+
+		var got []zipkinSpan
+		require.NoError(t, json.Unmarshal(b, &got))
+		require.Equal(t, 4, len(got))
+
+		span := got[0]
+		assert.Equal(t, "01000000000000000000000000000000", span.TraceID)
+		assert.Equal(t, "2122232425262728", span.ParentID)
+		assert.Equal(t, "span-0-0-0", span.Name)
+		assert.Equal(t, "", span.Kind)
+		assert.Equal(t, uint64(200000), span.Duration)
+		assert.Equal(t, "test-service-1", span.LocalEndpoint.ServiceName)
+		assert.Equal(t, "0", span.Tags["span index"])
+		assert.Equal(t, "resource attribute value", span.Tags["resource attribute"])
+		assert.Equal(t, []zipkinAnnotation{{Timestamp: span.Annotations[0].Timestamp, Value: "span event"}}, span.Annotations)
+	})
+}
+
+func TestStoreExportTrace(t *testing.T) {
+	store := NewStore(clockwork.NewRealClock())
+	payload, _ := test.GenerateOTLPTracesPayload(t, 1, 1, []int{1}, [][]int{{2}})
+	store.AddSpan(&payload)
+
+	b, ok, err := store.ExportTrace("01000000000000000000000000000000", TRACE_FORMAT_ZIPKIN)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.NotEmpty(t, b)
+
+	_, ok, err = store.ExportTrace("unknown", TRACE_FORMAT_ZIPKIN)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
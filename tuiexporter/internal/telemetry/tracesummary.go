@@ -0,0 +1,188 @@
+package telemetry
+
+import (
+	"sort"
+	"time"
+
+	"github.com/icza/gox/timex"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/datetime"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// TraceSummary is a summary of all spans in a trace
+type TraceSummary struct {
+	TraceID string
+	// RootSpanName and RootServiceName are empty if the root span is not received yet
+	RootSpanName    string
+	RootServiceName string
+	StartTime       time.Time
+	EndTime         time.Time
+	Duration        time.Duration
+	SpanCount       int
+	Services        []string
+	ErrorCount      int
+	// Incomplete is true if the root span or the parent span of any span is not received yet
+	Incomplete bool
+	// ReceivedAt and Seq are of the span received last, so Seq increases when a span is added
+	// to the trace
+	ReceivedAt time.Time
+	Seq        uint64
+}
+
+// GetDurationText returns the duration of the trace
+func (ts *TraceSummary) GetDurationText() string {
+	return timex.Round(ts.Duration, 2).String()
+}
+
+// GetStartTimeText returns the start time of the trace
+func (ts *TraceSummary) GetStartTimeText(full bool) string {
+	if full {
+		return datetime.GetFullTime(ts.StartTime.Local())
+	}
+	return datetime.GetSimpleTime(ts.StartTime.Local())
+}
+
+// SummarizeTrace summarizes the spans of a trace
+func SummarizeTrace(traceID string, spans []*SpanData) TraceSummary {
+	summary := TraceSummary{
+		TraceID:   traceID,
+		SpanCount: len(spans),
+		Services:  []string{},
+	}
+	if len(spans) == 0 {
+		summary.Incomplete = true
+		return summary
+	}
+
+	spanIDs := make(map[string]bool, len(spans))
+	for _, sd := range spans {
+		spanIDs[sd.Span.SpanID().String()] = true
+	}
+
+	services := map[string]bool{}
+	var root *SpanData
+	for _, sd := range spans {
+		start := sd.Span.StartTimestamp().AsTime()
+		end := sd.Span.EndTimestamp().AsTime()
+		if summary.StartTime.IsZero() || start.Before(summary.StartTime) {
+			summary.StartTime = start
+		}
+		if end.After(summary.EndTime) {
+			summary.EndTime = end
+		}
+		if sd.ReceivedAt.After(summary.ReceivedAt) {
+			summary.ReceivedAt = sd.ReceivedAt
+		}
+		summary.Seq = max(summary.Seq, sd.Seq)
+
+		if sname := sd.GetServiceName(); !services[sname] {
+			services[sname] = true
+			summary.Services = append(summary.Services, sname)
+		}
+		if sd.Span.Status().Code() == ptrace.StatusCodeError {
+			summary.ErrorCount++
+		}
+
+		if sd.IsRoot() {
+			if root == nil || start.Before(root.Span.StartTimestamp().AsTime()) {
+				root = sd
+			}
+		} else if !spanIDs[sd.Span.ParentSpanID().String()] {
+			summary.Incomplete = true
+		}
+	}
+	sort.Strings(summary.Services)
+	summary.Duration = summary.EndTime.Sub(summary.StartTime)
+
+	if root == nil {
+		summary.Incomplete = true
+	} else {
+		summary.RootSpanName = root.GetSpanName()
+		summary.RootServiceName = root.GetServiceName()
+	}
+
+	return summary
+}
+
+// SummarizeTraces returns the summaries of the traces of the service spans in the order of
+// their first appearance
+func (s *Store) SummarizeTraces(svcspans []*SpanData) []TraceSummary {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	return s.summarizeTraces(svcspans)
+}
+
+// GetFilteredTraceSummaries returns the summaries of the filtered traces in the store
+func (s *Store) GetFilteredTraceSummaries() *[]TraceSummary {
+	return &s.traceSummariesFiltered
+}
+
+// GetTraceIDBySummaryIdx returns the trace of the filtered trace summaries at the given index
+func (s *Store) GetTraceIDBySummaryIdx(idx int) string {
+	if idx >= 0 && idx < len(s.traceSummariesFiltered) {
+		return s.traceSummariesFiltered[idx].TraceID
+	}
+	return ""
+}
+
+// GetRootServiceSpansBySummaryIdx returns the spans of the root service of the trace at the given
+// index of the filtered trace summaries. All spans of the trace are returned if the root span is not
+// received yet.
+func (s *Store) GetRootServiceSpansBySummaryIdx(idx int) []*SpanData {
+	if idx < 0 || idx >= len(s.traceSummariesFiltered) {
+		return []*SpanData{}
+	}
+	summary := s.traceSummariesFiltered[idx]
+	if summary.RootServiceName != "" {
+		if spans, ok := s.tracecache.GetSpansByTraceIDAndSvc(summary.TraceID, summary.RootServiceName); ok {
+			return spans
+		}
+	}
+	spans, _ := s.tracecache.GetSpansByTraceID(summary.TraceID)
+
+	return spans
+}
+
+func (s *Store) summarizeTraces(svcspans []*SpanData) []TraceSummary {
+	summaries := []TraceSummary{}
+	seen := map[string]bool{}
+	for _, ss := range svcspans {
+		traceID := ss.Span.TraceID().String()
+		if seen[traceID] {
+			continue
+		}
+		seen[traceID] = true
+
+		summary, ok := s.traceSummaries[traceID]
+		if !ok {
+			spans, _ := s.tracecache.GetSpansByTraceID(traceID)
+			summary = SummarizeTrace(traceID, spans)
+			s.traceSummaries[traceID] = summary
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// updateTraceSummaries updates the summaries of the filtered traces in the same order as
+// the filtered service spans, or sorted by the duration of the trace
+func (s *Store) updateTraceSummaries() {
+	summaries := s.summarizeTraces(s.svcspansFiltered)
+	switch s.sortTrace {
+	case SORT_TYPE_LATENCY_DESC:
+		sort.SliceStable(summaries, func(i, j int) bool {
+			return summaries[i].Duration > summaries[j].Duration
+		})
+	case SORT_TYPE_LATENCY_ASC:
+		sort.SliceStable(summaries, func(i, j int) bool {
+			return summaries[i].Duration < summaries[j].Duration
+		})
+	}
+	s.traceSummariesFiltered = summaries
+}
+
+// invalidateTraceSummary drops the cached summary of the trace, which is summarized again when needed
+func (s *Store) invalidateTraceSummary(traceID string) {
+	delete(s.traceSummaries, traceID)
+}
@@ -0,0 +1,121 @@
+package telemetry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/test"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestSummarizeTrace(t *testing.T) {
+	// traceid: 1
+	//  └- resource: test-service-1
+	//  | └- scope: test-scope-1-1
+	//  |   └- span: span-0-0-0 (root, 0s - 3s)
+	//  |   └- span: span-0-0-1 (1s - 2s, error)
+	//  └- resource: test-service-2
+	//    └- scope: test-scope-2-1
+	//      └- span: span-1-0-0 (2s - 4s, error)
+	_, testdata := test.GenerateOTLPTracesPayload(t, 1, 2, []int{1, 1}, [][]int{{2}, {1}})
+	base := time.Date(2024, 3, 30, 12, 30, 15, 0, time.UTC)
+	setTime := func(span *ptrace.Span, start, end int) {
+		span.SetStartTimestamp(pcommon.NewTimestampFromTime(base.Add(time.Duration(start) * time.Second)))
+		span.SetEndTimestamp(pcommon.NewTimestampFromTime(base.Add(time.Duration(end) * time.Second)))
+	}
+	root := testdata.Spans[0]
+	root.SetParentSpanID(pcommon.NewSpanIDEmpty())
+	setTime(root, 0, 3)
+	testdata.Spans[1].SetParentSpanID(root.SpanID())
+	testdata.Spans[1].Status().SetCode(ptrace.StatusCodeError)
+	setTime(testdata.Spans[1], 1, 2)
+	testdata.Spans[2].SetParentSpanID(testdata.Spans[1].SpanID())
+	testdata.Spans[2].Status().SetCode(ptrace.StatusCodeError)
+	setTime(testdata.Spans[2], 2, 4)
+
+	spans := []*SpanData{
+		{Span: testdata.Spans[0], ResourceSpan: testdata.RSpans[0], ReceivedAt: base, Seq: 1},
+		{Span: testdata.Spans[1], ResourceSpan: testdata.RSpans[0], ReceivedAt: base, Seq: 2},
+		{Span: testdata.Spans[2], ResourceSpan: testdata.RSpans[1], ReceivedAt: base.Add(time.Second), Seq: 3},
+	}
+	traceID := root.TraceID().String()
+
+	t.Run("complete", func(t *testing.T) {
+		got := SummarizeTrace(traceID, spans)
+		assert.Equal(t, TraceSummary{
+			TraceID:         traceID,
+			RootSpanName:    "span-0-0-0",
+			RootServiceName: "test-service-1",
+			StartTime:       base,
+			EndTime:         base.Add(4 * time.Second),
+			Duration:        4 * time.Second,
+			SpanCount:       3,
+			Services:        []string{"test-service-1", "test-service-2"},
+			ErrorCount:      2,
+			ReceivedAt:      base.Add(time.Second),
+			Seq:             3,
+		}, got)
+		assert.Equal(t, "4s", got.GetDurationText())
+	})
+
+	t.Run("root not received", func(t *testing.T) {
+		got := SummarizeTrace(traceID, spans[1:])
+		assert.True(t, got.Incomplete)
+		assert.Equal(t, "", got.RootSpanName)
+		assert.Equal(t, 2, got.SpanCount)
+	})
+
+	t.Run("parent not received", func(t *testing.T) {
+		got := SummarizeTrace(traceID, []*SpanData{spans[0], spans[2]})
+		assert.True(t, got.Incomplete)
+		assert.Equal(t, "span-0-0-0", got.RootSpanName)
+	})
+
+	t.Run("no spans", func(t *testing.T) {
+		got := SummarizeTrace(traceID, nil)
+		assert.True(t, got.Incomplete)
+		assert.Equal(t, 0, got.SpanCount)
+	})
+}
+
+func TestStoreTraceSummaries(t *testing.T) {
+	store := NewStore(clockwork.NewRealClock())
+
+	// trace 1 has 2 services and trace 2 has 1 service
+	payload1, _ := test.GenerateOTLPTracesPayload(t, 1, 2, []int{1, 1}, [][]int{{2}, {1}})
+	store.AddSpan(&payload1)
+	payload2, _ := test.GenerateOTLPTracesPayload(t, 2, 1, []int{1}, [][]int{{1}})
+	store.AddSpan(&payload2)
+
+	summaries := store.GetFilteredTraceSummaries()
+	assert.Equal(t, 2, len(*summaries))
+	assert.Equal(t, 3, (*summaries)[0].SpanCount)
+	assert.Equal(t, []string{"test-service-1", "test-service-2"}, (*summaries)[0].Services)
+	assert.Equal(t, 1, (*summaries)[1].SpanCount)
+	assert.Equal(t, (*summaries)[1].TraceID, store.GetTraceIDBySummaryIdx(1))
+	assert.Equal(t, "", store.GetTraceIDBySummaryIdx(2))
+	assert.Equal(t, 3, len(store.GetRootServiceSpansBySummaryIdx(0))) // no root span
+	assert.Empty(t, store.GetRootServiceSpansBySummaryIdx(2))
+
+	// the summary is updated when a span is added to the trace
+	payload3, _ := test.GenerateOTLPTracesPayload(t, 2, 2, []int{1, 1}, [][]int{{0}, {1}})
+	store.AddSpan(&payload3)
+	summaries = store.GetFilteredTraceSummaries()
+	assert.Equal(t, 2, len(*summaries))
+	assert.Equal(t, 2, (*summaries)[1].SpanCount)
+
+	// filtered
+	assert.NoError(t, store.ApplyFilterTraces("test-service-2", MatchMode{}, SORT_TYPE_NONE))
+	summaries = store.GetFilteredTraceSummaries()
+	assert.Equal(t, 2, len(*summaries))
+	assert.NoError(t, store.ApplyFilterTraces(`"span index" = 1`, MatchMode{}, SORT_TYPE_NONE))
+	summaries = store.GetFilteredTraceSummaries()
+	assert.Equal(t, 1, len(*summaries))
+	assert.Equal(t, store.SummarizeTraces(*store.GetSvcSpans())[0], (*summaries)[0])
+
+	store.Flush()
+	assert.Empty(t, *store.GetFilteredTraceSummaries())
+}
@@ -0,0 +1,160 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/telemetry"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/layout"
+)
+
+// KeyMaps returns the key maps to export the filtered data of the signal shown in the page
+// or the whole store to an OTLP JSON lines file in the current directory. The path of the file
+// or the error is shown in the command list.
+func KeyMaps(commands *tview.TextView, store *telemetry.Store, signal telemetry.Signal) layout.KeyMaps {
+	return layout.KeyMaps{
+		{
+			Key:         tcell.NewEventKey(tcell.KeyRune, 'e', tcell.ModNone),
+			Description: "Export view",
+			Handler: func(_ *tcell.EventKey) *tcell.EventKey {
+				exportAndNotify(commands, store, []telemetry.Signal{signal}, true)
+				return nil
+			},
+		},
+		{
+			Key:         tcell.NewEventKey(tcell.KeyRune, 'E', tcell.ModNone),
+			Description: "Export all",
+			Handler: func(_ *tcell.EventKey) *tcell.EventKey {
+				exportAndNotify(commands, store, telemetry.AllSignals, false)
+				return nil
+			},
+		},
+	}
+}
+
+// maxExportFileSuffix is the maximum suffix added to the name of the export file when the files
+// of the same second already exist
+const maxExportFileSuffix = 100
+
+// ToFile writes the data in the store to a new OTLP JSON lines file in the directory,
+// which can be re-imported with --from-json-file. It returns the path of the file.
+// The name of the file has a -N suffix when the data is already exported in the same second.
+// The file is removed if the data cannot be written.
+func ToFile(store *telemetry.Store, dir string, signals []telemetry.Signal, filtered bool, now time.Time) (string, error) {
+	path, f, err := createExportFile(dir, now)
+	if err != nil {
+		return "", err
+	}
+	if err := store.ExportJSONLines(f, signals, filtered); err != nil {
+		_ = f.Close()
+		_ = os.Remove(path)
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(path)
+		return "", err
+	}
+
+	return path, nil
+}
+
+// createExportFile creates a new export file in the directory without overwriting the existing one
+func createExportFile(dir string, now time.Time) (string, *os.File, error) {
+	name := fmt.Sprintf("otel-tui-export-%s", now.Format("20060102-150405"))
+	for i := 0; i <= maxExportFileSuffix; i++ {
+		path := filepath.Join(dir, name+".jsonl")
+		if i > 0 {
+			path = filepath.Join(dir, fmt.Sprintf("%s-%d.jsonl", name, i))
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		return path, f, nil
+	}
+	return "", nil, fmt.Errorf("too many export files of %s in %s", now.Format("20060102-150405"), dir)
+}
+
+// TraceKeyMaps returns the key maps to export the trace shown in the timeline page
+// to a file in the current directory as OTLP JSON (e), Jaeger JSON (J) or Zipkin JSON (Z).
+// The path of the file or the error is shown in the command list.
+func TraceKeyMaps(commands *tview.TextView, store *telemetry.Store, traceID func() string) layout.KeyMaps {
+	keyMap := func(key rune, description string, format telemetry.TraceFormat) *layout.KeyMap {
+		return &layout.KeyMap{
+			Key:         tcell.NewEventKey(tcell.KeyRune, key, tcell.ModNone),
+			Description: description,
+			Handler: func(_ *tcell.EventKey) *tcell.EventKey {
+				exportTraceAndNotify(commands, store, traceID(), format)
+				return nil
+			},
+		}
+	}
+	return layout.KeyMaps{
+		keyMap('e', "Export OTLP", telemetry.TRACE_FORMAT_OTLP),
+		keyMap('J', "Export Jaeger", telemetry.TRACE_FORMAT_JAEGER),
+		keyMap('Z', "Export Zipkin", telemetry.TRACE_FORMAT_ZIPKIN),
+	}
+}
+
+// TraceToFile writes the spans of the trace to a new file in the directory in the given format.
+// It returns the path of the file.
+func TraceToFile(store *telemetry.Store, dir, traceID string, format telemetry.TraceFormat) (string, error) {
+	b, ok, err := store.ExportTrace(traceID, format)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("trace %s is not found", traceID)
+	}
+
+	path := filepath.Join(dir, telemetry.TraceExportFileName(traceID, format))
+	if err := os.WriteFile(path, b, 0o600); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+func exportAndNotify(commands *tview.TextView, store *telemetry.Store, signals []telemetry.Signal, filtered bool) {
+	path, err := ToFile(store, ".", signals, filtered, time.Now())
+	if err != nil {
+		notify(commands, "red", "Failed to export data: %v", err)
+		return
+	}
+	notify(commands, "green", "Data has been exported to %s", absPath(path))
+}
+
+func exportTraceAndNotify(commands *tview.TextView, store *telemetry.Store, traceID string, format telemetry.TraceFormat) {
+	path, err := TraceToFile(store, ".", traceID, format)
+	if err != nil {
+		notify(commands, "red", "Failed to export trace: %v", err)
+		return
+	}
+	notify(commands, "green", "Trace has been exported to %s", absPath(path))
+}
+
+// notify logs the message and shows it in the command list until the focus moves
+func notify(commands *tview.TextView, color, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	log.Println(msg)
+	if commands != nil {
+		commands.SetText(fmt.Sprintf(" [%s]%s[white]", color, tview.Escape(msg)))
+	}
+}
+
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
@@ -0,0 +1,92 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/telemetry"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/test"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestToFile(t *testing.T) {
+	store := telemetry.NewStore(clockwork.NewRealClock())
+	tp, _ := test.GenerateOTLPTracesPayload(t, 1, 2, []int{2, 1}, [][]int{{2, 1}, {1}})
+	lp, _ := test.GenerateOTLPLogsPayload(t, 1, 2, []int{2, 1}, [][]int{{2, 1}, {1}})
+	store.AddSpan(&tp)
+	store.AddLog(&lp)
+	store.ApplyFilterTraces("test-service-1", telemetry.MatchMode{}, telemetry.SORT_TYPE_NONE)
+
+	dir := t.TempDir()
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("filtered", func(t *testing.T) {
+		path, err := ToFile(store, dir, []telemetry.Signal{telemetry.SignalTraces}, true, now)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "otel-tui-export-20240102-030405.jsonl"), path)
+
+		b, err := os.ReadFile(path)
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+		assert.Equal(t, 1, len(lines))
+
+		traces, err := (&ptrace.JSONUnmarshaler{}).UnmarshalTraces([]byte(lines[0]))
+		require.NoError(t, err)
+		assert.Equal(t, 3, traces.SpanCount())
+	})
+
+	t.Run("all", func(t *testing.T) {
+		path, err := ToFile(store, dir, telemetry.AllSignals, false, now.Add(time.Second))
+		require.NoError(t, err)
+
+		b, err := os.ReadFile(path)
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+		// traces and logs (no metrics)
+		assert.Equal(t, 2, len(lines))
+	})
+
+	t.Run("existing file is not overwritten", func(t *testing.T) {
+		before, err := os.ReadFile(filepath.Join(dir, "otel-tui-export-20240102-030405.jsonl"))
+		require.NoError(t, err)
+
+		path, err := ToFile(store, dir, telemetry.AllSignals, false, now)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "otel-tui-export-20240102-030405-1.jsonl"), path)
+		path, err = ToFile(store, dir, telemetry.AllSignals, false, now)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "otel-tui-export-20240102-030405-2.jsonl"), path)
+
+		after, err := os.ReadFile(filepath.Join(dir, "otel-tui-export-20240102-030405.jsonl"))
+		require.NoError(t, err)
+		assert.Equal(t, before, after)
+	})
+}
+
+func TestTraceToFile(t *testing.T) {
+	store := telemetry.NewStore(clockwork.NewRealClock())
+	tp, _ := test.GenerateOTLPTracesPayload(t, 1, 1, []int{1}, [][]int{{2}})
+	store.AddSpan(&tp)
+
+	dir := t.TempDir()
+	traceID := "01000000000000000000000000000000"
+
+	path, err := TraceToFile(store, dir, traceID, telemetry.TRACE_FORMAT_OTLP)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "otel-tui-trace-01000000000000000000000000000000-otlp.json"), path)
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	traces, err := (&ptrace.JSONUnmarshaler{}).UnmarshalTraces(b)
+	require.NoError(t, err)
+	assert.Equal(t, 2, traces.SpanCount())
+
+	_, err = TraceToFile(store, dir, "unknown", telemetry.TRACE_FORMAT_OTLP)
+	assert.Error(t, err)
+}
@@ -1,20 +1,31 @@
 package filter
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/telemetry"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/layout"
 )
 
-type onInputEnterFn func(inputConfirmed string, sortType telemetry.SortType)
+// onInputEnterFn applies the confirmed input. The returned error (e.g. a malformed query)
+// is shown in the filter and the input is not confirmed.
+type onInputEnterFn func(inputConfirmed string, mode telemetry.MatchMode, sortType telemetry.SortType) error
 type onInputDoneFn func()
 type onInputChangedFn func(text string)
-type onSortTypeChangedFn func(inputConfirmed string, sortType telemetry.SortType)
+
+// onSortTypeChangedFn applies the confirmed input with the new sort type.
+// The returned error is shown in the filter.
+type onSortTypeChangedFn func(inputConfirmed string, mode telemetry.MatchMode, sortType telemetry.SortType) error
 
 type Filter struct {
 	view                  *tview.InputField
+	label                 string
+	err                   error
 	sortType              telemetry.SortType
+	mode, modeConfirmed   telemetry.MatchMode
 	input, inputConfirmed string
 	onInputEnterFn        onInputEnterFn
 	onInputDoneFn         onInputDoneFn
@@ -36,6 +47,7 @@ func NewFilter(
 
 	filter := &Filter{
 		view:                field,
+		label:               label,
 		sortType:            telemetry.SORT_TYPE_NONE,
 		onInputEnterFn:      onInputEnterFn,
 		onInputDoneFn:       onInputDoneFn,
@@ -64,12 +76,20 @@ func (f *Filter) onDoneFunc() func(key tcell.Key) {
 	return func(key tcell.Key) {
 		switch key {
 		case tcell.KeyEnter:
-			f.inputConfirmed = f.input
 			if f.onInputEnterFn != nil {
-				f.onInputEnterFn(f.inputConfirmed, f.sortType)
+				if err := f.onInputEnterFn(f.input, f.mode, f.sortType); err != nil {
+					// keep the focus so that the input can be fixed
+					f.setError(err)
+					return
+				}
 			}
+			f.inputConfirmed = f.input
+			f.modeConfirmed = f.mode
+			f.setError(nil)
 		case tcell.KeyEsc:
 			f.view.SetText(f.inputConfirmed)
+			f.mode = f.modeConfirmed
+			f.setError(nil)
 		}
 		if f.onInputDoneFn != nil {
 			f.onInputDoneFn()
@@ -77,6 +97,30 @@ func (f *Filter) onDoneFunc() func(key tcell.Key) {
 	}
 }
 
+func (f *Filter) setError(err error) {
+	f.err = err
+	if err == nil {
+		f.view.SetLabel(f.labelText()).SetLabelColor(tview.Styles.SecondaryTextColor)
+		return
+	}
+	f.view.SetLabel(fmt.Sprintf("Invalid filter (%s): ", err)).SetLabelColor(tcell.ColorRed)
+}
+
+// labelText returns the label with the current match mode (e.g. "Filter [regex, ignore case]: ")
+func (f *Filter) labelText() string {
+	modes := []string{}
+	if f.mode.Regex {
+		modes = append(modes, "regex")
+	}
+	if f.mode.IgnoreCase {
+		modes = append(modes, "ignore case")
+	}
+	if len(modes) == 0 {
+		return f.label
+	}
+	return fmt.Sprintf("%s [%s]: ", strings.TrimSuffix(f.label, ": "), strings.Join(modes, ", "))
+}
+
 func (f *Filter) registerCommands(commands *tview.TextView) {
 	layout.RegisterCommandList(commands, f.view, nil, layout.KeyMaps{
 		{
@@ -87,9 +131,65 @@ func (f *Filter) registerCommands(commands *tview.TextView) {
 			Key:         tcell.NewEventKey(tcell.KeyEnter, ' ', tcell.ModNone),
 			Description: "Confirm",
 		},
+		{
+			Key:         tcell.NewEventKey(tcell.KeyCtrlR, ' ', tcell.ModNone),
+			Description: "Toggle regex",
+			Handler: func(_ *tcell.EventKey) *tcell.EventKey {
+				f.ToggleRegex()
+				return nil
+			},
+		},
+		{
+			Key:         tcell.NewEventKey(tcell.KeyCtrlT, ' ', tcell.ModNone),
+			Description: "Toggle ignore case",
+			Handler: func(_ *tcell.EventKey) *tcell.EventKey {
+				f.ToggleIgnoreCase()
+				return nil
+			},
+		},
 	})
 }
 
+// ToggleRegex toggles the regex mode which is applied when the input is confirmed
+func (f *Filter) ToggleRegex() {
+	f.mode.Regex = !f.mode.Regex
+	f.setError(nil)
+}
+
+// ToggleIgnoreCase toggles the case-insensitive mode which is applied when the input is confirmed
+func (f *Filter) ToggleIgnoreCase() {
+	f.mode.IgnoreCase = !f.mode.IgnoreCase
+	f.setError(nil)
+}
+
+// AddCondition adds the query condition to the confirmed input with AND and applies it.
+// A plain text input is quoted as a free text term which matches any span of the service
+// like the other conditions. It returns an error without changing the input if the filter
+// cannot be applied.
+func (f *Filter) AddCondition(condition string) error {
+	input := condition
+	if f.inputConfirmed != "" {
+		input = fmt.Sprintf("(%s) AND %s", telemetry.TextCondition(f.inputConfirmed, f.modeConfirmed), condition)
+	}
+	return f.Apply(input, f.modeConfirmed, f.sortType)
+}
+
+// Apply applies the input with the match mode and the sort type, and confirms them.
+// It returns an error without changing the filter if the input cannot be applied.
+func (f *Filter) Apply(input string, mode telemetry.MatchMode, sortType telemetry.SortType) error {
+	if f.onInputEnterFn != nil {
+		if err := f.onInputEnterFn(input, mode, sortType); err != nil {
+			return err
+		}
+	}
+	f.view.SetText(input)
+	f.inputConfirmed = input
+	f.mode, f.modeConfirmed = mode, mode
+	f.sortType = sortType
+	f.setError(nil)
+	return nil
+}
+
 func (f *Filter) RotateSortType() {
 	switch f.sortType {
 	case telemetry.SORT_TYPE_NONE:
@@ -100,10 +200,22 @@ func (f *Filter) RotateSortType() {
 		f.sortType = telemetry.SORT_TYPE_NONE
 	}
 	if f.onSortTypeChangedFn != nil {
-		f.onSortTypeChangedFn(f.inputConfirmed, f.sortType)
+		if err := f.onSortTypeChangedFn(f.inputConfirmed, f.modeConfirmed, f.sortType); err != nil {
+			f.setError(err)
+		}
 	}
 }
 
+// Err returns the error of the last input applied
+func (f *Filter) Err() error {
+	return f.err
+}
+
+// Mode returns the match mode of the confirmed input
+func (f *Filter) Mode() telemetry.MatchMode {
+	return f.modeConfirmed
+}
+
 func (f *Filter) InputConfirmed() string {
 	return f.inputConfirmed
 }
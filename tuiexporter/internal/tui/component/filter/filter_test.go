@@ -1,6 +1,7 @@
 package filter
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/gdamore/tcell/v2"
@@ -15,8 +16,9 @@ type filterCallbackMock struct {
 	mock.Mock
 }
 
-func (m *filterCallbackMock) OnInputEnter(inputConfirmed string, sortType telemetry.SortType) {
-	m.Called(inputConfirmed, sortType)
+func (m *filterCallbackMock) OnInputEnter(inputConfirmed string, mode telemetry.MatchMode, sortType telemetry.SortType) error {
+	args := m.Called(inputConfirmed, mode, sortType)
+	return args.Error(0)
 }
 func (m *filterCallbackMock) OnInputDone() {
 	m.Called()
@@ -24,8 +26,9 @@ func (m *filterCallbackMock) OnInputDone() {
 func (m *filterCallbackMock) OnInputChanged(text string) {
 	m.Called(text)
 }
-func (m *filterCallbackMock) OnSortTypeChanged(inputConfirmed string, sortType telemetry.SortType) {
-	m.Called(inputConfirmed, sortType)
+func (m *filterCallbackMock) OnSortTypeChanged(inputConfirmed string, mode telemetry.MatchMode, sortType telemetry.SortType) error {
+	args := m.Called(inputConfirmed, mode, sortType)
+	return args.Error(0)
 }
 
 func TestDrawFilter(t *testing.T) {
@@ -92,7 +95,7 @@ func TestDrawFilter(t *testing.T) {
 			handler := filter.view.InputHandler()
 
 			mockcb := &filterCallbackMock{}
-			mockcb.On("OnInputEnter", "a-", telemetry.SORT_TYPE_NONE).Once()
+			mockcb.On("OnInputEnter", "a-", telemetry.MatchMode{}, telemetry.SORT_TYPE_NONE).Return(nil).Once()
 			mockcb.On("OnInputDone").Once()
 
 			filter.onInputEnterFn = mockcb.OnInputEnter
@@ -105,10 +108,41 @@ func TestDrawFilter(t *testing.T) {
 			assert.Equal(t, "a-", filter.input)
 			assert.Equal(t, "a-", filter.inputConfirmed)
 			assert.Equal(t, "a-", filter.view.GetText())
+			assert.NilError(t, filter.Err())
 
 			mockcb.AssertExpectations(t)
 		})
 
+		t.Run("enter with error", func(t *testing.T) {
+			filter := setup()
+			handler := filter.view.InputHandler()
+
+			mockcb := &filterCallbackMock{}
+			mockcb.On("OnInputEnter", "a-", telemetry.MatchMode{}, telemetry.SORT_TYPE_NONE).Return(errors.New("bad query")).Once()
+
+			filter.onInputEnterFn = mockcb.OnInputEnter
+			filter.onInputDoneFn = mockcb.OnInputDone
+
+			filter.view.Focus(nil)
+
+			handler(tcell.NewEventKey(tcell.KeyEnter, ' ', tcell.ModNone), nil)
+
+			assert.Equal(t, "a-", filter.input)
+			assert.Equal(t, "", filter.inputConfirmed)
+			assert.Equal(t, "Invalid filter (bad query): ", filter.view.GetLabel())
+			assert.ErrorContains(t, filter.Err(), "bad query")
+
+			mockcb.AssertExpectations(t)
+			mockcb.AssertNotCalled(t, "OnInputDone")
+
+			// cancel clears the error
+			mockcb.On("OnInputDone").Once()
+			handler(tcell.NewEventKey(tcell.KeyEsc, ' ', tcell.ModNone), nil)
+
+			assert.Equal(t, "test input: ", filter.view.GetLabel())
+			assert.NilError(t, filter.Err())
+		})
+
 		t.Run("escape", func(t *testing.T) {
 			filter := setup()
 			handler := filter.view.InputHandler()
@@ -132,6 +166,92 @@ func TestDrawFilter(t *testing.T) {
 		})
 	})
 
+	t.Run("toggle match mode", func(t *testing.T) {
+		filter := setup()
+		filter.input = "GET.*"
+		filter.view.SetText("GET.*")
+		handler := filter.view.InputHandler()
+
+		filter.ToggleRegex()
+		assert.Equal(t, "test input [regex]: ", filter.view.GetLabel())
+		filter.ToggleIgnoreCase()
+		assert.Equal(t, "test input [regex, ignore case]: ", filter.view.GetLabel())
+
+		mode := telemetry.MatchMode{Regex: true, IgnoreCase: true}
+		mockcb := &filterCallbackMock{}
+		mockcb.On("OnInputEnter", "GET.*", mode, telemetry.SORT_TYPE_NONE).Return(nil).Once()
+		filter.onInputEnterFn = mockcb.OnInputEnter
+		filter.view.Focus(nil)
+
+		handler(tcell.NewEventKey(tcell.KeyEnter, ' ', tcell.ModNone), nil)
+		assert.Equal(t, mode, filter.Mode())
+
+		// cancel restores the confirmed mode
+		filter.ToggleRegex()
+		assert.Equal(t, "test input [ignore case]: ", filter.view.GetLabel())
+		handler(tcell.NewEventKey(tcell.KeyEsc, ' ', tcell.ModNone), nil)
+		assert.Equal(t, "test input [regex, ignore case]: ", filter.view.GetLabel())
+		assert.Equal(t, mode, filter.Mode())
+
+		mockcb.AssertExpectations(t)
+	})
+
+	t.Run("add condition", func(t *testing.T) {
+		filter := setup()
+
+		mockcb := &filterCallbackMock{}
+		mockcb.On("OnInputEnter", `"trace.http.route" = "/checkout"`, telemetry.MatchMode{}, telemetry.SORT_TYPE_NONE).Return(nil).Once()
+		mockcb.On("OnInputEnter", `("trace.http.route" = "/checkout") AND "trace.http.method" = "GET"`, telemetry.MatchMode{}, telemetry.SORT_TYPE_NONE).Return(nil).Once()
+		mockcb.On("OnInputEnter", `(("trace.http.route" = "/checkout") AND "trace.http.method" = "GET") AND bad`, telemetry.MatchMode{}, telemetry.SORT_TYPE_NONE).Return(errors.New("bad query")).Once()
+		filter.onInputEnterFn = mockcb.OnInputEnter
+
+		assert.NilError(t, filter.AddCondition(`"trace.http.route" = "/checkout"`))
+		assert.Equal(t, `"trace.http.route" = "/checkout"`, filter.InputConfirmed())
+		assert.Equal(t, `"trace.http.route" = "/checkout"`, filter.view.GetText())
+
+		assert.NilError(t, filter.AddCondition(`"trace.http.method" = "GET"`))
+		assert.Equal(t, `("trace.http.route" = "/checkout") AND "trace.http.method" = "GET"`, filter.InputConfirmed())
+
+		// the input is kept on error
+		assert.ErrorContains(t, filter.AddCondition("bad"), "bad query")
+		assert.Equal(t, `("trace.http.route" = "/checkout") AND "trace.http.method" = "GET"`, filter.InputConfirmed())
+		assert.Equal(t, `("trace.http.route" = "/checkout") AND "trace.http.method" = "GET"`, filter.view.GetText())
+
+		mockcb.AssertExpectations(t)
+	})
+
+	t.Run("add condition to plain text", func(t *testing.T) {
+		filter := setup()
+		filter.inputConfirmed = "api GET"
+
+		mockcb := &filterCallbackMock{}
+		mockcb.On("OnInputEnter", `("api GET") AND "trace.http.route" = "/checkout"`, telemetry.MatchMode{}, telemetry.SORT_TYPE_NONE).Return(nil).Once()
+		filter.onInputEnterFn = mockcb.OnInputEnter
+
+		assert.NilError(t, filter.AddCondition(`"trace.http.route" = "/checkout"`))
+		assert.Equal(t, `("api GET") AND "trace.http.route" = "/checkout"`, filter.InputConfirmed())
+
+		mockcb.AssertExpectations(t)
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		filter := setup()
+
+		mode := telemetry.MatchMode{IgnoreCase: true}
+		mockcb := &filterCallbackMock{}
+		mockcb.On("OnInputEnter", "status = error", mode, telemetry.SORT_TYPE_LATENCY_DESC).Return(nil).Once()
+		filter.onInputEnterFn = mockcb.OnInputEnter
+
+		assert.NilError(t, filter.Apply("status = error", mode, telemetry.SORT_TYPE_LATENCY_DESC))
+		assert.Equal(t, "status = error", filter.InputConfirmed())
+		assert.Equal(t, "status = error", filter.view.GetText())
+		assert.Equal(t, mode, filter.Mode())
+		assert.Equal(t, telemetry.SORT_TYPE_LATENCY_DESC, *filter.SortType())
+		assert.Equal(t, "test input [ignore case]: ", filter.view.GetLabel())
+
+		mockcb.AssertExpectations(t)
+	})
+
 	t.Run("rotate sort type", func(t *testing.T) {
 		tests := []struct {
 			name  string
@@ -161,7 +281,7 @@ func TestDrawFilter(t *testing.T) {
 				filter.sortType = tt.input
 
 				mockcb := &filterCallbackMock{}
-				mockcb.On("OnSortTypeChanged", "", tt.want).Once()
+				mockcb.On("OnSortTypeChanged", "", telemetry.MatchMode{}, tt.want).Return(nil).Once()
 
 				filter.onSortTypeChangedFn = mockcb.OnSortTypeChanged
 				filter.RotateSortType()
@@ -170,5 +290,19 @@ func TestDrawFilter(t *testing.T) {
 				mockcb.AssertExpectations(t)
 			})
 		}
+
+		t.Run("error", func(t *testing.T) {
+			filter := setup()
+
+			mockcb := &filterCallbackMock{}
+			mockcb.On("OnSortTypeChanged", "", telemetry.MatchMode{}, telemetry.SORT_TYPE_LATENCY_DESC).Return(errors.New("bad query")).Once()
+
+			filter.onSortTypeChangedFn = mockcb.OnSortTypeChanged
+			filter.RotateSortType()
+
+			assert.Equal(t, "Invalid filter (bad query): ", filter.view.GetLabel())
+			assert.ErrorContains(t, filter.Err(), "bad query")
+			mockcb.AssertExpectations(t)
+		})
 	})
 }
@@ -8,7 +8,24 @@ import (
 	"go.opentelemetry.io/collector/pdata/pcommon"
 )
 
+// Attribute is the key and value of an attribute node appended by AppendAttrsSorted.
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// GetAttribute returns the attribute of the node. It returns false if the node is not
+// an attribute node.
+func GetAttribute(node *tview.TreeNode) (Attribute, bool) {
+	if node == nil {
+		return Attribute{}, false
+	}
+	attr, ok := node.GetReference().(Attribute)
+	return attr, ok
+}
+
 // AppendAttrsSorted appends attributes to the given parent node in sorted order by key.
+// Each node has the Attribute as its reference.
 func AppendAttrsSorted(parent *tview.TreeNode, attrs pcommon.Map) {
 	keys := make([]string, 0, attrs.Len())
 	attrs.Range(func(k string, _ pcommon.Value) bool {
@@ -19,7 +36,8 @@ func AppendAttrsSorted(parent *tview.TreeNode, attrs pcommon.Map) {
 
 	for _, k := range keys {
 		v, _ := attrs.Get(k)
-		attr := tview.NewTreeNode(fmt.Sprintf("%s: %s", k, v.AsString()))
+		attr := tview.NewTreeNode(fmt.Sprintf("%s: %s", k, v.AsString())).
+			SetReference(Attribute{Key: k, Value: v.AsString()})
 		parent.AddChild(attr)
 	}
 }
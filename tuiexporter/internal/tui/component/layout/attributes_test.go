@@ -107,3 +107,28 @@ func TestAppendAttrsSorted(t *testing.T) {
 		})
 	}
 }
+
+func TestGetAttribute(t *testing.T) {
+	parent := tview.NewTreeNode("root")
+	attrs := pcommon.NewMap()
+	attrs.PutStr("http.route", "/checkout")
+	attrs.PutInt("http.status_code", 200)
+	AppendAttrsSorted(parent, attrs)
+
+	children := parent.GetChildren()
+	got, ok := GetAttribute(children[0])
+	if !ok || got != (Attribute{Key: "http.route", Value: "/checkout"}) {
+		t.Errorf("unexpected attribute: %v, %v", got, ok)
+	}
+	got, ok = GetAttribute(children[1])
+	if !ok || got != (Attribute{Key: "http.status_code", Value: "200"}) {
+		t.Errorf("unexpected attribute: %v, %v", got, ok)
+	}
+
+	if _, ok := GetAttribute(parent); ok {
+		t.Error("expected the parent node not to be an attribute")
+	}
+	if _, ok := GetAttribute(nil); ok {
+		t.Error("expected nil not to be an attribute")
+	}
+}
@@ -1,27 +1,38 @@
 package layout
 
-import "github.com/rivo/tview"
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
 
 const (
 	PageIDTraces        = "Traces"
+	PageIDServices      = "Services"
 	PageIDMetrics       = "Metrics"
 	PageIDLogs          = "Logs"
 	PageIDTraceTopology = "TraceTopology"
 	PageIDTimeline      = "Timeline"
+	PageIDTraceDiff     = "TraceDiff"
 	PageIDModal         = "Modal"
 )
 
-func AttachTab(p tview.Primitive, name string) *tview.Flex {
+const tabStatusWidth = 34
+
+// AttachTab attaches the tabs to the page. statusFn returns the status text (e.g. memory usage)
+// shown on the right side of the tabs. No status is shown if it is nil.
+func AttachTab(p tview.Primitive, name string, statusFn func() string) *tview.Flex {
 	var text string
 	switch name {
 	case PageIDTraces:
-		text = "< [yellow]Traces[white] | Metrics | Logs | Topology (beta) > (Tab to switch)"
+		text = "< [yellow]Traces[white] | Services | Metrics | Logs | Topology (beta) > (Tab to switch)"
+	case PageIDServices:
+		text = "< Traces | [yellow]Services[white] | Metrics | Logs | Topology (beta) > (Tab to switch)"
 	case PageIDMetrics:
-		text = "< Traces | [yellow]Metrics[white] | Logs | Topology (beta) > (Tab to switch)"
+		text = "< Traces | Services | [yellow]Metrics[white] | Logs | Topology (beta) > (Tab to switch)"
 	case PageIDLogs:
-		text = "< Traces | Metrics | [yellow]Logs[white] | Topology (beta) > (Tab to switch)"
+		text = "< Traces | Services | Metrics | [yellow]Logs[white] | Topology (beta) > (Tab to switch)"
 	case PageIDTraceTopology:
-		text = "< Traces | Metrics | Logs | [yellow]Topology (beta)[white] > (Tab to switch)"
+		text = "< Traces | Services | Metrics | Logs | [yellow]Topology (beta)[white] > (Tab to switch)"
 	}
 
 	tabs := tview.NewTextView().
@@ -29,8 +40,22 @@ func AttachTab(p tview.Primitive, name string) *tview.Flex {
 		SetTextAlign(tview.AlignCenter).
 		SetText(text)
 
+	header := tview.NewFlex()
+	if statusFn != nil {
+		status := tview.NewBox().SetDrawFunc(func(screen tcell.Screen, x, y, width, height int) (int, int, int, int) {
+			tview.Print(screen, statusFn(), x, y, width-1, tview.AlignRight, tcell.ColorGray)
+			return x, y, width, height
+		})
+		// keep the tabs centered
+		header.AddItem(nil, tabStatusWidth, 0, false).
+			AddItem(tabs, 0, 1, false).
+			AddItem(status, tabStatusWidth, 0, false)
+	} else {
+		header.AddItem(tabs, 0, 1, false)
+	}
+
 	base := tview.NewFlex().SetDirection(tview.FlexRow)
-	base.AddItem(tabs, 1, 1, false).
+	base.AddItem(header, 1, 1, false).
 		AddItem(p, 0, 1, true)
 
 	return base
@@ -1,6 +1,8 @@
 package component
 
 import (
+	"fmt"
+
 	"github.com/rivo/tview"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/telemetry"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/layout"
@@ -8,9 +10,11 @@ import (
 	clog "github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/page/log"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/page/metric"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/page/modal"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/page/services"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/page/timeline"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/page/topology"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/page/trace"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/page/tracediff"
 )
 
 type TUIPages struct {
@@ -18,6 +22,8 @@ type TUIPages struct {
 	pages    *tview.Pages
 	traces   tview.Primitive
 	timeline *timeline.TimelinePage
+	diff     *tracediff.TraceDiffPage
+	services *services.ServicesPage
 	topology *topology.TopologyPage
 	metrics  tview.Primitive
 	logs     tview.Primitive
@@ -25,7 +31,7 @@ type TUIPages struct {
 	current  string
 }
 
-func NewTUIPages(store *telemetry.Store, setFocusFn func(tview.Primitive)) *TUIPages {
+func NewTUIPages(store *telemetry.Store, presets *telemetry.PresetStore, setFocusFn func(tview.Primitive)) *TUIPages {
 	pages := tview.NewPages()
 	tp := &TUIPages{
 		store:   store,
@@ -33,7 +39,7 @@ func NewTUIPages(store *telemetry.Store, setFocusFn func(tview.Primitive)) *TUIP
 		current: layout.PageIDTraces,
 	}
 
-	tp.registerPages(store, setFocusFn)
+	tp.registerPages(store, presets, setFocusFn)
 
 	return tp
 }
@@ -47,6 +53,9 @@ func (p *TUIPages) GetPages() *tview.Pages {
 func (p *TUIPages) TogglePage() {
 	switch p.current {
 	case layout.PageIDTraces:
+		p.switchToPage(layout.PageIDServices)
+		p.services.UpdateServices()
+	case layout.PageIDServices:
 		p.switchToPage(layout.PageIDMetrics)
 	case layout.PageIDMetrics:
 		p.switchToPage(layout.PageIDLogs)
@@ -63,8 +72,11 @@ func (p *TUIPages) TogglePageReverse() {
 	case layout.PageIDTraces:
 		p.switchToPage(layout.PageIDTraceTopology)
 		p.topology.UpdateTopology()
-	case layout.PageIDMetrics:
+	case layout.PageIDServices:
 		p.switchToPage(layout.PageIDTraces)
+	case layout.PageIDMetrics:
+		p.switchToPage(layout.PageIDServices)
+		p.services.UpdateServices()
 	case layout.PageIDLogs:
 		p.switchToPage(layout.PageIDMetrics)
 	case layout.PageIDTraceTopology:
@@ -74,12 +86,20 @@ func (p *TUIPages) TogglePageReverse() {
 	}
 }
 
+// Refresh updates the current page with the data added to the store. It must be called from
+// the event loop of the application.
+func (p *TUIPages) Refresh() {
+	if p.current == layout.PageIDTraceTopology {
+		p.topology.Refresh()
+	}
+}
+
 func (p *TUIPages) switchToPage(name string) {
 	p.pages.SwitchToPage(name)
 	p.current = name
 }
 
-func (p *TUIPages) registerPages(store *telemetry.Store, setFocusFn func(tview.Primitive)) {
+func (p *TUIPages) registerPages(store *telemetry.Store, presets *telemetry.PresetStore, setFocusFn func(tview.Primitive)) {
 	modal := modal.NewModalPage()
 	p.modal = modal.GetPrimitive()
 	p.pages.AddPage(layout.PageIDModal, p.modal, true, true)
@@ -94,12 +114,20 @@ func (p *TUIPages) registerPages(store *telemetry.Store, setFocusFn func(tview.P
 	})
 
 	navigation.Init(setFocusFn, showModalFn, hideModalFn)
+	statusFn := func() string {
+		return memoryStatus(store)
+	}
 
 	traces := trace.NewTracePage(
-		func(row, _ int) {
-			p.timeline.ShowTimelineByRow(row - 1)
+		func(traceID string) {
+			p.timeline.DrawTimeline(traceID)
+		},
+		func(traceIDA, traceIDB string) {
+			p.diff.DrawTraceDiff(traceIDA, traceIDB)
 		},
 		store,
+		presets,
+		statusFn,
 	)
 	tracesPage := traces.GetPrimitive()
 	p.traces = tracesPage
@@ -113,16 +141,52 @@ func (p *TUIPages) registerPages(store *telemetry.Store, setFocusFn func(tview.P
 		func() {
 			p.switchToPage(layout.PageIDTraces)
 		},
+		func(attr layout.Attribute) {
+			p.switchToPage(layout.PageIDTraces)
+			traces.FilterByAttribute(attr)
+		},
 	)
 	p.timeline = timeline
 	p.pages.AddPage(layout.PageIDTimeline, timeline.GetPrimitive(), true, false)
 
-	topology := topology.NewTopologyPage(store.GetTraceCache())
+	diff := tracediff.NewTraceDiffPage(
+		func() {
+			p.switchToPage(layout.PageIDTraceDiff)
+		},
+		store,
+		func() {
+			p.switchToPage(layout.PageIDTraces)
+		},
+	)
+	p.diff = diff
+	p.pages.AddPage(layout.PageIDTraceDiff, diff.GetPrimitive(), true, false)
+
+	services := services.NewServicesPage(
+		store,
+		func(service, spanName string) {
+			p.switchToPage(layout.PageIDTraces)
+			traces.FilterByOperation(service, spanName)
+		},
+		statusFn,
+	)
+	p.services = services
+	p.pages.AddPage(layout.PageIDServices, services.GetPrimitive(), true, false)
+
+	topology := topology.NewTopologyPage(
+		store,
+		func(condition string) {
+			p.switchToPage(layout.PageIDTraces)
+			traces.FilterByCondition(condition)
+		},
+		statusFn,
+	)
 	p.topology = topology
 	p.pages.AddPage(layout.PageIDTraceTopology, topology.GetPrimitive(), true, false)
 
 	metrics := metric.NewMetricPage(
 		store,
+		presets,
+		statusFn,
 	)
 	metricsPage := metrics.GetPrimitive()
 	p.metrics = metricsPage
@@ -133,8 +197,18 @@ func (p *TUIPages) registerPages(store *telemetry.Store, setFocusFn func(tview.P
 			p.timeline.DrawTimeline(traceID)
 		},
 		store,
+		presets,
+		statusFn,
 	)
 	logsPage := logs.GetPrimitive()
 	p.logs = logsPage
 	p.pages.AddPage(layout.PageIDLogs, logsPage, true, false)
 }
+
+func memoryStatus(store *telemetry.Store) string {
+	usage := telemetry.FormatBytes(store.MemoryUsage())
+	if store.MaxMemoryBytes() > 0 {
+		return fmt.Sprintf("Memory: %s / %s", usage, telemetry.FormatBytes(store.MaxMemoryBytes()))
+	}
+	return fmt.Sprintf("Memory: %s", usage)
+}
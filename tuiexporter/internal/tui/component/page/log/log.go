@@ -25,6 +25,8 @@ type LogPage struct {
 func NewLogPage(
 	drawTimelineFn func(traceID string),
 	store *telemetry.Store,
+	presets *telemetry.PresetStore,
+	statusFn func() string,
 ) *LogPage {
 	commands := layout.NewCommandList()
 	container := tview.NewFlex().SetDirection(tview.FlexRow)
@@ -37,7 +39,7 @@ func NewLogPage(
 		resizeManager,
 	}, store.GetTraceCache())
 	body := newBody(commands, resizeManager)
-	table := newTable(commands, store, detail, body, []*layout.ResizeManager{
+	table := newTable(commands, store, presets, detail, body, []*layout.ResizeManager{
 		mainResizeManager,
 		resizeManager,
 	})
@@ -71,7 +73,7 @@ func NewLogPage(
 		body:   body,
 	}
 
-	logPage.view = layout.AttachTab(layout.AttachCommandList(commands, container), layout.PageIDLogs)
+	logPage.view = layout.AttachTab(layout.AttachCommandList(commands, container), layout.PageIDLogs, statusFn)
 
 	logPage.registerCommands()
 	store.RegisterOnFlushed(func() {
@@ -92,7 +94,7 @@ func (p *LogPage) flush() {
 
 func (p *LogPage) registerCommands() {
 	p.view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		if !p.table.filter.View().HasFocus() {
+		if !p.table.filter.View().HasFocus() && !p.table.picker.HasFocus() {
 			switch event.Rune() {
 			case 'd':
 				navigation.Focus(p.detail.view)
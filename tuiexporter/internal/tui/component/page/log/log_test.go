@@ -27,6 +27,10 @@ func setupLogPage(t *testing.T) (*mockDrawTimelineHandler, *LogPage, tcell.Simul
 	mockHandler := new(mockDrawTimelineHandler)
 	mockClock := clockwork.NewFakeClockAt(time.Date(2025, 11, 9, 12, 15, 0, 0, time.UTC))
 	store := telemetry.NewStore(mockClock)
+	presets, err := telemetry.NewPresetStore("")
+	if err != nil {
+		t.Fatalf("failed to create preset store: %v", err)
+	}
 
 	sw, sh := 220, 50
 	screen := tcell.NewSimulationScreen("")
@@ -35,7 +39,7 @@ func setupLogPage(t *testing.T) (*mockDrawTimelineHandler, *LogPage, tcell.Simul
 	}
 	screen.SetSize(sw, sh)
 
-	page := NewLogPage(mockHandler.DrawTimeline, store)
+	page := NewLogPage(mockHandler.DrawTimeline, store, presets, nil)
 	page.table.table.Focus(nil)
 
 	page.view.SetRect(0, 0, sw, sh)
@@ -8,9 +8,11 @@ import (
 	"github.com/rivo/tview"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/json"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/telemetry"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/export"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/filter"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/layout"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/navigation"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/preset"
 	ctable "github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/table"
 )
 
@@ -20,6 +22,7 @@ type table struct {
 	table           *tview.Table
 	logData         *ctable.LogDataForTable
 	filter          *filter.Filter
+	picker          *preset.Picker
 	detail          *detail
 	body            *body
 	resolvedLogBody string
@@ -28,6 +31,7 @@ type table struct {
 func newTable(
 	commands *tview.TextView,
 	store *telemetry.Store,
+	presets *telemetry.PresetStore,
 	detail *detail,
 	body *body,
 	resizeManagers []*layout.ResizeManager,
@@ -43,8 +47,8 @@ func newTable(
 	filter := filter.NewFilter(
 		commands,
 		"Filter by service or body (/): ",
-		func(inputConfirmed string, _ telemetry.SortType) {
-			store.ApplyFilterLogs(inputConfirmed)
+		func(inputConfirmed string, mode telemetry.MatchMode, _ telemetry.SortType) error {
+			return store.ApplyFilterLogs(inputConfirmed, mode)
 		},
 		func() {
 			navigation.Focus(t)
@@ -61,12 +65,17 @@ func newTable(
 		}
 	})
 
+	picker := preset.NewPicker(commands, container, presets, telemetry.SignalLogs, filter, func() {
+		navigation.Focus(t)
+	})
+
 	stable := &table{
 		store:   store,
 		view:    container,
 		table:   t,
 		logData: &logData,
 		filter:  filter,
+		picker:  picker,
 		detail:  detail,
 		body:    body,
 	}
@@ -124,6 +133,8 @@ func (t *table) registerCommands(commands *tview.TextView, resizeManagers []*lay
 			},
 		},
 	}
+	keyMaps.Merge(t.picker.KeyMaps())
+	keyMaps.Merge(export.KeyMaps(commands, t.store, telemetry.SignalLogs))
 	for _, rm := range resizeManagers {
 		keyMaps.Merge(rm.KeyMaps())
 	}
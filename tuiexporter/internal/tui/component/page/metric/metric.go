@@ -24,6 +24,8 @@ type MetricPage struct {
 
 func NewMetricPage(
 	store *telemetry.Store,
+	presets *telemetry.PresetStore,
+	statusFn func() string,
 ) *MetricPage {
 	commands := layout.NewCommandList()
 	container := tview.NewFlex().SetDirection(tview.FlexColumn)
@@ -39,7 +41,7 @@ func NewMetricPage(
 		sideResizeManager,
 		resizeManager,
 	})
-	table := newTable(commands, store, detail, chart, []*layout.ResizeManager{resizeManager})
+	table := newTable(commands, store, presets, detail, chart, []*layout.ResizeManager{resizeManager})
 
 	resizeManager.Register(
 		container,
@@ -70,7 +72,7 @@ func NewMetricPage(
 		chart:  chart,
 	}
 
-	metric.view = layout.AttachTab(layout.AttachCommandList(commands, container), layout.PageIDMetrics)
+	metric.view = layout.AttachTab(layout.AttachCommandList(commands, container), layout.PageIDMetrics, statusFn)
 
 	metric.registerCommands()
 	store.RegisterOnFlushed(func() {
@@ -91,7 +93,7 @@ func (p *MetricPage) flush() {
 
 func (p *MetricPage) registerCommands() {
 	p.view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		if !p.table.filter.View().HasFocus() {
+		if !p.table.filter.View().HasFocus() && !p.table.picker.HasFocus() {
 			switch event.Rune() {
 			case 'd':
 				navigation.Focus(p.detail.view)
@@ -17,6 +17,10 @@ func setupMetricPage(t *testing.T) (*MetricPage, tcell.SimulationScreen, *teleme
 
 	mockClock := clockwork.NewFakeClockAt(time.Date(2025, 11, 9, 12, 15, 0, 0, time.UTC))
 	store := telemetry.NewStore(mockClock)
+	presets, err := telemetry.NewPresetStore("")
+	if err != nil {
+		t.Fatalf("failed to create preset store: %v", err)
+	}
 
 	sw, sh := 220, 50
 	screen := tcell.NewSimulationScreen("")
@@ -25,7 +29,7 @@ func setupMetricPage(t *testing.T) (*MetricPage, tcell.SimulationScreen, *teleme
 	}
 	screen.SetSize(sw, sh)
 
-	page := NewMetricPage(store)
+	page := NewMetricPage(store, presets, nil)
 	page.table.table.Focus(nil)
 
 	page.view.SetRect(0, 0, sw, sh)
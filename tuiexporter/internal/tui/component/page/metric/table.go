@@ -6,9 +6,11 @@ import (
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/telemetry"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/export"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/filter"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/layout"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/navigation"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/preset"
 	ctable "github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/table"
 )
 
@@ -18,6 +20,7 @@ type table struct {
 	table      *tview.Table
 	metricData *ctable.MetricDataForTable
 	filter     *filter.Filter
+	picker     *preset.Picker
 	detail     *detail
 	chart      *chart
 }
@@ -25,6 +28,7 @@ type table struct {
 func newTable(
 	commands *tview.TextView,
 	store *telemetry.Store,
+	presets *telemetry.PresetStore,
 	detail *detail,
 	chart *chart,
 	resizeManagers []*layout.ResizeManager,
@@ -40,15 +44,15 @@ func newTable(
 	filter := filter.NewFilter(
 		commands,
 		"Filter by service or metric name (/): ",
-		func(inputConfirmed string, _ telemetry.SortType) {
-			store.ApplyFilterMetrics(inputConfirmed)
+		func(inputConfirmed string, mode telemetry.MatchMode, _ telemetry.SortType) error {
+			return store.ApplyFilterMetrics(inputConfirmed, mode)
 		},
 		func() {
 			navigation.Focus(t)
 		},
 		nil,
-		func(inputConfirmed string, _ telemetry.SortType) {
-			store.ApplyFilterMetrics(inputConfirmed)
+		func(inputConfirmed string, mode telemetry.MatchMode, _ telemetry.SortType) error {
+			return store.ApplyFilterMetrics(inputConfirmed, mode)
 		},
 	)
 
@@ -60,12 +64,17 @@ func newTable(
 		}
 	})
 
+	picker := preset.NewPicker(commands, container, presets, telemetry.SignalMetrics, filter, func() {
+		navigation.Focus(t)
+	})
+
 	stable := &table{
 		store:      store,
 		view:       container,
 		table:      t,
 		metricData: &metricData,
 		filter:     filter,
+		picker:     picker,
 		detail:     detail,
 		chart:      chart,
 	}
@@ -103,6 +112,8 @@ func (t *table) registerCommands(commands *tview.TextView, resizeManagers []*lay
 			},
 		},
 	}
+	keyMaps.Merge(t.picker.KeyMaps())
+	keyMaps.Merge(export.KeyMaps(commands, t.store, telemetry.SignalMetrics))
 	for _, rm := range resizeManagers {
 		keyMaps.Merge(rm.KeyMaps())
 	}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/telemetry"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/layout"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/navigation"
+)
+
+const (
+	defaultTableProportion     = 30
+	defaultHistogramProportion = 20
+	histogramBarWidth          = 30
+)
+
+type ServicesPage struct {
+	view              *tview.Flex
+	table             *tview.Table
+	histogram         *tview.TextView
+	store             *telemetry.Store
+	onSelectOperation func(service, spanName string)
+	stats             []*telemetry.OperationStats
+}
+
+func NewServicesPage(store *telemetry.Store, onSelectOperation func(service, spanName string), statusFn func() string) *ServicesPage {
+	commands := layout.NewCommandList()
+	container := tview.NewFlex().SetDirection(tview.FlexColumn)
+	container.SetBorder(false)
+
+	table := tview.NewTable().
+		SetBorders(false).
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetTitle("Services (s)").SetBorder(true)
+
+	histogram := tview.NewTextView().
+		SetWrap(false).
+		SetDynamicColors(false)
+	histogram.SetTitle("Latency Histogram (h)").SetBorder(true)
+
+	resizeManager := layout.NewResizeManager(layout.ResizeDirectionHorizontal)
+	resizeManager.Register(
+		container,
+		table,
+		histogram,
+		defaultTableProportion,
+		defaultHistogramProportion,
+		commands,
+	)
+
+	container.AddItem(table, 0, defaultTableProportion, true).
+		AddItem(histogram, 0, defaultHistogramProportion, false)
+
+	page := &ServicesPage{
+		table:             table,
+		histogram:         histogram,
+		store:             store,
+		onSelectOperation: onSelectOperation,
+	}
+
+	table.SetSelectionChangedFunc(func(row, _ int) {
+		page.updateHistogram(row)
+	})
+
+	page.view = layout.AttachTab(layout.AttachCommandList(commands, container), layout.PageIDServices, statusFn)
+
+	page.registerCommands(commands, container, resizeManager)
+
+	return page
+}
+
+func (p *ServicesPage) GetPrimitive() tview.Primitive {
+	return p.view
+}
+
+func (p *ServicesPage) registerCommands(commands *tview.TextView, container *tview.Flex, resizeManager *layout.ResizeManager) {
+	container.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Rune() {
+		case 's':
+			navigation.Focus(p.table)
+			return nil
+		case 'h':
+			navigation.Focus(p.histogram)
+			return nil
+		}
+		return event
+	})
+
+	keyMaps := layout.KeyMaps{
+		{
+			Key:         tcell.NewEventKey(tcell.KeyEnter, ' ', tcell.ModNone),
+			Description: "Show traces",
+			Handler: func(event *tcell.EventKey) *tcell.EventKey {
+				row, _ := p.table.GetSelection()
+				if o := p.getOperationByRow(row); o != nil {
+					p.onSelectOperation(o.ServiceName, o.SpanName)
+				}
+				return nil
+			},
+		},
+		{
+			Key:         tcell.NewEventKey(tcell.KeyCtrlR, ' ', tcell.ModNone),
+			Description: "Reload",
+			Handler: func(event *tcell.EventKey) *tcell.EventKey {
+				p.UpdateServices()
+				return nil
+			},
+		},
+	}
+	keyMaps.Merge(resizeManager.KeyMaps())
+	layout.RegisterCommandList(commands, p.table, nil, keyMaps)
+	layout.RegisterCommandList(commands, p.histogram, nil, resizeManager.KeyMaps())
+}
+
+// UpdateServices aggregates the spans in the store by the service and span name and redraws the table
+func (p *ServicesPage) UpdateServices() {
+	p.stats = p.store.OperationStats("")
+
+	p.table.Clear()
+	for col, h := range []string{"Service", "Operation", "Count", "Errors", "Error Rate", "P50", "P90", "P99"} {
+		p.table.SetCell(0, col, tview.NewTableCell(h).
+			SetSelectable(false).
+			SetTextColor(tcell.ColorYellow))
+	}
+
+	for i, o := range p.stats {
+		color := tcell.ColorDefault
+		if o.ErrorCount > 0 {
+			color = tcell.ColorRed
+		}
+		cells := []*tview.TableCell{
+			tview.NewTableCell(tview.Escape(o.ServiceName)),
+			tview.NewTableCell(tview.Escape(o.SpanName)),
+			tview.NewTableCell(fmt.Sprintf("%d", o.Count)).SetAlign(tview.AlignRight),
+			tview.NewTableCell(fmt.Sprintf("%d", o.ErrorCount)).SetAlign(tview.AlignRight).SetTextColor(color),
+			tview.NewTableCell(fmt.Sprintf("%.1f%%", o.ErrorRate()*100)).SetAlign(tview.AlignRight).SetTextColor(color),
+			tview.NewTableCell(o.P50.String()).SetAlign(tview.AlignRight),
+			tview.NewTableCell(o.P90.String()).SetAlign(tview.AlignRight),
+			tview.NewTableCell(o.P99.String()).SetAlign(tview.AlignRight),
+		}
+		for col, cell := range cells {
+			p.table.SetCell(i+1, col, cell)
+		}
+	}
+
+	row, _ := p.table.GetSelection()
+	if row < 1 || row > len(p.stats) {
+		row = 1
+	}
+	p.table.Select(row, 0)
+	p.updateHistogram(row)
+}
+
+func (p *ServicesPage) getOperationByRow(row int) *telemetry.OperationStats {
+	if row < 1 || row > len(p.stats) {
+		return nil
+	}
+	return p.stats[row-1]
+}
+
+func (p *ServicesPage) updateHistogram(row int) {
+	o := p.getOperationByRow(row)
+	if o == nil {
+		p.histogram.SetText("No data")
+		return
+	}
+	p.histogram.SetText(drawHistogram(o))
+}
+
+// drawHistogram draws the bars of the latency buckets of the operation scaled by the largest bucket
+func drawHistogram(o *telemetry.OperationStats) string {
+	maxCount := 0
+	for _, c := range o.BucketCounts {
+		maxCount = max(maxCount, c)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s\n", o.ServiceName, o.SpanName)
+	fmt.Fprintf(&b, "count: %d, p50: %s, p90: %s, p99: %s\n\n", o.Count, o.P50, o.P90, o.P99)
+	for i, c := range o.BucketCounts {
+		width := 0
+		if maxCount > 0 {
+			width = c * histogramBarWidth / maxCount
+		}
+		if c > 0 && width == 0 {
+			width = 1
+		}
+		fmt.Fprintf(&b, "%-9s %s %d\n", bucketLabel(i), strings.Repeat(string(tview.BlockFullBlock), width), c)
+	}
+	return b.String()
+}
+
+func bucketLabel(idx int) string {
+	bounds := telemetry.LatencyBucketBounds
+	if idx < len(bounds) {
+		return "<= " + bounds[idx].String()
+	}
+	return "> " + bounds[len(bounds)-1].String()
+}
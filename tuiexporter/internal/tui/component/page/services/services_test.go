@@ -0,0 +1,76 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/jonboulle/clockwork"
+	"github.com/rivo/tview"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/telemetry"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/test"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"gotest.tools/v3/assert"
+)
+
+func TestServicesPage(t *testing.T) {
+	// traceid: 1
+	//  └- resource: test-service-1
+	//    └- scope: test-scope-1-1
+	//      └- span: span-0-0-0
+	//      └- span: span-0-0-1 (error)
+	//  └- resource: test-service-2
+	//    └- scope: test-scope-2-1
+	//      └- span: span-1-0-0
+	payload, testdata := test.GenerateOTLPTracesPayload(t, 1, 2, []int{1, 1}, [][]int{{2}, {1}})
+	testdata.Spans[1].Status().SetCode(ptrace.StatusCodeError)
+	store := telemetry.NewStore(clockwork.NewRealClock())
+	store.AddSpan(&payload)
+
+	selected := []string{}
+	page := NewServicesPage(store, func(service, spanName string) {
+		selected = append(selected, service, spanName)
+	}, nil)
+	page.view.Focus(func(p tview.Primitive) {
+		page.table.Focus(nil)
+	})
+
+	page.UpdateServices()
+
+	assert.Equal(t, 4, page.table.GetRowCount())
+	assert.Equal(t, "test-service-1", page.table.GetCell(1, 0).Text)
+	assert.Equal(t, "span-0-0-0", page.table.GetCell(1, 1).Text)
+	assert.Equal(t, "1", page.table.GetCell(1, 2).Text)
+	assert.Equal(t, "0.0%", page.table.GetCell(1, 4).Text)
+	assert.Equal(t, "200ms", page.table.GetCell(1, 5).Text)
+	assert.Equal(t, "span-0-0-1", page.table.GetCell(2, 1).Text)
+	assert.Equal(t, "1", page.table.GetCell(2, 3).Text)
+	assert.Equal(t, "100.0%", page.table.GetCell(2, 4).Text)
+	assert.Equal(t, "test-service-2", page.table.GetCell(3, 0).Text)
+
+	histogram := page.histogram.GetText(false)
+	assert.Assert(t, strings.Contains(histogram, "test-service-1: span-0-0-0\n"))
+	assert.Assert(t, strings.Contains(histogram, "<= 100ms   0\n"))
+	assert.Assert(t, strings.Contains(histogram, "<= 250ms  "+strings.Repeat(string(tview.BlockFullBlock), histogramBarWidth)+" 1\n"))
+
+	t.Run("select operation", func(t *testing.T) {
+		page.table.Select(3, 0)
+
+		assert.Assert(t, strings.Contains(page.histogram.GetText(false), "test-service-2: span-1-0-0\n"))
+
+		handler := page.view.InputHandler()
+		handler(tcell.NewEventKey(tcell.KeyEnter, ' ', tcell.ModNone), nil)
+
+		assert.DeepEqual(t, []string{"test-service-2", "span-1-0-0"}, selected)
+	})
+
+	t.Run("reload", func(t *testing.T) {
+		store.Flush()
+
+		handler := page.view.InputHandler()
+		handler(tcell.NewEventKey(tcell.KeyCtrlR, ' ', tcell.ModNone), nil)
+
+		assert.Equal(t, 1, page.table.GetRowCount())
+		assert.Equal(t, "No data", page.histogram.GetText(false))
+	})
+}
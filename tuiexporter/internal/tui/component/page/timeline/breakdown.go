@@ -0,0 +1,99 @@
+package timeline
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/telemetry"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/layout"
+)
+
+const breakdownPaneHeight = 12
+
+type breakdownPane struct {
+	commands   *tview.TextView
+	tableView  *tview.Table
+	breakdown  *telemetry.TraceBreakdown
+	bySpanName bool
+}
+
+func newBreakdownPane(commands *tview.TextView) *breakdownPane {
+	container := tview.NewTable().
+		SetBorders(false).
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	container.SetBorder(true)
+
+	return &breakdownPane{
+		commands:  commands,
+		tableView: container,
+	}
+}
+
+func (b *breakdownPane) update(breakdown *telemetry.TraceBreakdown) {
+	b.breakdown = breakdown
+	b.draw()
+	b.updateCommands()
+}
+
+func (b *breakdownPane) draw() {
+	b.tableView.Clear()
+
+	by := "service"
+	headers := []string{"Service Name", "Spans", "Self Time", "Self %", "Total Time"}
+	if b.bySpanName {
+		by = "span name"
+		headers = []string{"Service Name", "Span Name", "Spans", "Self Time", "Self %", "Total Time"}
+	}
+	b.tableView.SetTitle(fmt.Sprintf("Breakdown (b) -- by %s (s: toggle by service or span name)", by))
+
+	for col, h := range headers {
+		b.tableView.SetCell(0, col, tview.NewTableCell(h).
+			SetSelectable(false).
+			SetTextColor(tcell.ColorYellow))
+	}
+
+	if b.breakdown == nil {
+		return
+	}
+	entries := b.breakdown.ByService
+	if b.bySpanName {
+		entries = b.breakdown.BySpanName
+	}
+	for i, e := range entries {
+		texts := []string{e.ServiceName}
+		if b.bySpanName {
+			texts = append(texts, e.SpanName)
+		}
+		texts = append(texts,
+			strconv.Itoa(e.SpanCount),
+			e.SelfTime.String(),
+			fmt.Sprintf("%.1f%%", b.breakdown.SelfTimeRatio(e)*100),
+			e.TotalTime.String(),
+		)
+		for col, text := range texts {
+			b.tableView.SetCell(i+1, col, tview.NewTableCell(tview.Escape(text)))
+		}
+	}
+}
+
+func (b *breakdownPane) toggleBySpanName() {
+	b.bySpanName = !b.bySpanName
+	b.draw()
+}
+
+func (b *breakdownPane) updateCommands() {
+	keyMaps := layout.KeyMaps{
+		{
+			Key:         tcell.NewEventKey(tcell.KeyRune, 's', tcell.ModNone),
+			Description: "Toggle by service or span name",
+			Handler: func(event *tcell.EventKey) *tcell.EventKey {
+				b.toggleBySpanName()
+				return nil
+			},
+		},
+	}
+	layout.RegisterCommandList(b.commands, b.tableView, nil, keyMaps)
+}
@@ -12,23 +12,27 @@ import (
 )
 
 type detail struct {
-	commands      *tview.TextView
-	view          *tview.Flex
-	tree          *tview.TreeView
-	resizeManager *layout.ResizeManager
+	commands            *tview.TextView
+	view                *tview.Flex
+	tree                *tview.TreeView
+	onFilterByAttribute func(attr layout.Attribute)
+	resizeManager       *layout.ResizeManager
+	breakdown           *telemetry.TraceBreakdown
 }
 
 func newDetail(
 	commands *tview.TextView,
+	onFilterByAttribute func(attr layout.Attribute),
 	resizeManager *layout.ResizeManager,
 ) *detail {
 	container := tview.NewFlex().SetDirection(tview.FlexRow)
 	container.SetTitle("Details (d)").SetBorder(true)
 
 	detail := &detail{
-		commands:      commands,
-		view:          container,
-		resizeManager: resizeManager,
+		commands:            commands,
+		view:                container,
+		onFilterByAttribute: onFilterByAttribute,
+		resizeManager:       resizeManager,
 	}
 
 	detail.update(nil)
@@ -36,6 +40,11 @@ func newDetail(
 	return detail
 }
 
+// setBreakdown sets the breakdown of the trace to show the self time of the spans
+func (d *detail) setBreakdown(breakdown *telemetry.TraceBreakdown) {
+	d.breakdown = breakdown
+}
+
 func (d *detail) update(span *telemetry.SpanData) {
 	d.view.Clear()
 	d.tree = d.getSpanInfoTree(span)
@@ -92,7 +101,13 @@ func (d *detail) getSpanInfoTree(span *telemetry.SpanData) *tview.TreeView {
 	root.AddChild(kindNode)
 
 	duration := span.Span.EndTimestamp().AsTime().Sub(span.Span.StartTimestamp().AsTime())
-	durationNode := tview.NewTreeNode(fmt.Sprintf("duration: %s", duration.String()))
+	durationText := fmt.Sprintf("duration: %s", duration.String())
+	if d.breakdown != nil {
+		if self, ok := d.breakdown.SpanSelfTime(spanID); ok {
+			durationText = fmt.Sprintf("%s (self time: %s)", durationText, self.String())
+		}
+	}
+	durationNode := tview.NewTreeNode(durationText)
 	root.AddChild(durationNode)
 
 	startTime := datetime.GetFullTime(span.Span.StartTimestamp().AsTime())
@@ -216,6 +231,16 @@ func (d *detail) updateCommands() {
 			Key:         tcell.NewEventKey(tcell.KeyEnter, ' ', tcell.ModNone),
 			Description: "Toggle folding (parent), Show full text (child)",
 		},
+		{
+			Key:         tcell.NewEventKey(tcell.KeyRune, 'f', tcell.ModNone),
+			Description: "Filter traces by attribute",
+			Handler: func(_ *tcell.EventKey) *tcell.EventKey {
+				if attr, ok := layout.GetAttribute(d.tree.GetCurrentNode()); ok && d.onFilterByAttribute != nil {
+					d.onFilterByAttribute(attr)
+				}
+				return nil
+			},
+		},
 	}
 	keyMaps.Merge(d.resizeManager.KeyMaps())
 	layout.RegisterCommandList(d.commands, d.tree, nil, keyMaps)
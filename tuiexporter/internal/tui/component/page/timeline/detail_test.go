@@ -18,7 +18,7 @@ func TestDetailInputCaptureAfterModalClosed(t *testing.T) {
 		ScopeSpans:   testdata.SSpans[0],
 	}
 
-	detail := newDetail(layout.NewCommandList(), layout.NewResizeManager(layout.ResizeDirectionHorizontal))
+	detail := newDetail(layout.NewCommandList(), nil, layout.NewResizeManager(layout.ResizeDirectionHorizontal))
 	detail.update(span)
 
 	sw, sh := 55, 10
@@ -43,3 +43,31 @@ func TestDetailInputCaptureAfterModalClosed(t *testing.T) {
 	// resize key should be captured
 	assert.Nil(t, got)
 }
+
+func TestDetailFilterByAttribute(t *testing.T) {
+	_, testdata := test.GenerateOTLPTracesPayload(t, 1, 1, []int{1}, [][]int{{1}})
+	span := &telemetry.SpanData{
+		Span:         testdata.Spans[0],
+		ResourceSpan: testdata.RSpans[0],
+		ScopeSpans:   testdata.SSpans[0],
+	}
+
+	got := []layout.Attribute{}
+	detail := newDetail(layout.NewCommandList(), func(attr layout.Attribute) {
+		got = append(got, attr)
+	}, layout.NewResizeManager(layout.ResizeDirectionHorizontal))
+	detail.update(span)
+
+	capture := detail.tree.GetInputCapture()
+	key := tcell.NewEventKey(tcell.KeyRune, 'f', tcell.ModNone)
+
+	// root node is not an attribute
+	assert.Nil(t, capture(key))
+	assert.Empty(t, got)
+
+	// Attributes > span index
+	attrs := detail.tree.GetRoot().GetChildren()[11]
+	detail.tree.SetCurrentNode(attrs.GetChildren()[0])
+	assert.Nil(t, capture(key))
+	assert.Equal(t, []layout.Attribute{{Key: "span index", Value: "0"}}, got)
+}
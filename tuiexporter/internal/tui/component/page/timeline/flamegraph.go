@@ -0,0 +1,461 @@
+package timeline
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/telemetry"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/layout"
+)
+
+const flameGraphRootLabel = "all spans"
+
+// flameFrame is a frame of the flame graph, which is a span, a frame merged from the spans of
+// the traces or the whole graph
+type flameFrame struct {
+	// name is shown in the breadcrumb
+	name     string
+	label    string
+	value    time.Duration
+	color    tcell.Color
+	span     *telemetry.SpanData
+	parent   *flameFrame
+	children []*flameFrame
+}
+
+// flameRect is the position of a frame in the flame graph in cells
+type flameRect struct {
+	frame *flameFrame
+	depth int
+	x     int
+	width int
+}
+
+type flameGraph struct {
+	commands      *tview.TextView
+	view          *tview.Box
+	tcache        *telemetry.TraceCache
+	resizeManager *layout.ResizeManager
+	exportKeyMaps layout.KeyMaps
+	detail        *detail
+	logPane       *logPane
+	root          *flameFrame
+	zoom          *flameFrame
+	selected      *flameFrame
+	width         int
+	tree          []*spanTreeNode
+	duration      time.Duration
+	// aggregated shows the frames merged from the traces with the same root span name
+	aggregated bool
+	// isFlame draws the frames bottom-up, otherwise top-down as an icicle graph
+	isFlame bool
+}
+
+func newFlameGraph(
+	commands *tview.TextView,
+	tcache *telemetry.TraceCache,
+	resizeManager *layout.ResizeManager,
+	exportKeyMaps layout.KeyMaps,
+	detail *detail,
+	logPane *logPane,
+) *flameGraph {
+	fg := &flameGraph{
+		commands:      commands,
+		tcache:        tcache,
+		resizeManager: resizeManager,
+		exportKeyMaps: exportKeyMaps,
+		detail:        detail,
+		logPane:       logPane,
+	}
+	fg.view = tview.NewBox().SetDrawFunc(fg.draw)
+	fg.view.SetBorder(true)
+	fg.updateTitle()
+
+	return fg
+}
+
+func (f *flameGraph) update(tree []*spanTreeNode, duration time.Duration) {
+	f.tree = tree
+	f.duration = duration
+	f.aggregated = false
+	f.setFrames(newFlameFrames(tree, duration))
+
+	f.updateCommands()
+}
+
+func (f *flameGraph) setFrames(root *flameFrame) {
+	f.root = root
+	f.zoom = root
+	f.selected = root
+	if len(root.children) > 0 {
+		f.selected = root.children[0]
+	}
+	f.updateTitle()
+}
+
+// newFlameFrames converts the span tree to the frames under the frame of the whole trace.
+// The frame of a span is sized by its duration and colored by its service.
+func newFlameFrames(tree []*spanTreeNode, duration time.Duration) *flameFrame {
+	root := &flameFrame{
+		name:  flameGraphRootLabel,
+		label: fmt.Sprintf("%s %s", flameGraphRootLabel, duration.String()),
+		value: duration,
+		color: tcell.ColorGray,
+	}
+
+	var newFrame func(node *spanTreeNode, parent *flameFrame) *flameFrame
+	newFrame = func(node *spanTreeNode, parent *flameFrame) *flameFrame {
+		frame := &flameFrame{
+			name:   fmt.Sprintf("%s: %s", node.span.GetServiceName(), node.span.GetSpanName()),
+			label:  node.label,
+			value:  node.span.Span.EndTimestamp().AsTime().Sub(node.span.Span.StartTimestamp().AsTime()),
+			color:  node.color,
+			span:   node.span,
+			parent: parent,
+		}
+		for _, child := range node.children {
+			frame.children = append(frame.children, newFrame(child, frame))
+		}
+		return frame
+	}
+	for _, node := range tree {
+		root.children = append(root.children, newFrame(node, root))
+	}
+
+	return root
+}
+
+// newAggregatedFlameFrames converts the aggregated flame graph to the frames under the frame of
+// the whole graph. The frame is sized by the average time spent in it per trace, and labeled with
+// the average and the 95th percentile of the durations of the spans.
+func newAggregatedFlameFrames(g *telemetry.AggregatedFlameGraph) *flameFrame {
+	root := &flameFrame{
+		name:  fmt.Sprintf("all traces of %s", g.RootSpanName),
+		label: fmt.Sprintf("%d traces of %s", g.TraceCount, g.RootSpanName),
+		color: tcell.ColorGray,
+	}
+
+	colorMemo := make(map[string]tcell.Color)
+	var newFrame func(af *telemetry.AggregatedFrame, parent *flameFrame) *flameFrame
+	newFrame = func(af *telemetry.AggregatedFrame, parent *flameFrame) *flameFrame {
+		if _, ok := colorMemo[af.ServiceName]; !ok {
+			colorMemo[af.ServiceName] = layout.Colors[len(colorMemo)%len(layout.Colors)]
+		}
+		frame := &flameFrame{
+			name:   fmt.Sprintf("%s: %s", af.ServiceName, af.SpanName),
+			label:  fmt.Sprintf("%s avg %s p95 %s", af.SpanName, af.Average.String(), af.P95.String()),
+			value:  g.AverageTimePerTrace(af),
+			color:  colorMemo[af.ServiceName],
+			parent: parent,
+		}
+		for _, child := range af.Children {
+			frame.children = append(frame.children, newFrame(child, frame))
+		}
+		return frame
+	}
+	for _, af := range g.Roots {
+		frame := newFrame(af, root)
+		root.value += frame.value
+		root.children = append(root.children, frame)
+	}
+
+	return root
+}
+
+// layoutFrames places the frames under the zoomed frame, which fills the width. The children are
+// placed side by side from the left of the parent and sized by the ratio of their value to the
+// value of the parent. If the children exceed the parent (e.g. parallel children), they are
+// scaled down to fit in the parent. The frames which don't reach the next cell are omitted.
+func (f *flameGraph) layoutFrames(width int) []flameRect {
+	rects := []flameRect{}
+	if f.zoom == nil {
+		return rects
+	}
+
+	var place func(frame *flameFrame, depth int, x, w float64)
+	place = func(frame *flameFrame, depth int, x, w float64) {
+		s, e := int(x), int(x+w)
+		if e <= s {
+			return
+		}
+		rects = append(rects, flameRect{frame: frame, depth: depth, x: s, width: e - s})
+
+		var total time.Duration
+		for _, child := range frame.children {
+			total += child.value
+		}
+		total = max(total, frame.value)
+		if total <= 0 {
+			return
+		}
+		cx := x
+		for _, child := range frame.children {
+			cw := w * float64(child.value) / float64(total)
+			place(child, depth+1, cx, cw)
+			cx += cw
+		}
+	}
+	place(f.zoom, 0, 0, float64(width))
+
+	return rects
+}
+
+func (f *flameGraph) draw(screen tcell.Screen, x, y, width, height int) (int, int, int, int) {
+	// inside the border
+	x, y, width, height = x+1, y+1, width-2, height-2
+	f.width = width
+	if f.root == nil || width <= 0 || height <= 0 {
+		return x, y, width, height
+	}
+
+	tview.Print(screen, tview.Escape(f.breadcrumb()), x, y, width, tview.AlignLeft, tcell.ColorYellow)
+
+	rows := height - 1
+	for _, r := range f.layoutFrames(width) {
+		if r.depth >= rows {
+			continue
+		}
+		ry := y + 1 + r.depth
+		if f.isFlame {
+			ry = y + height - 1 - r.depth
+		}
+		style := tcell.StyleDefault.Background(r.frame.color).Foreground(tcell.ColorBlack)
+		if r.frame == f.selected {
+			style = style.Reverse(true)
+		}
+		// leave a gap between the adjacent frames
+		fw := r.width
+		if fw > 2 {
+			fw--
+		}
+		label := []rune(r.frame.label)
+		for i := range fw {
+			ch := ' '
+			if i < len(label) {
+				ch = label[i]
+			}
+			screen.SetContent(x+r.x+i, ry, ch, nil, style)
+		}
+	}
+
+	return x, y, width, height
+}
+
+// breadcrumb returns the path from the frame of the whole trace to the zoomed frame
+func (f *flameGraph) breadcrumb() string {
+	path := []string{}
+	for frame := f.zoom; frame != nil; frame = frame.parent {
+		path = append([]string{frame.name}, path...)
+	}
+	return strings.Join(path, " > ")
+}
+
+func (f *flameGraph) getCurrentSpan() *telemetry.SpanData {
+	if f.selected == nil {
+		return nil
+	}
+	return f.selected.span
+}
+
+func (f *flameGraph) selectFrame(frame *flameFrame) {
+	if frame == nil || frame == f.selected {
+		return
+	}
+	f.selected = frame
+	if frame.span != nil {
+		f.detail.update(frame.span)
+		f.logPane.updateLog(
+			frame.span.Span.TraceID().String(),
+			frame.span.Span.SpanID().String(),
+		)
+	}
+}
+
+// moveHorizontally selects the next frame at the same depth in the direction
+func (f *flameGraph) moveHorizontally(direction int) {
+	rects := f.layoutFrames(f.width)
+	depth := -1
+	for _, r := range rects {
+		if r.frame == f.selected {
+			depth = r.depth
+			break
+		}
+	}
+	same := []*flameFrame{}
+	idx := -1
+	for _, r := range rects {
+		if r.depth != depth {
+			continue
+		}
+		if r.frame == f.selected {
+			idx = len(same)
+		}
+		same = append(same, r.frame)
+	}
+	if idx < 0 || idx+direction < 0 || idx+direction >= len(same) {
+		return
+	}
+	f.selectFrame(same[idx+direction])
+}
+
+// moveToChild selects the first child of the selected frame shown in the graph
+func (f *flameGraph) moveToChild() {
+	for _, r := range f.layoutFrames(f.width) {
+		if r.frame.parent == f.selected && r.frame.parent != nil {
+			f.selectFrame(r.frame)
+			return
+		}
+	}
+}
+
+// moveToParent selects the parent of the selected frame up to the zoomed frame
+func (f *flameGraph) moveToParent() {
+	if f.selected == f.zoom || f.selected.parent == nil {
+		return
+	}
+	f.selectFrame(f.selected.parent)
+}
+
+func (f *flameGraph) zoomIn() {
+	if f.selected == nil || f.selected == f.zoom {
+		return
+	}
+	f.zoom = f.selected
+	f.updateTitle()
+}
+
+func (f *flameGraph) zoomOut() {
+	if f.zoom == nil || f.zoom.parent == nil {
+		return
+	}
+	f.zoom = f.zoom.parent
+	f.updateTitle()
+}
+
+// toggleAggregated switches the frames between the spans of the trace and the spans merged from
+// the traces with the same root span name as the trace
+func (f *flameGraph) toggleAggregated() {
+	if f.aggregated {
+		f.aggregated = false
+		f.setFrames(newFlameFrames(f.tree, f.duration))
+		return
+	}
+
+	name := ""
+	for _, node := range f.tree {
+		if node.span.Span.ParentSpanID().IsEmpty() {
+			name = node.span.Span.Name()
+			break
+		}
+	}
+	if name == "" && len(f.tree) > 0 {
+		name = f.tree[0].span.Span.Name()
+	}
+	if name == "" {
+		return
+	}
+	g := telemetry.NewAggregatedFlameGraph(name, f.tcache.GetTracesByRootSpanName(name))
+	f.aggregated = true
+	f.setFrames(newAggregatedFlameFrames(g))
+}
+
+func (f *flameGraph) toggleFlame() {
+	f.isFlame = !f.isFlame
+	f.updateTitle()
+}
+
+func (f *flameGraph) updateTitle() {
+	mode := "icicle"
+	if f.isFlame {
+		mode = "flame"
+	}
+	if f.aggregated {
+		mode += ", aggregated"
+	}
+	zoom := ""
+	if f.zoom != nil && f.zoom.parent != nil {
+		zoom = " (zoomed)"
+	}
+	f.view.SetTitle(fmt.Sprintf("Flame Graph (t) -- %s%s", mode, zoom))
+}
+
+func (f *flameGraph) updateCommands() {
+	keyMaps := layout.KeyMaps{
+		{
+			Key:         tcell.NewEventKey(tcell.KeyUp, ' ', tcell.ModNone),
+			Arrow:       true,
+			Description: "Move selection",
+			Handler: func(event *tcell.EventKey) *tcell.EventKey {
+				if f.isFlame {
+					f.moveToChild()
+				} else {
+					f.moveToParent()
+				}
+				return nil
+			},
+		},
+		{
+			Key: tcell.NewEventKey(tcell.KeyDown, ' ', tcell.ModNone),
+			Handler: func(event *tcell.EventKey) *tcell.EventKey {
+				if f.isFlame {
+					f.moveToParent()
+				} else {
+					f.moveToChild()
+				}
+				return nil
+			},
+		},
+		{
+			Key: tcell.NewEventKey(tcell.KeyLeft, ' ', tcell.ModNone),
+			Handler: func(event *tcell.EventKey) *tcell.EventKey {
+				f.moveHorizontally(-1)
+				return nil
+			},
+		},
+		{
+			Key: tcell.NewEventKey(tcell.KeyRight, ' ', tcell.ModNone),
+			Handler: func(event *tcell.EventKey) *tcell.EventKey {
+				f.moveHorizontally(1)
+				return nil
+			},
+		},
+		{
+			Key:         tcell.NewEventKey(tcell.KeyEnter, ' ', tcell.ModNone),
+			Description: "Zoom into the span",
+			Handler: func(event *tcell.EventKey) *tcell.EventKey {
+				f.zoomIn()
+				return nil
+			},
+		},
+		{
+			Key:         tcell.NewEventKey(tcell.KeyRune, 'u', tcell.ModNone),
+			Description: "Zoom out",
+			Handler: func(event *tcell.EventKey) *tcell.EventKey {
+				f.zoomOut()
+				return nil
+			},
+		},
+		{
+			Key:         tcell.NewEventKey(tcell.KeyRune, 'a', tcell.ModNone),
+			Description: "Toggle aggregated by root span",
+			Handler: func(event *tcell.EventKey) *tcell.EventKey {
+				f.toggleAggregated()
+				return nil
+			},
+		},
+		{
+			Key:         tcell.NewEventKey(tcell.KeyRune, 'i', tcell.ModNone),
+			Description: "Toggle icicle or flame",
+			Handler: func(event *tcell.EventKey) *tcell.EventKey {
+				f.toggleFlame()
+				return nil
+			},
+		},
+	}
+	keyMaps.Merge(f.exportKeyMaps)
+	keyMaps.Merge(f.resizeManager.KeyMaps())
+	layout.RegisterCommandList(f.commands, f.view, nil, keyMaps)
+}
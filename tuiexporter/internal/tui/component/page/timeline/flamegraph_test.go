@@ -0,0 +1,130 @@
+package timeline
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/telemetry"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/test"
+	"gotest.tools/v3/assert"
+)
+
+func TestFlameGraph(t *testing.T) {
+	// traceid: 1
+	//  └- resource: test-service-1
+	//    └- scope: test-scope-1-1
+	//      └- span: span-1-1-1 [root]
+	//        └- span: span-1-1-2
+	//          └- span: span-1-1-3
+	//        └- span: span-1-1-4
+	//      └- span: span-1-1-5 [root]
+	//        └- span: span-1-1-6
+	store := telemetry.NewStore(clockwork.NewRealClock())
+	payload, testdata := test.GenerateOTLPTracesPayload(t, 1, 1, []int{1}, [][]int{{6}})
+	testdata.Spans[0].SetParentSpanID([8]byte{byte(0)}) // root span
+	testdata.Spans[1].SetParentSpanID(testdata.Spans[0].SpanID())
+	testdata.Spans[2].SetParentSpanID(testdata.Spans[1].SpanID())
+	testdata.Spans[3].SetParentSpanID(testdata.Spans[0].SpanID())
+	testdata.Spans[4].SetParentSpanID([8]byte{byte(0)}) // root span
+	testdata.Spans[5].SetParentSpanID(testdata.Spans[4].SpanID())
+
+	store.AddSpan(&payload)
+
+	grid := newGrid(nil, store.GetTraceCache(), nil, nil, nil, nil)
+	st, d := grid.newSpanTree(testdata.Spans[0].TraceID().String())
+
+	t.Run("frames", func(t *testing.T) {
+		root := newFlameFrames(st, d)
+
+		assert.Equal(t, "all spans 200ms", root.label)
+		assert.Equal(t, 200*time.Millisecond, root.value)
+		assert.Equal(t, true, root.span == nil)
+		assert.Equal(t, 2, len(root.children))
+		assert.Equal(t, *testdata.Spans[0], *root.children[0].span.Span)
+		assert.Equal(t, 200*time.Millisecond, root.children[0].value)
+		assert.Equal(t, st[0].color, root.children[0].color)
+		assert.Equal(t, root, root.children[0].parent)
+		assert.Equal(t, *testdata.Spans[2], *root.children[0].children[0].children[0].span.Span)
+		assert.Equal(t, *testdata.Spans[5], *root.children[1].children[0].span.Span)
+	})
+
+	t.Run("layout and zoom", func(t *testing.T) {
+		fg := newFlameGraph(nil, store.GetTraceCache(), nil, nil, nil, nil)
+		fg.root = newFlameFrames(st, d)
+		fg.zoom = fg.root
+		fg.selected = fg.root
+
+		// the parallel children exceeding the parent are scaled down (label@depth:x+width)
+		root := fg.root
+		assert.DeepEqual(t, []string{
+			"all spans 200ms@0:0+100",
+			"span-0-0-0 200ms@1:0+50",
+			"span-0-0-1 200ms@2:0+25",
+			"span-0-0-2 200ms@3:0+25",
+			"span-0-0-3 200ms@2:25+25",
+			"span-0-0-4 200ms@1:50+50",
+			"span-0-0-5 200ms@2:50+50",
+		}, flameRectTexts(fg.layoutFrames(100)))
+		assert.Equal(t, "all spans", fg.breadcrumb())
+
+		fg.selected = root.children[0]
+		fg.zoomIn()
+
+		assert.DeepEqual(t, []string{
+			"span-0-0-0 200ms@0:0+100",
+			"span-0-0-1 200ms@1:0+50",
+			"span-0-0-2 200ms@2:0+50",
+			"span-0-0-3 200ms@1:50+50",
+		}, flameRectTexts(fg.layoutFrames(100)))
+		assert.Equal(t, "all spans > test-service-1: span-0-0-0", fg.breadcrumb())
+		assert.Equal(t, "Flame Graph (t) -- icicle (zoomed)", fg.view.GetTitle())
+
+		fg.zoomOut()
+
+		assert.Equal(t, root, fg.zoom)
+		assert.Equal(t, "Flame Graph (t) -- icicle", fg.view.GetTitle())
+
+		// the root can't be zoomed out
+		fg.zoomOut()
+
+		assert.Equal(t, root, fg.zoom)
+	})
+
+	t.Run("aggregated", func(t *testing.T) {
+		fg := newFlameGraph(nil, store.GetTraceCache(), nil, nil, nil, nil)
+		fg.tree = st
+		fg.duration = d
+
+		fg.toggleAggregated()
+
+		assert.Equal(t, true, fg.aggregated)
+		assert.Equal(t, "1 traces of span-0-0-0", fg.root.label)
+		assert.Equal(t, "all traces of span-0-0-0", fg.breadcrumb())
+		assert.Equal(t, "Flame Graph (t) -- icicle, aggregated", fg.view.GetTitle())
+		assert.DeepEqual(t, []string{
+			"1 traces of span-0-0-0@0:0+100",
+			"span-0-0-0 avg 200ms p95 200ms@1:0+100",
+			"span-0-0-1 avg 200ms p95 200ms@2:0+50",
+			"span-0-0-2 avg 200ms p95 200ms@3:0+50",
+			"span-0-0-3 avg 200ms p95 200ms@2:50+50",
+		}, flameRectTexts(fg.layoutFrames(100)))
+		assert.Equal(t, fg.root.children[0], fg.selected)
+		assert.Equal(t, true, fg.getCurrentSpan() == nil)
+
+		fg.toggleAggregated()
+
+		assert.Equal(t, false, fg.aggregated)
+		assert.Equal(t, "all spans", fg.breadcrumb())
+		assert.Equal(t, *testdata.Spans[0], *fg.getCurrentSpan().Span)
+	})
+}
+
+func flameRectTexts(rects []flameRect) []string {
+	texts := []string{}
+	for _, r := range rects {
+		texts = append(texts, fmt.Sprintf("%s@%d:%d+%d", r.frame.label, r.depth, r.x, r.width))
+	}
+	return texts
+}
@@ -25,28 +25,36 @@ type spanTreeNode struct {
 	box      *tview.Box
 	children []*spanTreeNode
 	expand   bool
+	// color is assigned by the service name
+	color tcell.Color
+	// critical is set if the span is on the critical path
+	critical    *telemetry.CriticalPathSpan
+	criticalBox *tview.Box
 }
 
 type grid struct {
-	commands      *tview.TextView
-	gridView      *tview.Grid
-	tcache        *telemetry.TraceCache
-	snameWidth    int
-	totalRow      int
-	currentRow    int
-	tree          []*spanTreeNode
-	duration      time.Duration
-	nodes         []*spanTreeNode
-	items         []*tview.TextView
-	resizeManager *layout.ResizeManager
-	detail        *detail
-	logPane       *logPane
+	commands         *tview.TextView
+	gridView         *tview.Grid
+	tcache           *telemetry.TraceCache
+	snameWidth       int
+	totalRow         int
+	currentRow       int
+	tree             []*spanTreeNode
+	duration         time.Duration
+	nodes            []*spanTreeNode
+	items            []*tview.TextView
+	resizeManager    *layout.ResizeManager
+	exportKeyMaps    layout.KeyMaps
+	detail           *detail
+	logPane          *logPane
+	showCriticalPath bool
 }
 
 func newGrid(
 	commands *tview.TextView,
 	tcache *telemetry.TraceCache,
 	resizeManager *layout.ResizeManager,
+	exportKeyMaps layout.KeyMaps,
 	detail *detail,
 	logPane *logPane,
 ) *grid {
@@ -66,6 +74,7 @@ func newGrid(
 		nodes:         []*spanTreeNode{},
 		items:         []*tview.TextView{},
 		resizeManager: resizeManager,
+		exportKeyMaps: exportKeyMaps,
 		detail:        detail,
 		logPane:       logPane,
 	}
@@ -156,6 +165,11 @@ func (g *grid) placeSpan(
 ) int {
 	row++
 	label := node.label
+	box := node.box
+	if g.showCriticalPath && node.critical != nil {
+		label = fmt.Sprintf("* %s (self %s)", label, node.critical.SelfTime.String())
+		box = node.criticalBox
+	}
 	prefix := ""
 	for i := range depth {
 		if i == depth-1 {
@@ -176,7 +190,7 @@ func (g *grid) placeSpan(
 	*tvs = append(*tvs, tv)
 	*nodes = append(*nodes, node)
 	g.gridView.AddItem(tv, row, 0, 1, 1, 0, 0, false)
-	g.gridView.AddItem(node.box, row, 1, 1, 1, 0, 0, false)
+	g.gridView.AddItem(box, row, 1, 1, 1, 0, 0, false)
 	if !node.expand {
 		return row
 	}
@@ -200,13 +214,9 @@ func (g *grid) newSpanTree(traceID string) (rootNodes []*spanTreeNode, duration
 	start := time.Now().Add(time.Hour * 24)
 	end := time.Time{}
 
-	// store memo and calculate start and end time of the trace
-	spanMemo := make(map[string]int)
+	// calculate start and end time of the trace
 	colorMemo := make(map[string]tcell.Color)
-	nodes := []*spanTreeNode{}
-	for idx, span := range spans {
-		nodes = append(nodes, &spanTreeNode{span: span, expand: true})
-		spanMemo[span.Span.SpanID().String()] = idx
+	for _, span := range spans {
 		if span.Span.StartTimestamp().AsTime().Before(start) {
 			start = span.Span.StartTimestamp().AsTime()
 		}
@@ -221,36 +231,34 @@ func (g *grid) newSpanTree(traceID string) (rootNodes []*spanTreeNode, duration
 	}
 	duration = end.Sub(start)
 
+	criticalPath := telemetry.NewCriticalPath(traceID, spans)
+
 	// generate span tree
-	for _, span := range spans {
-		current := span.Span.SpanID().String()
-		node := nodes[spanMemo[current]]
+	var newNode func(tn *telemetry.SpanTreeNode) *spanTreeNode
+	newNode = func(tn *telemetry.SpanTreeNode) *spanTreeNode {
+		span := tn.Span
 		sname := telemetry.GetServiceNameFromResource(span.ResourceSpan.Resource())
+		node := &spanTreeNode{span: span, expand: true, color: colorMemo[sname]}
 		st, en := span.Span.StartTimestamp().AsTime().Sub(start), span.Span.EndTimestamp().AsTime().Sub(start)
 		d := en - st
-		node.box = createSpan(colorMemo[sname], duration, st, en)
+		node.box = createSpan(colorMemo[sname], duration, st, en, tview.BlockMediumShade)
 		if span.Span.Status().Code() == ptrace.StatusCodeError {
 			node.label = fmt.Sprintf("[!] %s %s", span.Span.Name(), d.String())
 		} else {
 			node.label = fmt.Sprintf("%s %s", span.Span.Name(), d.String())
 		}
-
-		parent := span.Span.ParentSpanID().String()
-		_, parentExists := g.tcache.GetSpanByID(parent)
-		if !parentExists {
-			rootNodes = append(rootNodes, node)
-			continue
+		if cs, ok := criticalPath.Get(span.Span.SpanID().String()); ok {
+			node.critical = &cs
+			node.criticalBox = createSpan(colorMemo[sname], duration, st, en, tview.BlockFullBlock)
 		}
-		parentIdx := spanMemo[parent]
-		nodes[parentIdx].children = append(nodes[parentIdx].children, nodes[spanMemo[span.Span.SpanID().String()]])
+		for _, child := range tn.Children {
+			node.children = append(node.children, newNode(child))
+		}
+		return node
+	}
+	for _, root := range telemetry.NewSpanTree(spans) {
+		rootNodes = append(rootNodes, newNode(root))
 	}
-
-	// sort root spans by start time
-	sort.SliceStable(rootNodes, func(i, j int) bool {
-		return rootNodes[i].span.Span.StartTimestamp().AsTime().Before(
-			rootNodes[j].span.Span.StartTimestamp().AsTime(),
-		)
-	})
 
 	return rootNodes, duration
 }
@@ -322,6 +330,17 @@ func (g *grid) updateCommands() {
 				return nil
 			},
 		},
+		{
+			Key:         tcell.NewEventKey(tcell.KeyRune, 'c', tcell.ModNone),
+			Description: "Toggle critical path",
+			Handler: func(event *tcell.EventKey) *tcell.EventKey {
+				g.showCriticalPath = !g.showCriticalPath
+
+				g.placeSpans()
+
+				return nil
+			},
+		},
 		{
 			Key:         tcell.NewEventKey(tcell.KeyRight, ' ', tcell.ModNone),
 			Description: "Widen span name column",
@@ -342,6 +361,7 @@ func (g *grid) updateCommands() {
 			},
 		},
 	}
+	keyMaps.Merge(g.exportKeyMaps)
 	keyMaps.Merge(g.resizeManager.KeyMaps())
 	layout.RegisterCommandList(g.commands, g.gridView, func() {
 		if g.getCurrentSpan() != nil {
@@ -357,7 +377,7 @@ func (g *grid) getCurrentSpan() *telemetry.SpanData {
 	return g.nodes[g.currentRow].span
 }
 
-func createSpan(color tcell.Color, total, start, end time.Duration) (span *tview.Box) {
+func createSpan(color tcell.Color, total, start, end time.Duration, block rune) (span *tview.Box) {
 	return tview.NewBox().SetBorder(false).
 		SetDrawFunc(func(screen tcell.Screen, x, y, width, height int) (int, int, int, int) {
 			// Draw a horizontal line across the middle of the box.
@@ -370,7 +390,7 @@ func createSpan(color tcell.Color, total, start, end time.Duration) (span *tview
 				screen.SetContent(s, centerY, tview.BoxDrawingsHeavyVertical, nil, tcell.StyleDefault.Foreground(color))
 			} else {
 				for cx := s; cx < e; cx++ {
-					screen.SetContent(cx, centerY, tview.BlockMediumShade, nil, tcell.StyleDefault.Foreground(color))
+					screen.SetContent(cx, centerY, block, nil, tcell.StyleDefault.Foreground(color))
 				}
 			}
 
@@ -39,7 +39,7 @@ func TestNewSpanTreeWithServiceName(t *testing.T) {
 
 	store.AddSpan(&payload)
 
-	grid := newGrid(nil, store.GetTraceCache(), nil, nil, nil)
+	grid := newGrid(nil, store.GetTraceCache(), nil, nil, nil, nil)
 	st, d := grid.newSpanTree(testdata.Spans[0].TraceID().String())
 
 	// duration assertion
@@ -53,6 +53,13 @@ func TestNewSpanTreeWithServiceName(t *testing.T) {
 	assert.Equal(t, *sds[3].Span, *st[0].children[1].span.Span)
 	assert.Equal(t, *sds[4].Span, *st[1].span.Span)
 	assert.Equal(t, *sds[5].Span, *st[1].children[0].span.Span)
+
+	// critical path assertion (all spans have the same start and end time)
+	assert.Equal(t, true, st[0].critical != nil)
+	assert.Equal(t, true, st[0].children[0].critical != nil)
+	assert.Equal(t, true, st[0].children[0].children[0].critical != nil)
+	assert.Equal(t, true, st[0].children[1].critical == nil)
+	assert.Equal(t, true, st[1].critical == nil)
 }
 
 func TestNewSpanTreeWithoutServiceName(t *testing.T) {
@@ -85,7 +92,7 @@ func TestNewSpanTreeWithoutServiceName(t *testing.T) {
 
 	store.AddSpan(&payload)
 
-	grid := newGrid(nil, store.GetTraceCache(), nil, nil, nil)
+	grid := newGrid(nil, store.GetTraceCache(), nil, nil, nil, nil)
 	st, d := grid.newSpanTree(testdata.Spans[0].TraceID().String())
 
 	// duration assertion
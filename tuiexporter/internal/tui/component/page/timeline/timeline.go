@@ -4,6 +4,7 @@ import (
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/telemetry"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/export"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/layout"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/navigation"
 )
@@ -19,12 +20,18 @@ type TimelinePage struct {
 	base           *tview.Flex
 	container      *tview.Flex
 	mainContainer  *tview.Flex
+	spanContainer  *tview.Flex
 	store          *telemetry.Store
 	onEscape       func()
 	detail         *detail
 	grid           *grid
+	flameGraph     *flameGraph
 	logPane        *logPane
+	breakdownPane  *breakdownPane
+	exportKeyMaps  layout.KeyMaps
 	isLogCollapsed bool
+	showBreakdown  bool
+	showFlameGraph bool
 	traceID        string
 }
 
@@ -32,41 +39,55 @@ func NewTimelinePage(
 	switchToPageFn func(),
 	store *telemetry.Store,
 	onEscape func(),
+	onFilterByAttribute func(attr layout.Attribute),
 ) *TimelinePage {
 	commands := layout.NewCommandList()
 	base := tview.NewFlex().SetDirection(tview.FlexRow)
 	container := tview.NewFlex().SetDirection(tview.FlexRow)
 	container.SetBorder(false)
 	mainContainer := tview.NewFlex().SetDirection(tview.FlexColumn)
+	// spanContainer shows the grid or the flame graph of the spans
+	spanContainer := tview.NewFlex().SetDirection(tview.FlexRow)
 
 	base.AddItem(container, 0, 1, true)
 
+	var timeline *TimelinePage
+	// the export keys are also shown in the command list of the grid and the flame graph
+	exportKeyMaps := export.TraceKeyMaps(commands, store, func() string { return timeline.traceID })
+
 	resizeManager := layout.NewResizeManager(layout.ResizeDirectionHorizontal)
-	detail := newDetail(commands, resizeManager)
+	detail := newDetail(commands, onFilterByAttribute, resizeManager)
 	logPane := newLogPane(commands, store.GetLogCache())
-	grid := newGrid(commands, store.GetTraceCache(), resizeManager, detail, logPane)
+	grid := newGrid(commands, store.GetTraceCache(), resizeManager, exportKeyMaps, detail, logPane)
+	flameGraph := newFlameGraph(commands, store.GetTraceCache(), resizeManager, exportKeyMaps, detail, logPane)
+
+	spanContainer.AddItem(grid.gridView, 0, 1, true)
 
 	resizeManager.Register(
 		mainContainer,
-		grid.gridView,
+		spanContainer,
 		detail.view,
 		defaultGridProportion,
 		defaultDetailProportion,
 		commands,
 	)
 
-	timeline := &TimelinePage{
+	timeline = &TimelinePage{
 		switchToPageFn: switchToPageFn,
 		commands:       commands,
 		base:           base,
 		container:      container,
 		mainContainer:  mainContainer,
+		spanContainer:  spanContainer,
 		store:          store,
 		onEscape:       onEscape,
 		detail:         detail,
 		grid:           grid,
+		flameGraph:     flameGraph,
 		logPane:        logPane,
+		breakdownPane:  newBreakdownPane(commands),
 		isLogCollapsed: true,
+		exportKeyMaps:  exportKeyMaps,
 	}
 
 	timeline.updateContainer()
@@ -81,12 +102,6 @@ func (p *TimelinePage) GetPrimitive() tview.Primitive {
 	return p.base
 }
 
-func (p *TimelinePage) ShowTimelineByRow(row int) {
-	p.DrawTimeline(
-		p.store.GetTraceIDByFilteredIdx(row),
-	)
-}
-
 func (p *TimelinePage) DrawTimeline(traceID string) {
 	p.traceID = traceID
 
@@ -94,13 +109,18 @@ func (p *TimelinePage) DrawTimeline(traceID string) {
 	p.mainContainer.Clear()
 
 	span := p.grid.updateGrid(traceID)
+	p.flameGraph.update(p.grid.tree, p.grid.duration)
+	spans, _ := p.store.SpansByTraceID(traceID)
+	breakdown := telemetry.NewTraceBreakdown(traceID, spans)
+	p.detail.setBreakdown(breakdown)
 	p.detail.update(span)
+	p.breakdownPane.update(breakdown)
 	p.logPane.updateLog(traceID, span.Span.SpanID().String())
 
 	p.updateContainer()
 
 	p.switchToPageFn()
-	navigation.Focus(p.grid.gridView)
+	navigation.Focus(p.getSpanView())
 }
 
 func (p *TimelinePage) registerCommands() {
@@ -115,7 +135,7 @@ func (p *TimelinePage) registerCommands() {
 		{
 			Key: tcell.NewEventKey(tcell.KeyRune, 't', tcell.ModNone),
 			Handler: func(event *tcell.EventKey) *tcell.EventKey {
-				navigation.Focus(p.grid.gridView)
+				navigation.Focus(p.getSpanView())
 				return nil
 			},
 		},
@@ -130,20 +150,36 @@ func (p *TimelinePage) registerCommands() {
 			Key: tcell.NewEventKey(tcell.KeyRune, 'L', tcell.ModNone),
 			Handler: func(event *tcell.EventKey) *tcell.EventKey {
 				p.isLogCollapsed = !p.isLogCollapsed
-				logHeight := 10
-				if p.isLogCollapsed {
-					logHeight = 2
+				p.updateBottomPanes()
+
+				return nil
+			},
+		},
+		{
+			Key: tcell.NewEventKey(tcell.KeyRune, 'b', tcell.ModNone),
+			Handler: func(event *tcell.EventKey) *tcell.EventKey {
+				p.showBreakdown = !p.showBreakdown
+				p.updateBottomPanes()
+				if p.showBreakdown {
+					navigation.Focus(p.breakdownPane.tableView)
+				} else if !p.mainContainer.HasFocus() && !p.logPane.tableView.HasFocus() {
+					navigation.Focus(p.getSpanView())
 				}
-				p.container.Clear().AddItem(p.mainContainer, 0, 1, p.mainContainer.HasFocus()).
-					AddItem(p.logPane.tableView, logHeight, 1, p.logPane.tableView.HasFocus())
 
 				return nil
 			},
 		},
+		{
+			Key: tcell.NewEventKey(tcell.KeyRune, 'F', tcell.ModNone),
+			Handler: func(event *tcell.EventKey) *tcell.EventKey {
+				p.toggleFlameGraph()
+				return nil
+			},
+		},
 		{
 			Key: tcell.NewEventKey(tcell.KeyRune, 'A', tcell.ModNone),
 			Handler: func(event *tcell.EventKey) *tcell.EventKey {
-				p.logPane.toggleAllLogs(p.traceID, p.grid.getCurrentSpan())
+				p.logPane.toggleAllLogs(p.traceID, p.getCurrentSpan())
 				return nil
 			},
 		},
@@ -155,12 +191,56 @@ func (p *TimelinePage) registerCommands() {
 			},
 		},
 	}
+	keyMaps.Merge(p.exportKeyMaps)
 	layout.RegisterCommandList(p.commands, p.container, nil, keyMaps)
 }
 
+// toggleFlameGraph switches the view of the spans between the grid and the flame graph
+func (p *TimelinePage) toggleFlameGraph() {
+	p.showFlameGraph = !p.showFlameGraph
+
+	view := p.getSpanView()
+	p.spanContainer.Clear().AddItem(view, 0, 1, true)
+	if span := p.getCurrentSpan(); span != nil {
+		p.detail.update(span)
+		p.logPane.updateLog(p.traceID, span.Span.SpanID().String())
+	}
+	navigation.Focus(view)
+}
+
+func (p *TimelinePage) getSpanView() tview.Primitive {
+	if p.showFlameGraph {
+		return p.flameGraph.view
+	}
+	return p.grid.gridView
+}
+
+func (p *TimelinePage) getCurrentSpan() *telemetry.SpanData {
+	if p.showFlameGraph {
+		return p.flameGraph.getCurrentSpan()
+	}
+	return p.grid.getCurrentSpan()
+}
+
+// updateBottomPanes lays out the breakdown pane and the log pane under the grid
+func (p *TimelinePage) updateBottomPanes() {
+	logHeight := 10
+	if p.isLogCollapsed {
+		logHeight = 2
+	}
+	p.container.Clear().AddItem(p.mainContainer, 0, 1, p.mainContainer.HasFocus())
+	if p.showBreakdown {
+		p.container.AddItem(p.breakdownPane.tableView, breakdownPaneHeight, 1, p.breakdownPane.tableView.HasFocus())
+	}
+	p.container.AddItem(p.logPane.tableView, logHeight, 1, p.logPane.tableView.HasFocus())
+}
+
 func (p *TimelinePage) updateContainer() {
-	p.mainContainer.AddItem(p.grid.gridView, 0, defaultGridProportion, true).
+	p.mainContainer.AddItem(p.spanContainer, 0, defaultGridProportion, true).
 		AddItem(p.detail.view, 0, defaultDetailProportion, false)
-	p.container.AddItem(p.mainContainer, 0, 1, true).
-		AddItem(p.logPane.tableView, 2, 1, false)
+	p.container.AddItem(p.mainContainer, 0, 1, true)
+	if p.showBreakdown {
+		p.container.AddItem(p.breakdownPane.tableView, breakdownPaneHeight, 1, false)
+	}
+	p.container.AddItem(p.logPane.tableView, 2, 1, false)
 }
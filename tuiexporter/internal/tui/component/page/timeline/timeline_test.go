@@ -39,7 +39,7 @@ func setupTimelinePage(t *testing.T) (*mockTimelineHandler, *TimelinePage, tcell
 	}
 	screen.SetSize(sw, sh)
 
-	page := NewTimelinePage(mockHandler.switchToPageHandler, store, mockHandler.onEscapeHandler)
+	page := NewTimelinePage(mockHandler.switchToPageHandler, store, mockHandler.onEscapeHandler, nil)
 	page.base.Focus(func(p tview.Primitive) {
 		page.container.Focus(func(p tview.Primitive) {
 			page.mainContainer.Focus(func(p tview.Primitive) {
@@ -144,6 +144,82 @@ func TestTimelinePage(t *testing.T) {
 				assert.Equal(t, want, got.String())
 			})
 
+			t.Run("toggle breakdown pane", func(t *testing.T) {
+				mockHandler, page, _, store := setupTimelinePage(t)
+
+				payload, spans := test.GenerateOTLPTracesPayload(t, 1, 1, []int{1}, [][]int{{3}})
+				store.AddSpan(&payload)
+
+				mockHandler.On("switchToPageHandler").Return().Once()
+
+				page.DrawTimeline(spans.Spans[0].TraceID().String())
+				page.grid.gridView.Focus(nil)
+
+				handler := page.base.InputHandler()
+				handler(tcell.NewEventKey(tcell.KeyRune, 'b', tcell.ModNone), nil)
+
+				assert.Equal(t, true, page.showBreakdown)
+				assert.Equal(t, 3, page.container.GetItemCount())
+				bt := page.breakdownPane.tableView
+				assert.Equal(t, "test-service-1", bt.GetCell(1, 0).Text)
+				assert.Equal(t, "3", bt.GetCell(1, 1).Text)
+				assert.Equal(t, "600ms", bt.GetCell(1, 2).Text)
+				assert.Equal(t, "100.0%", bt.GetCell(1, 3).Text)
+
+				page.breakdownPane.toggleBySpanName()
+
+				assert.Equal(t, 4, bt.GetRowCount())
+				assert.Equal(t, "span-0-0-0", bt.GetCell(1, 1).Text)
+				assert.Equal(t, "200ms", bt.GetCell(1, 3).Text)
+
+				handler(tcell.NewEventKey(tcell.KeyRune, 'b', tcell.ModNone), nil)
+
+				assert.Equal(t, false, page.showBreakdown)
+				assert.Equal(t, 2, page.container.GetItemCount())
+			})
+
+			t.Run("toggle flame graph", func(t *testing.T) {
+				mockHandler, page, screen, store := setupTimelinePage(t)
+
+				payload, spans := test.GenerateOTLPTracesPayload(t, 1, 1, []int{1}, [][]int{{3}})
+				store.AddSpan(&payload)
+
+				mockHandler.On("switchToPageHandler").Return().Once()
+
+				page.DrawTimeline(spans.Spans[0].TraceID().String())
+				page.grid.gridView.Focus(nil)
+
+				handler := page.base.InputHandler()
+				handler(tcell.NewEventKey(tcell.KeyRune, 'F', tcell.ModNone), nil)
+
+				assert.Equal(t, true, page.showFlameGraph)
+				assert.Equal(t, page.flameGraph.view, page.spanContainer.GetItem(0))
+				assert.Equal(t, "span-0-0-0", page.getCurrentSpan().GetSpanName())
+
+				page.grid.gridView.Blur()
+				page.flameGraph.view.Focus(nil)
+				page.base.Draw(screen)
+				screen.Sync()
+
+				handler(tcell.NewEventKey(tcell.KeyRight, ' ', tcell.ModNone), nil)
+
+				assert.Equal(t, "span-0-0-1", page.getCurrentSpan().GetSpanName())
+
+				handler(tcell.NewEventKey(tcell.KeyEnter, ' ', tcell.ModNone), nil)
+
+				assert.Equal(t, "all spans > test-service-1: span-0-0-1", page.flameGraph.breadcrumb())
+
+				handler(tcell.NewEventKey(tcell.KeyRune, 'u', tcell.ModNone), nil)
+
+				assert.Equal(t, "all spans", page.flameGraph.breadcrumb())
+
+				handler(tcell.NewEventKey(tcell.KeyRune, 'F', tcell.ModNone), nil)
+
+				assert.Equal(t, false, page.showFlameGraph)
+				assert.Equal(t, page.grid.gridView, page.spanContainer.GetItem(0))
+				assert.Equal(t, "span-0-0-0", page.getCurrentSpan().GetSpanName())
+			})
+
 			tests := []struct {
 				name            string
 				key             *tcell.EventKey
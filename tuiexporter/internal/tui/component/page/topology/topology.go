@@ -1,21 +1,54 @@
 package topology
 
 import (
+	"fmt"
 	"log"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/telemetry"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/layout"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/navigation"
 )
 
+const (
+	defaultGraphProportion = 3
+	defaultStatsProportion = 2
+)
+
+type timeWindow struct {
+	label    string
+	duration time.Duration
+}
+
+// timeWindows are the periods of the spans in the graph, where zero means all spans
+var timeWindows = []timeWindow{
+	{label: "all time"},
+	{label: "last 1m", duration: time.Minute},
+	{label: "last 5m", duration: 5 * time.Minute},
+	{label: "last 15m", duration: 15 * time.Minute},
+	{label: "last 1h", duration: time.Hour},
+}
+
 type TopologyPage struct {
-	view  *tview.Flex
-	topo  *tview.TextView
-	cache *telemetry.TraceCache
+	view        *tview.Flex
+	topo        *tview.TextView
+	stats       *tview.Table
+	store       *telemetry.Store
+	onSelect    func(condition string)
+	topology    *telemetry.Topology
+	selected    string
+	focused     bool
+	collapsed   bool
+	windowIdx   int
+	refreshedAt time.Time
+	now         func() time.Time
 }
 
-func NewTopologyPage(cache *telemetry.TraceCache) *TopologyPage {
+func NewTopologyPage(store *telemetry.Store, onSelect func(condition string), statusFn func() string) *TopologyPage {
 	commands := layout.NewCommandList()
 	container := tview.NewFlex().SetDirection(tview.FlexRow)
 	container.SetBorder(false)
@@ -23,19 +56,31 @@ func NewTopologyPage(cache *telemetry.TraceCache) *TopologyPage {
 	topo := tview.NewTextView().
 		SetWrap(false).
 		SetRegions(false).
-		SetDynamicColors(false)
-	topo.SetBorder(true).SetTitle("Topology")
-	container.AddItem(topo, 0, 1, true)
+		SetDynamicColors(true)
+	topo.SetBorder(true)
+
+	stats := tview.NewTable().
+		SetBorders(false).
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	stats.SetBorder(true).SetTitle("Services and Calls (t)")
+
+	container.AddItem(topo, 0, defaultGraphProportion, true).
+		AddItem(stats, 0, defaultStatsProportion, false)
 
 	page := &TopologyPage{
-		view:  container,
-		topo:  topo,
-		cache: cache,
+		view:     container,
+		topo:     topo,
+		stats:    stats,
+		store:    store,
+		onSelect: onSelect,
+		now:      time.Now,
 	}
 
-	page.view = layout.AttachTab(layout.AttachCommandList(commands, container), layout.PageIDTraceTopology)
+	page.view = layout.AttachTab(layout.AttachCommandList(commands, container), layout.PageIDTraceTopology, statusFn)
 
-	page.registerCommands(commands)
+	page.registerCommands(commands, container)
+	page.updateTitle()
 
 	return page
 }
@@ -44,32 +89,325 @@ func (p *TopologyPage) GetPrimitive() tview.Primitive {
 	return p.view
 }
 
-func (p *TopologyPage) registerCommands(commands *tview.TextView) {
-	keyMaps := layout.KeyMaps{
+func (p *TopologyPage) registerCommands(commands *tview.TextView, container *tview.Flex) {
+	container.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Rune() {
+		case 'g':
+			navigation.Focus(p.topo)
+			return nil
+		case 't':
+			navigation.Focus(p.stats)
+			return nil
+		}
+		return event
+	})
+
+	reload := &layout.KeyMap{
+		Key:         tcell.NewEventKey(tcell.KeyCtrlR, ' ', tcell.ModNone),
+		Description: "Reload",
+		Handler: func(event *tcell.EventKey) *tcell.EventKey {
+			p.UpdateTopology()
+			return nil
+		},
+	}
+
+	layout.RegisterCommandList(commands, p.topo, nil, layout.KeyMaps{
 		{
-			Key:         tcell.NewEventKey(tcell.KeyCtrlR, ' ', tcell.ModNone),
-			Description: "Reload",
+			Key:         tcell.NewEventKey(tcell.KeyUp, ' ', tcell.ModNone),
+			Arrow:       true,
+			Description: "Select",
 			Handler: func(event *tcell.EventKey) *tcell.EventKey {
+				p.moveSelection(-1)
+				return nil
+			},
+		},
+		{
+			Key: tcell.NewEventKey(tcell.KeyDown, ' ', tcell.ModNone),
+			Handler: func(event *tcell.EventKey) *tcell.EventKey {
+				p.moveSelection(1)
+				return nil
+			},
+		},
+		{
+			Key: tcell.NewEventKey(tcell.KeyLeft, ' ', tcell.ModNone),
+			Handler: func(event *tcell.EventKey) *tcell.EventKey {
+				p.moveToCaller()
+				return nil
+			},
+		},
+		{
+			Key: tcell.NewEventKey(tcell.KeyRight, ' ', tcell.ModNone),
+			Handler: func(event *tcell.EventKey) *tcell.EventKey {
+				p.moveToCallee()
+				return nil
+			},
+		},
+		{
+			Key:         tcell.NewEventKey(tcell.KeyEnter, ' ', tcell.ModNone),
+			Description: "Show traces",
+			Handler: func(event *tcell.EventKey) *tcell.EventKey {
+				if n := p.getSelectedNode(); n != nil {
+					p.onSelect(n.Condition())
+				}
+				return nil
+			},
+		},
+		{
+			Key:         tcell.NewEventKey(tcell.KeyRune, 'f', tcell.ModNone),
+			Description: "Focus",
+			Handler: func(event *tcell.EventKey) *tcell.EventKey {
+				if p.selected != "" || p.focused {
+					p.focused = !p.focused
+					p.UpdateTopology()
+				}
+				return nil
+			},
+		},
+		{
+			Key:         tcell.NewEventKey(tcell.KeyRune, 'c', tcell.ModNone),
+			Description: "Collapse leaves",
+			Handler: func(event *tcell.EventKey) *tcell.EventKey {
+				p.collapsed = !p.collapsed
+				p.UpdateTopology()
+				return nil
+			},
+		},
+		{
+			Key:         tcell.NewEventKey(tcell.KeyRune, 'w', tcell.ModNone),
+			Description: "Window",
+			Handler: func(event *tcell.EventKey) *tcell.EventKey {
+				p.windowIdx = (p.windowIdx + 1) % len(timeWindows)
 				p.UpdateTopology()
 				return nil
 			},
 		},
+		reload,
+	})
+	layout.RegisterCommandList(commands, p.stats, nil, layout.KeyMaps{
+		{
+			Key:         tcell.NewEventKey(tcell.KeyEnter, ' ', tcell.ModNone),
+			Description: "Show traces",
+			Handler: func(event *tcell.EventKey) *tcell.EventKey {
+				row, _ := p.stats.GetSelection()
+				if cond, ok := p.getConditionByRow(row); ok {
+					p.onSelect(cond)
+				}
+				return nil
+			},
+		},
+		reload,
+	})
+}
+
+// Refresh rebuilds the topology if the store is updated after the last update. It is called
+// periodically from the event loop of the application while the page is shown.
+func (p *TopologyPage) Refresh() {
+	if p.store.UpdatedAt().After(p.refreshedAt) {
+		p.UpdateTopology()
 	}
-	layout.RegisterCommandList(commands, p.topo, nil, keyMaps)
 }
 
+// UpdateTopology rebuilds the topology of the spans in the time window and redraws the graph
+// and the table
 func (p *TopologyPage) UpdateTopology() {
 	log.Println("Updating trace topology view...")
+	p.refreshedAt = p.store.UpdatedAt()
+
+	var since time.Time
+	if w := timeWindows[p.windowIdx]; w.duration > 0 {
+		since = p.now().Add(-w.duration)
+	}
+	topo := p.store.Topology(since)
+	if p.collapsed {
+		topo = topo.CollapseLeaves()
+	}
+	if !hasNode(topo, p.selected) {
+		p.selected = ""
+		p.focused = false
+	}
+	if p.focused {
+		topo = topo.Neighbors(p.selected)
+	}
+	if p.selected == "" && len(topo.Nodes) > 0 {
+		p.selected = topo.Nodes[0].Service
+	}
+	p.topology = topo
+
+	p.updateStats()
+	p.updateTitle()
+	p.drawGraph()
+}
+
+func (p *TopologyPage) drawGraph() {
 	p.topo.SetText("Loading...")
-	graph, err := p.cache.DrawSpanDependencies()
+	graph, err := p.topology.Draw()
 	if err != nil {
 		p.topo.SetText("Failed to render the trace topology view")
 		log.Printf("Failed to render the trace topology view: %v", err)
 		return
 	}
-	if len(graph) <= 1 {
+	if len(p.topology.Nodes) == 0 || len(graph) <= 1 {
 		p.topo.SetText("No data")
 		return
 	}
-	p.topo.SetText(graph)
+	p.topo.SetText(highlightNode(tview.Escape(graph), p.selected))
+}
+
+// highlightNode shows the box of the service in the graph in reverse
+func highlightNode(graph, service string) string {
+	if service == "" {
+		return graph
+	}
+	re := regexp.MustCompile(`(│ +)(` + regexp.QuoteMeta(tview.Escape(service)) + `)( +│)`)
+	return re.ReplaceAllString(graph, "$1[::r]$2[::-]$3")
+}
+
+func (p *TopologyPage) updateTitle() {
+	parts := []string{timeWindows[p.windowIdx].label}
+	if p.focused {
+		parts = append(parts, "focused on "+p.selected)
+	}
+	if p.collapsed {
+		parts = append(parts, "leaves collapsed")
+	}
+	p.topo.SetTitle(fmt.Sprintf("Topology (g) -- %s", tview.Escape(strings.Join(parts, ", "))))
+}
+
+func hasNode(topo *telemetry.Topology, service string) bool {
+	for _, n := range topo.Nodes {
+		if n.Service == service {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *TopologyPage) selectNode(service string) {
+	p.selected = service
+	for i, n := range p.topology.Nodes {
+		if n.Service == service {
+			p.stats.Select(i+1, 0)
+			break
+		}
+	}
+	p.drawGraph()
+}
+
+// moveSelection selects the previous or next service in the order of the names
+func (p *TopologyPage) moveSelection(delta int) {
+	if p.topology == nil || len(p.topology.Nodes) == 0 {
+		return
+	}
+	idx := 0
+	for i, n := range p.topology.Nodes {
+		if n.Service == p.selected {
+			idx = i + delta
+			break
+		}
+	}
+	idx = max(0, min(idx, len(p.topology.Nodes)-1))
+	p.selectNode(p.topology.Nodes[idx].Service)
+}
+
+// moveToCaller selects the first service calling the selected service
+func (p *TopologyPage) moveToCaller() {
+	if p.topology == nil {
+		return
+	}
+	for _, e := range p.topology.Edges {
+		if e.Target == p.selected {
+			p.selectNode(e.Source)
+			return
+		}
+	}
+}
+
+// moveToCallee selects the first service called by the selected service
+func (p *TopologyPage) moveToCallee() {
+	if p.topology == nil {
+		return
+	}
+	for _, e := range p.topology.Edges {
+		if e.Source == p.selected {
+			p.selectNode(e.Target)
+			return
+		}
+	}
+}
+
+// updateStats redraws the table of the services followed by the calls between them
+func (p *TopologyPage) updateStats() {
+	p.stats.Clear()
+	for col, h := range []string{"Service / Call", "Count", "Errors", "Error Rate", "Rate", "Avg", "P95"} {
+		p.stats.SetCell(0, col, tview.NewTableCell(h).
+			SetSelectable(false).
+			SetTextColor(tcell.ColorYellow))
+	}
+
+	row := 1
+	for _, n := range p.topology.Nodes {
+		name := n.Service
+		if n.External {
+			name += " (external)"
+		}
+		p.setStatsRow(row, name, &n.TopologyStats)
+		row++
+	}
+	for _, e := range p.topology.Edges {
+		p.setStatsRow(row, e.Source+" -> "+e.Target, &e.TopologyStats)
+		row++
+	}
+
+	selected, _ := p.stats.GetSelection()
+	if selected < 1 || selected >= row {
+		selected = 1
+	}
+	p.stats.Select(selected, 0)
+}
+
+func (p *TopologyPage) setStatsRow(row int, name string, s *telemetry.TopologyStats) {
+	color := tcell.ColorDefault
+	if s.ErrorCount > 0 {
+		color = tcell.ColorRed
+	}
+	cells := []*tview.TableCell{
+		tview.NewTableCell(tview.Escape(name)),
+		tview.NewTableCell(fmt.Sprintf("%d", s.Count)).SetAlign(tview.AlignRight),
+		tview.NewTableCell(fmt.Sprintf("%d", s.ErrorCount)).SetAlign(tview.AlignRight).SetTextColor(color),
+		tview.NewTableCell(fmt.Sprintf("%.1f%%", s.ErrorRate()*100)).SetAlign(tview.AlignRight).SetTextColor(color),
+		tview.NewTableCell(fmt.Sprintf("%.2f/s", p.topology.Rate(s))).SetAlign(tview.AlignRight),
+		tview.NewTableCell(s.Average.String()).SetAlign(tview.AlignRight),
+		tview.NewTableCell(s.P95.String()).SetAlign(tview.AlignRight),
+	}
+	for col, cell := range cells {
+		p.stats.SetCell(row, col, cell)
+	}
+}
+
+// getConditionByRow returns the query condition of the traces of the service or the call in the row
+func (p *TopologyPage) getConditionByRow(row int) (string, bool) {
+	if p.topology == nil || row < 1 {
+		return "", false
+	}
+	idx := row - 1
+	if idx < len(p.topology.Nodes) {
+		return p.topology.Nodes[idx].Condition(), true
+	}
+	idx -= len(p.topology.Nodes)
+	if idx < len(p.topology.Edges) {
+		return p.topology.Edges[idx].Condition(), true
+	}
+	return "", false
+}
+
+func (p *TopologyPage) getSelectedNode() *telemetry.TopologyNode {
+	if p.topology == nil {
+		return nil
+	}
+	for _, n := range p.topology.Nodes {
+		if n.Service == p.selected {
+			return n
+		}
+	}
+	return nil
 }
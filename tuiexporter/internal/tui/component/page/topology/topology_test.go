@@ -1,13 +1,16 @@
 package topology
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/jonboulle/clockwork"
 	"github.com/rivo/tview"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/telemetry"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/test"
+	"go.opentelemetry.io/collector/pdata/ptrace"
 	"gotest.tools/v3/assert"
 )
 
@@ -30,7 +33,7 @@ func TestTopologyPage(t *testing.T) {
 		}
 		screen.SetSize(sw, sh)
 
-		page := NewTopologyPage(store.GetTraceCache())
+		page := NewTopologyPage(store, func(string) {}, nil)
 		page.view.Focus(func(p tview.Primitive) {
 			page.topo.Focus(nil)
 		})
@@ -63,6 +66,177 @@ func TestTopologyPage(t *testing.T) {
 		assert.Equal(t, want, got.String())
 	})
 
+	t.Run("select service and call", func(t *testing.T) {
+		// traceid: 1
+		//  └- resource: test-service-1
+		//    └- scope: test-scope-1-1
+		//      └- span: span-0-0-0
+		//        └- resource: test-service-2 (error)
+		//          └- scope: test-scope-2-1
+		//            └- span: span-1-0-0
+		payload, testdata := test.GenerateOTLPTracesPayload(t, 1, 2, []int{1, 1}, [][]int{{1}, {1}})
+		testdata.Spans[1].SetParentSpanID(testdata.Spans[0].SpanID())
+		testdata.Spans[1].Status().SetCode(ptrace.StatusCodeError)
+		store := telemetry.NewStore(clockwork.NewRealClock())
+		store.AddSpan(&payload)
+
+		selected := []string{}
+		page := NewTopologyPage(store, func(condition string) {
+			selected = append(selected, condition)
+		}, nil)
+		page.view.Focus(func(p tview.Primitive) {
+			page.stats.Focus(nil)
+		})
+		page.UpdateTopology()
+
+		// the services followed by the calls
+		assert.Equal(t, 4, page.stats.GetRowCount())
+		assert.Equal(t, "test-service-1", page.stats.GetCell(1, 0).Text)
+		assert.Equal(t, "test-service-2", page.stats.GetCell(2, 0).Text)
+		assert.Equal(t, "100.0%", page.stats.GetCell(2, 3).Text)
+		assert.Equal(t, "test-service-1 -> test-service-2", page.stats.GetCell(3, 0).Text)
+		assert.Equal(t, "1", page.stats.GetCell(3, 1).Text)
+		assert.Equal(t, "1", page.stats.GetCell(3, 2).Text)
+		assert.Equal(t, "200ms", page.stats.GetCell(3, 6).Text)
+
+		handler := page.view.InputHandler()
+		handler(tcell.NewEventKey(tcell.KeyEnter, ' ', tcell.ModNone), nil)
+		page.stats.Select(3, 0)
+		handler(tcell.NewEventKey(tcell.KeyEnter, ' ', tcell.ModNone), nil)
+
+		assert.DeepEqual(t, []string{
+			telemetry.ServiceCondition("test-service-1"),
+			telemetry.CallCondition("test-service-1", "test-service-2"),
+		}, selected)
+	})
+
+	t.Run("external node", func(t *testing.T) {
+		// traceid: 1
+		//  └- resource: test-service-1
+		//    └- scope: test-scope-1-1
+		//      └- span: span-0-0-0 (client, db.system: postgresql)
+		payload, testdata := test.GenerateOTLPTracesPayload(t, 1, 1, []int{1}, [][]int{{1}})
+		testdata.Spans[0].SetKind(ptrace.SpanKindClient)
+		testdata.Spans[0].Attributes().PutStr("db.system", "postgresql")
+		store := telemetry.NewStore(clockwork.NewRealClock())
+		store.AddSpan(&payload)
+
+		selected := []string{}
+		page := NewTopologyPage(store, func(condition string) {
+			selected = append(selected, condition)
+		}, nil)
+		page.view.Focus(func(p tview.Primitive) {
+			page.topo.Focus(nil)
+		})
+		page.UpdateTopology()
+
+		assert.Equal(t, "postgresql (external)", page.stats.GetCell(1, 0).Text)
+		assert.Equal(t, "test-service-1 -> postgresql", page.stats.GetCell(3, 0).Text)
+
+		handler := page.view.InputHandler()
+		handler(tcell.NewEventKey(tcell.KeyEnter, ' ', tcell.ModNone), nil)
+
+		assert.DeepEqual(t, []string{`"db.system" = "postgresql"`}, selected)
+	})
+
+	t.Run("navigate, focus, collapse and window", func(t *testing.T) {
+		// test-service-1: span-0-0-0
+		//  └- test-service-2: span-1-0-0
+		//    └- test-service-3: span-2-0-0
+		payload, testdata := test.GenerateOTLPTracesPayload(t, 1, 3, []int{1, 1, 1}, [][]int{{1}, {1}, {1}})
+		testdata.Spans[1].SetParentSpanID(testdata.Spans[0].SpanID())
+		testdata.Spans[2].SetParentSpanID(testdata.Spans[1].SpanID())
+		store := telemetry.NewStore(clockwork.NewRealClock())
+		store.AddSpan(&payload)
+
+		page := NewTopologyPage(store, func(string) {}, nil)
+		page.view.Focus(func(p tview.Primitive) {
+			page.topo.Focus(nil)
+		})
+		page.UpdateTopology()
+		handler := page.view.InputHandler()
+		press := func(key tcell.Key, ch rune) {
+			handler(tcell.NewEventKey(key, ch, tcell.ModNone), nil)
+		}
+
+		assert.Equal(t, "test-service-1", page.selected)
+
+		// callee, callee, caller, previous and next
+		for _, tt := range []struct {
+			key  tcell.Key
+			want string
+		}{
+			{key: tcell.KeyRight, want: "test-service-2"},
+			{key: tcell.KeyRight, want: "test-service-3"},
+			{key: tcell.KeyLeft, want: "test-service-2"},
+			{key: tcell.KeyUp, want: "test-service-1"},
+			{key: tcell.KeyUp, want: "test-service-1"},
+			{key: tcell.KeyDown, want: "test-service-2"},
+			{key: tcell.KeyDown, want: "test-service-3"},
+		} {
+			press(tt.key, ' ')
+			assert.Equal(t, tt.want, page.selected)
+		}
+		assert.Assert(t, strings.Contains(page.topo.GetText(false), "[::r]test-service-3[::-]"))
+		row, _ := page.stats.GetSelection()
+		assert.Equal(t, 3, row)
+
+		// focus on the service and its neighbours
+		press(tcell.KeyRune, 'f')
+		assert.Equal(t, 2, len(page.topology.Nodes))
+		assert.Equal(t, "Topology (g) -- all time, focused on test-service-3", page.topo.GetTitle())
+		press(tcell.KeyRune, 'f')
+		assert.Equal(t, 3, len(page.topology.Nodes))
+
+		// the selected leaf is collapsed
+		press(tcell.KeyRune, 'c')
+		assert.Equal(t, 2, len(page.topology.Nodes))
+		assert.Equal(t, "test-service-1", page.selected)
+		assert.Equal(t, "Topology (g) -- all time, leaves collapsed", page.topo.GetTitle())
+		press(tcell.KeyRune, 'c')
+
+		// the spans end at 2022-10-21 07:10:02.3
+		page.now = func() time.Time {
+			return time.Date(2022, 10, 21, 7, 11, 0, 0, time.UTC)
+		}
+		press(tcell.KeyRune, 'w')
+		assert.Equal(t, "Topology (g) -- last 1m", page.topo.GetTitle())
+		assert.Equal(t, 3, len(page.topology.Nodes))
+		page.now = func() time.Time {
+			return time.Date(2022, 10, 21, 7, 20, 0, 0, time.UTC)
+		}
+		press(tcell.KeyRune, 'w')
+		assert.Equal(t, "Topology (g) -- last 5m", page.topo.GetTitle())
+		assert.Equal(t, 0, len(page.topology.Nodes))
+		assert.Equal(t, "No data", page.topo.GetText(false))
+	})
+
+	t.Run("refresh on new spans", func(t *testing.T) {
+		clock := clockwork.NewFakeClock()
+		payload, _ := test.GenerateOTLPTracesPayload(t, 1, 1, []int{1}, [][]int{{1}})
+		store := telemetry.NewStore(clock)
+		store.AddSpan(&payload)
+
+		page := NewTopologyPage(store, func(string) {}, nil)
+		page.UpdateTopology()
+
+		assert.Equal(t, 2, page.stats.GetRowCount())
+
+		clock.Advance(time.Second)
+		payload, _ = test.GenerateOTLPTracesPayload(t, 2, 1, []int{1}, [][]int{{1}})
+		payload.ResourceSpans().At(0).Resource().Attributes().PutStr("service.name", "test-service-2")
+		store.AddSpan(&payload)
+		page.Refresh()
+
+		assert.Equal(t, 3, page.stats.GetRowCount())
+
+		// the topology is not rebuilt without new spans
+		page.stats.Clear()
+		page.Refresh()
+
+		assert.Equal(t, 0, page.stats.GetRowCount())
+	})
+
 	t.Run("empty render", func(t *testing.T) {
 		store := telemetry.NewStore(clockwork.NewRealClock())
 
@@ -73,7 +247,7 @@ func TestTopologyPage(t *testing.T) {
 		}
 		screen.SetSize(sw, sh)
 
-		page := NewTopologyPage(store.GetTraceCache())
+		page := NewTopologyPage(store, func(string) {}, nil)
 		page.view.Focus(func(p tview.Primitive) {
 			page.topo.Focus(nil)
 		})
@@ -11,23 +11,26 @@ import (
 )
 
 type detail struct {
-	commands      *tview.TextView
-	view          *tview.Flex
-	tree          *tview.TreeView
-	resizeManager *layout.ResizeManager
+	commands            *tview.TextView
+	view                *tview.Flex
+	tree                *tview.TreeView
+	onFilterByAttribute func(attr layout.Attribute)
+	resizeManager       *layout.ResizeManager
 }
 
 func newDetail(
 	commands *tview.TextView,
+	onFilterByAttribute func(attr layout.Attribute),
 	resizeManager *layout.ResizeManager,
 ) *detail {
 	container := tview.NewFlex().SetDirection(tview.FlexRow)
 	container.SetTitle("Details (d)").SetBorder(true)
 
 	detail := &detail{
-		commands:      commands,
-		view:          container,
-		resizeManager: resizeManager,
+		commands:            commands,
+		view:                container,
+		onFilterByAttribute: onFilterByAttribute,
+		resizeManager:       resizeManager,
 	}
 
 	detail.update(nil)
@@ -111,6 +114,16 @@ func (d *detail) updateCommands() {
 			Key:         tcell.NewEventKey(tcell.KeyEnter, ' ', tcell.ModNone),
 			Description: "Toggle folding (parent), Show full text (child)",
 		},
+		{
+			Key:         tcell.NewEventKey(tcell.KeyRune, 'f', tcell.ModNone),
+			Description: "Filter traces by attribute",
+			Handler: func(_ *tcell.EventKey) *tcell.EventKey {
+				if attr, ok := layout.GetAttribute(d.tree.GetCurrentNode()); ok && d.onFilterByAttribute != nil {
+					d.onFilterByAttribute(attr)
+				}
+				return nil
+			},
+		},
 	}
 	keyMaps.Merge(d.resizeManager.KeyMaps())
 	layout.RegisterCommandList(d.commands, d.tree, nil, keyMaps)
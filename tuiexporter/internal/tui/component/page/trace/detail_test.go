@@ -50,7 +50,7 @@ func TestDrawTreeWithServiceName(t *testing.T) {
 	}
 	screen.SetSize(sw, sh)
 
-	detail := newDetail(layout.NewCommandList(), layout.NewResizeManager(layout.ResizeDirectionHorizontal))
+	detail := newDetail(layout.NewCommandList(), nil, layout.NewResizeManager(layout.ResizeDirectionHorizontal))
 	detail.update(spans)
 
 	detail.view.SetRect(0, 0, sw, sh)
@@ -119,7 +119,7 @@ func TestDrawTreeWithoutServiceName(t *testing.T) {
 	}
 	screen.SetSize(sw, sh)
 
-	detail := newDetail(layout.NewCommandList(), layout.NewResizeManager(layout.ResizeDirectionHorizontal))
+	detail := newDetail(layout.NewCommandList(), nil, layout.NewResizeManager(layout.ResizeDirectionHorizontal))
 	detail.update(spans)
 
 	detail.view.SetRect(0, 0, sw, sh)
@@ -140,7 +140,7 @@ func TestDrawTreeWithoutSpans(t *testing.T) {
 	}
 	screen.SetSize(sw, sh)
 
-	detail := newDetail(layout.NewCommandList(), layout.NewResizeManager(layout.ResizeDirectionHorizontal))
+	detail := newDetail(layout.NewCommandList(), nil, layout.NewResizeManager(layout.ResizeDirectionHorizontal))
 	detail.update([]*telemetry.SpanData{})
 
 	detail.view.SetRect(0, 0, sw, sh)
@@ -187,7 +187,7 @@ func TestInputCaptureAfterModalClosed(t *testing.T) {
 		ScopeSpans:   testdata.SSpans[2],
 	})
 
-	detail := newDetail(layout.NewCommandList(), layout.NewResizeManager(layout.ResizeDirectionHorizontal))
+	detail := newDetail(layout.NewCommandList(), nil, layout.NewResizeManager(layout.ResizeDirectionHorizontal))
 	detail.update(spans)
 
 	handler := detail.tree.InputHandler()
@@ -205,3 +205,33 @@ func TestInputCaptureAfterModalClosed(t *testing.T) {
 	// resize key should be captured
 	assert.Nil(t, got)
 }
+
+func TestFilterByAttribute(t *testing.T) {
+	_, testdata := test.GenerateOTLPTracesPayload(t, 1, 1, []int{1}, [][]int{{1}})
+	spans := []*telemetry.SpanData{
+		{
+			Span:         testdata.Spans[0],
+			ResourceSpan: testdata.RSpans[0],
+			ScopeSpans:   testdata.SSpans[0],
+		},
+	}
+
+	got := []layout.Attribute{}
+	detail := newDetail(layout.NewCommandList(), func(attr layout.Attribute) {
+		got = append(got, attr)
+	}, layout.NewResizeManager(layout.ResizeDirectionHorizontal))
+	detail.update(spans)
+
+	capture := detail.tree.GetInputCapture()
+	key := tcell.NewEventKey(tcell.KeyRune, 'f', tcell.ModNone)
+
+	// root node is not an attribute
+	assert.Nil(t, capture(key))
+	assert.Empty(t, got)
+
+	// Resource > Attributes > resource attribute
+	resourceAttrs := detail.tree.GetRoot().GetChildren()[1].GetChildren()[2]
+	detail.tree.SetCurrentNode(resourceAttrs.GetChildren()[0])
+	assert.Nil(t, capture(key))
+	assert.Equal(t, []layout.Attribute{{Key: "resource attribute", Value: "resource attribute value"}}, got)
+}
@@ -1,58 +1,79 @@
 package trace
 
 import (
+	"fmt"
 	"log"
+	"slices"
+	"strings"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/telemetry"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/export"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/filter"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/layout"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/navigation"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/preset"
 	ctable "github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/table"
 )
 
+const (
+	serviceModeTitle = "Traces (t)"
+	traceModeTitle   = "Traces - grouped by trace (t)"
+	// maxMarkedTraces is the number of the traces to compare
+	maxMarkedTraces = 2
+)
+
 type table struct {
-	store    *telemetry.Store
-	view     *tview.Flex
-	table    *tview.Table
-	spanData *ctable.SpanDataForTable
-	filter   *filter.Filter
-	detail   *detail
+	store       *telemetry.Store
+	view        *tview.Flex
+	table       *tview.Table
+	spanData    *ctable.SpanDataForTable
+	summaryData *ctable.TraceSummaryDataForTable
+	filter      *filter.Filter
+	picker      *preset.Picker
+	detail      *detail
+	// traceMode is true when a row is a trace instead of the spans of a service in a trace
+	traceMode bool
+	// markedTraceIDs are the traces marked to compare, the oldest one first
+	markedTraceIDs []string
+	onDiffTraces   func(traceIDA, traceIDB string)
 }
 
 func newTable(
 	commands *tview.TextView,
-	onSelectTableRow func(row, column int),
+	onSelectTrace func(traceID string),
+	onDiffTraces func(traceIDA, traceIDB string),
 	store *telemetry.Store,
+	presets *telemetry.PresetStore,
 	detail *detail,
 	resizeManager *layout.ResizeManager,
 ) *table {
 	container := tview.NewFlex().SetDirection(tview.FlexRow)
-	container.SetTitle("Traces (t)").SetBorder(true)
+	container.SetTitle(serviceModeTitle).SetBorder(true)
 
 	t := tview.NewTable().
 		SetBorders(false).
 		SetSelectable(true, false).
-		SetSelectedFunc(onSelectTableRow).
 		SetFixed(1, 0)
 
 	filter := filter.NewFilter(
 		commands,
 		"Filter by service or span name (/): ",
-		func(inputConfirmed string, sortType telemetry.SortType) {
-			store.ApplyFilterTraces(inputConfirmed, sortType)
+		func(inputConfirmed string, mode telemetry.MatchMode, sortType telemetry.SortType) error {
+			return store.ApplyFilterTraces(inputConfirmed, mode, sortType)
 		},
 		func() {
 			navigation.Focus(t)
 		},
 		nil,
-		func(inputConfirmed string, sortType telemetry.SortType) {
-			store.ApplyFilterTraces(inputConfirmed, sortType)
+		func(inputConfirmed string, mode telemetry.MatchMode, sortType telemetry.SortType) error {
+			return store.ApplyFilterTraces(inputConfirmed, mode, sortType)
 		},
 	)
 
 	spanData := ctable.NewSpanDataForTable(store.GetTraceCache(), store.GetFilteredSvcSpans(), filter.SortType())
+	summaryData := ctable.NewTraceSummaryDataForTable(store.GetFilteredTraceSummaries(), filter.SortType())
 	t.SetContent(&spanData)
 	store.SetOnSpanAdded(func() {
 		if detail.tree.GetRoot() == nil {
@@ -60,15 +81,27 @@ func newTable(
 		}
 	})
 
+	picker := preset.NewPicker(commands, container, presets, telemetry.SignalTraces, filter, func() {
+		navigation.Focus(t)
+	})
+
 	stable := &table{
-		store:    store,
-		view:     container,
-		table:    t,
-		spanData: &spanData,
-		filter:   filter,
-		detail:   detail,
+		store:        store,
+		view:         container,
+		table:        t,
+		spanData:     &spanData,
+		summaryData:  &summaryData,
+		filter:       filter,
+		picker:       picker,
+		detail:       detail,
+		onDiffTraces: onDiffTraces,
 	}
 
+	t.SetSelectedFunc(func(row, _ int) {
+		if traceID := stable.getTraceIDByRow(row); traceID != "" {
+			onSelectTrace(traceID)
+		}
+	})
 	t.SetSelectionChangedFunc(stable.onSelectionChangedFunc())
 
 	container.
@@ -104,7 +137,38 @@ func (t *table) registerCommands(commands *tview.TextView, resizeManager *layout
 			Key:         tcell.NewEventKey(tcell.KeyCtrlF, ' ', tcell.ModNone),
 			Description: "Toggle full datetime",
 			Handler: func(_ *tcell.EventKey) *tcell.EventKey {
-				t.spanData.SetFullDatetime(!t.spanData.IsFullDatetime())
+				full := !t.spanData.IsFullDatetime()
+				t.spanData.SetFullDatetime(full)
+				t.summaryData.SetFullDatetime(full)
+				return nil
+			},
+		},
+		{
+			Key:         tcell.NewEventKey(tcell.KeyRune, 'g', tcell.ModNone),
+			Description: "Toggle group by trace",
+			Handler: func(_ *tcell.EventKey) *tcell.EventKey {
+				t.toggleTraceMode()
+				return nil
+			},
+		},
+		{
+			Key:         tcell.NewEventKey(tcell.KeyRune, 'm', tcell.ModNone),
+			Description: "Mark trace for diff",
+			Handler: func(_ *tcell.EventKey) *tcell.EventKey {
+				row, _ := t.table.GetSelection()
+				if traceID := t.getTraceIDByRow(row); traceID != "" {
+					t.toggleMark(traceID)
+				}
+				return nil
+			},
+		},
+		{
+			Key:         tcell.NewEventKey(tcell.KeyRune, 'D', tcell.ModNone),
+			Description: "Diff marked traces",
+			Handler: func(_ *tcell.EventKey) *tcell.EventKey {
+				if len(t.markedTraceIDs) == maxMarkedTraces {
+					t.onDiffTraces(t.markedTraceIDs[0], t.markedTraceIDs[1])
+				}
 				return nil
 			},
 		},
@@ -114,7 +178,7 @@ func (t *table) registerCommands(commands *tview.TextView, resizeManager *layout
 			Handler: func(_ *tcell.EventKey) *tcell.EventKey {
 				log.Println("Recalculate service root span")
 				row, _ := t.table.GetSelection()
-				if row == 0 {
+				if row == 0 || t.traceMode {
 					return nil
 				}
 				log.Printf("Recalculating service root spans")
@@ -133,16 +197,67 @@ func (t *table) registerCommands(commands *tview.TextView, resizeManager *layout
 			},
 		},
 	}
+	keyMaps.Merge(t.picker.KeyMaps())
+	keyMaps.Merge(export.KeyMaps(commands, t.store, telemetry.SignalTraces))
 	keyMaps.Merge(resizeManager.KeyMaps())
 	layout.RegisterCommandList(commands, t.table, nil, keyMaps)
 }
 
+// toggleTraceMode switches the rows between the spans of a service in a trace and the traces
+func (t *table) toggleTraceMode() {
+	t.traceMode = !t.traceMode
+	if t.traceMode {
+		t.table.SetContent(t.summaryData)
+	} else {
+		t.table.SetContent(t.spanData)
+	}
+	t.updateTitle()
+	t.table.Select(0, 0)
+}
+
+// toggleMark marks or unmarks the trace to compare. The oldest marked trace is unmarked if
+// the traces are already marked.
+func (t *table) toggleMark(traceID string) {
+	if idx := slices.Index(t.markedTraceIDs, traceID); idx >= 0 {
+		t.markedTraceIDs = slices.Delete(t.markedTraceIDs, idx, idx+1)
+	} else {
+		t.markedTraceIDs = append(t.markedTraceIDs, traceID)
+		if len(t.markedTraceIDs) > maxMarkedTraces {
+			t.markedTraceIDs = t.markedTraceIDs[1:]
+		}
+	}
+	t.updateTitle()
+}
+
+func (t *table) updateTitle() {
+	title := serviceModeTitle
+	if t.traceMode {
+		title = traceModeTitle
+	}
+	if len(t.markedTraceIDs) > 0 {
+		title = fmt.Sprintf("%s -- marked for diff: %s", title, strings.Join(t.markedTraceIDs, ", "))
+	}
+	t.view.SetTitle(title)
+}
+
+func (t *table) getTraceIDByRow(row int) string {
+	if t.traceMode {
+		return t.store.GetTraceIDBySummaryIdx(row - 1)
+	}
+	return t.store.GetTraceIDByFilteredIdx(row - 1)
+}
+
 func (t *table) onSelectionChangedFunc() func(row, col int) {
 	return func(row, _ int) {
 		if row == 0 {
 			return
 		}
-		spans := t.store.GetFilteredServiceSpansByIdx(row - 1)
+		var spans []*telemetry.SpanData
+		if t.traceMode {
+			spans = t.store.GetRootServiceSpansBySummaryIdx(row - 1)
+		} else {
+			spans = t.store.GetFilteredServiceSpansByIdx(row - 1)
+		}
 		if spans == nil {
 			return
 		}
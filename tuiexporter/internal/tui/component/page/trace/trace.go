@@ -1,6 +1,8 @@
 package trace
 
 import (
+	"log"
+
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/telemetry"
@@ -20,15 +22,20 @@ type TracePage struct {
 }
 
 func NewTracePage(
-	onSelectTableRow func(row, column int),
+	onSelectTrace func(traceID string),
+	onDiffTraces func(traceIDA, traceIDB string),
 	store *telemetry.Store,
+	presets *telemetry.PresetStore,
+	statusFn func() string,
 ) *TracePage {
 	commands := layout.NewCommandList()
 	container := tview.NewFlex().SetDirection(tview.FlexColumn)
 
+	trace := &TracePage{}
+
 	resizeManager := layout.NewResizeManager(layout.ResizeDirectionHorizontal)
-	detail := newDetail(commands, resizeManager)
-	table := newTable(commands, onSelectTableRow, store, detail, resizeManager)
+	detail := newDetail(commands, trace.FilterByAttribute, resizeManager)
+	table := newTable(commands, onSelectTrace, onDiffTraces, store, presets, detail, resizeManager)
 
 	resizeManager.Register(
 		container,
@@ -42,13 +49,10 @@ func NewTracePage(
 	container.AddItem(table.view, 0, defaultTableProportion, true).
 		AddItem(detail.view, 0, defaultDetailProportion, false)
 
-	trace := &TracePage{
-		view:   container,
-		table:  table,
-		detail: detail,
-	}
+	trace.table = table
+	trace.detail = detail
 
-	trace.view = layout.AttachTab(layout.AttachCommandList(commands, container), layout.PageIDTraces)
+	trace.view = layout.AttachTab(layout.AttachCommandList(commands, container), layout.PageIDTraces, statusFn)
 
 	trace.registerCommands()
 	store.RegisterOnFlushed(func() {
@@ -62,13 +66,50 @@ func (p *TracePage) GetPrimitive() tview.Primitive {
 	return p.view
 }
 
+// FilterByAttribute adds the filter of the traces having the attribute in any span
+// and focuses the table
+func (p *TracePage) FilterByAttribute(attr layout.Attribute) {
+	if err := p.table.filter.AddCondition(telemetry.TraceAttributeCondition(attr.Key, attr.Value)); err != nil {
+		log.Printf("failed to filter traces by attribute: %v", err)
+		return
+	}
+	navigation.Focus(p.table.table)
+}
+
+// FilterByOperation replaces the filter with the spans of the service and span name
+// and focuses the table
+func (p *TracePage) FilterByOperation(service, spanName string) {
+	if err := p.replaceFilter(telemetry.OperationCondition(service, spanName)); err != nil {
+		log.Printf("failed to filter traces by operation: %v", err)
+		return
+	}
+	navigation.Focus(p.table.table)
+}
+
+// FilterByCondition replaces the filter with the query condition and focuses the table
+func (p *TracePage) FilterByCondition(condition string) {
+	if err := p.replaceFilter(condition); err != nil {
+		log.Printf("failed to filter traces by condition: %v", err)
+		return
+	}
+	navigation.Focus(p.table.table)
+}
+
+// replaceFilter applies the query condition instead of the current input so that drilling down
+// into another service or operation does not narrow the previous one
+func (p *TracePage) replaceFilter(condition string) error {
+	return p.table.filter.Apply(condition, telemetry.MatchMode{}, *p.table.filter.SortType())
+}
+
 func (p *TracePage) flush() {
 	p.detail.flush()
+	p.table.markedTraceIDs = nil
+	p.table.updateTitle()
 }
 
 func (p *TracePage) registerCommands() {
 	p.view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		if !p.table.filter.View().HasFocus() {
+		if !p.table.filter.View().HasFocus() && !p.table.picker.HasFocus() {
 			switch event.Rune() {
 			case 'd':
 				navigation.Focus(p.detail.view)
@@ -13,20 +13,28 @@ import (
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/test"
 )
 
-type mockSelectTableRowHandler struct {
+type mockSelectTraceHandler struct {
 	mock.Mock
 }
 
-func (m *mockSelectTableRowHandler) Handle(row, column int) {
-	m.Called(row, column)
+func (m *mockSelectTraceHandler) Handle(traceID string) {
+	m.Called(traceID)
 }
 
-func setupTracePage(t *testing.T) (*mockSelectTableRowHandler, *TracePage, tcell.SimulationScreen, *telemetry.Store) {
+func (m *mockSelectTraceHandler) HandleDiff(traceIDA, traceIDB string) {
+	m.Called(traceIDA, traceIDB)
+}
+
+func setupTracePage(t *testing.T) (*mockSelectTraceHandler, *TracePage, tcell.SimulationScreen, *telemetry.Store) {
 	t.Helper()
 
-	mockHandler := new(mockSelectTableRowHandler)
+	mockHandler := new(mockSelectTraceHandler)
 	mockClock := clockwork.NewFakeClockAt(time.Date(2025, 11, 9, 12, 15, 0, 0, time.UTC))
 	store := telemetry.NewStore(mockClock)
+	presets, err := telemetry.NewPresetStore("")
+	if err != nil {
+		t.Fatalf("failed to create preset store: %v", err)
+	}
 
 	sw, sh := 220, 50
 	screen := tcell.NewSimulationScreen("")
@@ -35,7 +43,7 @@ func setupTracePage(t *testing.T) (*mockSelectTableRowHandler, *TracePage, tcell
 	}
 	screen.SetSize(sw, sh)
 
-	page := NewTracePage(mockHandler.Handle, store)
+	page := NewTracePage(mockHandler.Handle, mockHandler.HandleDiff, store, presets, nil)
 	page.table.table.Focus(nil)
 
 	page.view.SetRect(0, 0, sw, sh)
@@ -157,10 +165,10 @@ func TestTracePage(t *testing.T) {
 			t.Run("select table row", func(t *testing.T) {
 				mockHandler, page, screen, store := setupTracePage(t)
 
-				payload, _ := test.GenerateOTLPTracesPayload(t, 1, 1, []int{1}, [][]int{{1}})
+				payload, testdata := test.GenerateOTLPTracesPayload(t, 1, 1, []int{1}, [][]int{{1}})
 				store.AddSpan(&payload)
 
-				mockHandler.On("Handle", 1, 0).Once()
+				mockHandler.On("Handle", testdata.Spans[0].TraceID().String()).Once()
 
 				handler := page.table.view.InputHandler()
 				handler(tcell.NewEventKey(tcell.KeyEnter, ' ', tcell.ModNone), nil)
@@ -171,6 +179,82 @@ func TestTracePage(t *testing.T) {
 				mockHandler.AssertExpectations(t)
 			})
 
+			t.Run("toggle group by trace", func(t *testing.T) {
+				mockHandler, page, _, store := setupTracePage(t)
+
+				// 2 services in trace 1 and 1 service in trace 2
+				payload1, testdata1 := test.GenerateOTLPTracesPayload(t, 1, 2, []int{1, 1}, [][]int{{1}, {1}})
+				payload2, _ := test.GenerateOTLPTracesPayload(t, 2, 1, []int{1}, [][]int{{1}})
+				store.AddSpan(&payload1)
+				store.AddSpan(&payload2)
+				assert.Equal(t, 4, page.table.table.GetRowCount())
+
+				handler := page.table.view.InputHandler()
+				handler(tcell.NewEventKey(tcell.KeyRune, 'g', tcell.ModNone), nil)
+
+				assert.True(t, page.table.traceMode)
+				assert.Equal(t, "Traces - grouped by trace (t)", page.table.view.GetTitle())
+				assert.Equal(t, 3, page.table.table.GetRowCount())
+				assert.Equal(t, "test-service-1, test-service-2", page.table.table.GetCell(1, 2).Text)
+
+				mockHandler.On("Handle", testdata1.Spans[0].TraceID().String()).Once()
+				page.table.table.Select(1, 0)
+				handler(tcell.NewEventKey(tcell.KeyEnter, ' ', tcell.ModNone), nil)
+				mockHandler.AssertExpectations(t)
+
+				handler(tcell.NewEventKey(tcell.KeyRune, 'g', tcell.ModNone), nil)
+
+				assert.False(t, page.table.traceMode)
+				assert.Equal(t, "Traces (t)", page.table.view.GetTitle())
+				assert.Equal(t, 4, page.table.table.GetRowCount())
+			})
+
+			t.Run("mark traces for diff", func(t *testing.T) {
+				mockHandler, page, _, store := setupTracePage(t)
+
+				payload1, _ := test.GenerateOTLPTracesPayload(t, 1, 1, []int{1}, [][]int{{1}})
+				payload2, _ := test.GenerateOTLPTracesPayload(t, 2, 1, []int{1}, [][]int{{1}})
+				payload3, _ := test.GenerateOTLPTracesPayload(t, 3, 1, []int{1}, [][]int{{1}})
+				store.AddSpan(&payload1)
+				store.AddSpan(&payload2)
+				store.AddSpan(&payload3)
+				traceID1 := page.table.getTraceIDByRow(1)
+				traceID2 := page.table.getTraceIDByRow(2)
+				traceID3 := page.table.getTraceIDByRow(3)
+
+				handler := page.table.view.InputHandler()
+
+				// only one trace is marked
+				page.table.table.Select(1, 0)
+				handler(tcell.NewEventKey(tcell.KeyRune, 'm', tcell.ModNone), nil)
+				handler(tcell.NewEventKey(tcell.KeyRune, 'D', tcell.ModNone), nil)
+
+				assert.Equal(t, "Traces (t) -- marked for diff: "+traceID1, page.table.view.GetTitle())
+				mockHandler.AssertNotCalled(t, "HandleDiff", mock.Anything, mock.Anything)
+
+				// the oldest mark is dropped
+				page.table.table.Select(2, 0)
+				handler(tcell.NewEventKey(tcell.KeyRune, 'm', tcell.ModNone), nil)
+				page.table.table.Select(3, 0)
+				handler(tcell.NewEventKey(tcell.KeyRune, 'm', tcell.ModNone), nil)
+
+				assert.Equal(t, []string{traceID2, traceID3}, page.table.markedTraceIDs)
+
+				mockHandler.On("HandleDiff", traceID2, traceID3).Once()
+				handler(tcell.NewEventKey(tcell.KeyRune, 'D', tcell.ModNone), nil)
+				mockHandler.AssertExpectations(t)
+
+				// unmark
+				handler(tcell.NewEventKey(tcell.KeyRune, 'm', tcell.ModNone), nil)
+
+				assert.Equal(t, []string{traceID2}, page.table.markedTraceIDs)
+
+				handler(tcell.NewEventKey(tcell.KeyCtrlX, ' ', tcell.ModNone), nil)
+
+				assert.Empty(t, page.table.markedTraceIDs)
+				assert.Equal(t, "Traces (t)", page.table.view.GetTitle())
+			})
+
 			t.Run("flush", func(t *testing.T) {
 				_, page, screen, store := setupTracePage(t)
 
@@ -305,3 +389,19 @@ func TestTracePage(t *testing.T) {
 		})
 	})
 }
+
+func TestTracePageFilterByOperation(t *testing.T) {
+	_, page, _, store := setupTracePage(t)
+
+	payload, _ := test.GenerateOTLPTracesPayload(t, 1, 2, []int{1, 1}, [][]int{{1}, {1}})
+	store.AddSpan(&payload)
+
+	page.FilterByOperation("test-service-1", "span-0-0-0")
+	assert.Equal(t, 1, len(*store.GetFilteredSvcSpans()))
+
+	// drilling down into another operation replaces the previous one
+	page.FilterByOperation("test-service-2", "span-1-0-0")
+	assert.Equal(t, telemetry.OperationCondition("test-service-2", "span-1-0-0"), page.table.filter.InputConfirmed())
+	assert.Equal(t, 1, len(*store.GetFilteredSvcSpans()))
+	assert.Equal(t, "test-service-2", (*store.GetFilteredSvcSpans())[0].GetServiceName())
+}
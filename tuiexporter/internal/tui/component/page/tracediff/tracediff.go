@@ -0,0 +1,263 @@
+package tracediff
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/telemetry"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/layout"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/navigation"
+)
+
+const (
+	defaultTableProportion  = 30
+	defaultDetailProportion = 20
+)
+
+type diffRow struct {
+	diff  *telemetry.SpanDiff
+	depth int
+}
+
+type TraceDiffPage struct {
+	switchToPageFn func()
+	onEscape       func()
+	store          *telemetry.Store
+	base           *tview.Flex
+	container      *tview.Flex
+	table          *tview.Table
+	detail         *tview.TextView
+	diff           *telemetry.TraceDiff
+	rows           []diffRow
+}
+
+func NewTraceDiffPage(
+	switchToPageFn func(),
+	store *telemetry.Store,
+	onEscape func(),
+) *TraceDiffPage {
+	commands := layout.NewCommandList()
+	container := tview.NewFlex().SetDirection(tview.FlexColumn)
+
+	table := tview.NewTable().
+		SetBorders(false).
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetTitle("Trace Diff (t)").SetBorder(true)
+
+	detail := tview.NewTextView().
+		SetWrap(true).
+		SetDynamicColors(false)
+	detail.SetTitle("Details (d)").SetBorder(true)
+
+	resizeManager := layout.NewResizeManager(layout.ResizeDirectionHorizontal)
+	resizeManager.Register(
+		container,
+		table,
+		detail,
+		defaultTableProportion,
+		defaultDetailProportion,
+		commands,
+	)
+
+	container.AddItem(table, 0, defaultTableProportion, true).
+		AddItem(detail, 0, defaultDetailProportion, false)
+
+	page := &TraceDiffPage{
+		switchToPageFn: switchToPageFn,
+		onEscape:       onEscape,
+		store:          store,
+		container:      container,
+		table:          table,
+		detail:         detail,
+	}
+
+	table.SetSelectionChangedFunc(func(row, _ int) {
+		page.updateDetail(row)
+	})
+
+	page.base = layout.AttachCommandList(commands, container)
+	page.registerCommands(commands, resizeManager)
+
+	return page
+}
+
+func (p *TraceDiffPage) GetPrimitive() tview.Primitive {
+	return p.base
+}
+
+// DrawTraceDiff compares the trace A and B and shows the aligned spans
+func (p *TraceDiffPage) DrawTraceDiff(traceIDA, traceIDB string) {
+	spans := p.store.SpansByTraceIDs(traceIDA, traceIDB)
+	p.diff = telemetry.NewTraceDiff(traceIDA, spans[0], traceIDB, spans[1])
+
+	p.rows = []diffRow{}
+	var flatten func(diffs []*telemetry.SpanDiff, depth int)
+	flatten = func(diffs []*telemetry.SpanDiff, depth int) {
+		for _, sd := range diffs {
+			p.rows = append(p.rows, diffRow{diff: sd, depth: depth})
+			flatten(sd.Children, depth+1)
+		}
+	}
+	flatten(p.diff.Roots, 0)
+
+	p.drawTable()
+	p.table.Select(1, 0)
+	p.updateDetail(1)
+
+	p.switchToPageFn()
+	navigation.Focus(p.table)
+}
+
+func (p *TraceDiffPage) drawTable() {
+	p.table.Clear()
+	p.table.SetTitle(fmt.Sprintf("Trace Diff (t) -- A: %s (%s) B: %s (%s) %s",
+		p.diff.TraceIDA, p.diff.DurationA.String(),
+		p.diff.TraceIDB, p.diff.DurationB.String(),
+		formatDelta(p.diff.DurationDelta()),
+	))
+
+	for col, h := range []string{"Span", "A", "B", "Delta", "Difference"} {
+		p.table.SetCell(0, col, tview.NewTableCell(h).
+			SetSelectable(false).
+			SetTextColor(tcell.ColorYellow))
+	}
+
+	for i, r := range p.rows {
+		sd := r.diff
+		color := tcell.ColorDefault
+		if sd.HasDifference() {
+			color = tcell.ColorOrange
+		}
+		deltaText, deltaColor := "-", tcell.ColorDefault
+		if !sd.OnlyInA() && !sd.OnlyInB() {
+			deltaText = formatDelta(sd.DurationDelta)
+			if sd.DurationDelta > 0 {
+				deltaColor = tcell.ColorRed
+			} else if sd.DurationDelta < 0 {
+				deltaColor = tcell.ColorGreen
+			}
+		}
+		cells := []*tview.TableCell{
+			tview.NewTableCell(tview.Escape(fmt.Sprintf("%s%s: %s", strings.Repeat("  ", r.depth), sd.ServiceName, sd.SpanName))).SetTextColor(color),
+			tview.NewTableCell(spanDurationText(sd.A)),
+			tview.NewTableCell(spanDurationText(sd.B)),
+			tview.NewTableCell(deltaText).SetTextColor(deltaColor),
+			tview.NewTableCell(tview.Escape(differenceText(sd))).SetTextColor(color),
+		}
+		for col, cell := range cells {
+			p.table.SetCell(i+1, col, cell)
+		}
+	}
+}
+
+func (p *TraceDiffPage) updateDetail(row int) {
+	if p.diff == nil {
+		return
+	}
+	d := p.diff
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "A: %s (%s)\n", d.TraceIDA, d.DurationA.String())
+	fmt.Fprintf(&b, "B: %s (%s)\n", d.TraceIDB, d.DurationB.String())
+	fmt.Fprintf(&b, "delta: %s\n", formatDelta(d.DurationDelta()))
+	fmt.Fprintf(&b, "spans only in A: %d, only in B: %d, changed: %d\n", d.SpansOnlyInA, d.SpansOnlyInB, d.SpansChanged)
+
+	if row > 0 && row <= len(p.rows) {
+		sd := p.rows[row-1].diff
+		fmt.Fprintf(&b, "\n%s: %s\n", sd.ServiceName, sd.SpanName)
+		for _, s := range []struct {
+			name string
+			span *telemetry.SpanData
+		}{{"A", sd.A}, {"B", sd.B}} {
+			if s.span == nil {
+				fmt.Fprintf(&b, "%s: (none)\n", s.name)
+				continue
+			}
+			fmt.Fprintf(&b, "%s: span id %s, duration %s, status %s\n",
+				s.name,
+				s.span.Span.SpanID().String(),
+				spanDurationText(s.span),
+				s.span.Span.Status().Code().String(),
+			)
+		}
+		if len(sd.ChangedAttributes) > 0 {
+			b.WriteString("changed attributes:\n")
+			for _, ad := range sd.ChangedAttributes {
+				fmt.Fprintf(&b, "  %s: %q -> %q\n", ad.Key, ad.ValueA, ad.ValueB)
+			}
+		}
+	}
+
+	p.detail.SetText(b.String())
+}
+
+func (p *TraceDiffPage) registerCommands(commands *tview.TextView, resizeManager *layout.ResizeManager) {
+	keyMaps := layout.KeyMaps{
+		{
+			Key: tcell.NewEventKey(tcell.KeyRune, 'd', tcell.ModNone),
+			Handler: func(event *tcell.EventKey) *tcell.EventKey {
+				navigation.Focus(p.detail)
+				return nil
+			},
+		},
+		{
+			Key: tcell.NewEventKey(tcell.KeyRune, 't', tcell.ModNone),
+			Handler: func(event *tcell.EventKey) *tcell.EventKey {
+				navigation.Focus(p.table)
+				return nil
+			},
+		},
+		{
+			Key: tcell.NewEventKey(tcell.KeyEsc, ' ', tcell.ModNone),
+			Handler: func(event *tcell.EventKey) *tcell.EventKey {
+				p.onEscape()
+				return nil
+			},
+		},
+	}
+	layout.RegisterCommandList(commands, p.container, nil, keyMaps)
+	layout.RegisterCommandList(commands, p.table, nil, resizeManager.KeyMaps())
+	layout.RegisterCommandList(commands, p.detail, nil, resizeManager.KeyMaps())
+}
+
+func spanDurationText(sd *telemetry.SpanData) string {
+	if sd == nil {
+		return "-"
+	}
+	return sd.Span.EndTimestamp().AsTime().Sub(sd.Span.StartTimestamp().AsTime()).String()
+}
+
+func differenceText(sd *telemetry.SpanDiff) string {
+	switch {
+	case sd.OnlyInA():
+		return "only in A"
+	case sd.OnlyInB():
+		return "only in B"
+	}
+	diffs := []string{}
+	if sd.StatusChanged {
+		diffs = append(diffs, fmt.Sprintf("status: %s -> %s",
+			sd.A.Span.Status().Code().String(),
+			sd.B.Span.Status().Code().String(),
+		))
+	}
+	if len(sd.ChangedAttributes) > 0 {
+		keys := make([]string, len(sd.ChangedAttributes))
+		for i, ad := range sd.ChangedAttributes {
+			keys[i] = ad.Key
+		}
+		diffs = append(diffs, "attributes: "+strings.Join(keys, ", "))
+	}
+	return strings.Join(diffs, ", ")
+}
+
+func formatDelta(d time.Duration) string {
+	if d > 0 {
+		return "+" + d.String()
+	}
+	return d.String()
+}
@@ -0,0 +1,76 @@
+package tracediff
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/mock"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/telemetry"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/test"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"gotest.tools/v3/assert"
+)
+
+type mockTraceDiffHandler struct {
+	mock.Mock
+}
+
+func (m *mockTraceDiffHandler) switchToPageHandler() {
+	m.Called()
+}
+
+func (m *mockTraceDiffHandler) onEscapeHandler() {
+	m.Called()
+}
+
+func TestTraceDiffPage(t *testing.T) {
+	mockHandler := new(mockTraceDiffHandler)
+	store := telemetry.NewStore(clockwork.NewFakeClockAt(time.Date(2025, 11, 9, 12, 15, 0, 0, time.UTC)))
+
+	// trace 1 has span-0-0-0 and span-0-0-1, and trace 2 has span-0-0-0 with an error
+	payloadA, testdataA := test.GenerateOTLPTracesPayload(t, 1, 1, []int{1}, [][]int{{2}})
+	payloadB, testdataB := test.GenerateOTLPTracesPayload(t, 2, 1, []int{1}, [][]int{{1}})
+	testdataB.Spans[0].Status().SetCode(ptrace.StatusCodeError)
+	store.AddSpan(&payloadA)
+	store.AddSpan(&payloadB)
+	traceIDA := testdataA.Spans[0].TraceID().String()
+	traceIDB := testdataB.Spans[0].TraceID().String()
+
+	page := NewTraceDiffPage(mockHandler.switchToPageHandler, store, mockHandler.onEscapeHandler)
+
+	mockHandler.On("switchToPageHandler").Return().Once()
+
+	page.DrawTraceDiff(traceIDA, traceIDB)
+
+	assert.Equal(t, 3, page.table.GetRowCount())
+	assert.Equal(t, "test-service-1: span-0-0-0", page.table.GetCell(1, 0).Text)
+	assert.Equal(t, "200ms", page.table.GetCell(1, 1).Text)
+	assert.Equal(t, "200ms", page.table.GetCell(1, 2).Text)
+	assert.Equal(t, "0s", page.table.GetCell(1, 3).Text)
+	assert.Equal(t, "status: Ok -> Error", page.table.GetCell(1, 4).Text)
+	assert.Equal(t, "test-service-1: span-0-0-1", page.table.GetCell(2, 0).Text)
+	assert.Equal(t, "-", page.table.GetCell(2, 2).Text)
+	assert.Equal(t, "-", page.table.GetCell(2, 3).Text)
+	assert.Equal(t, "only in A", page.table.GetCell(2, 4).Text)
+
+	detail := page.detail.GetText(false)
+	assert.Assert(t, strings.Contains(detail, "spans only in A: 1, only in B: 0, changed: 1"))
+	assert.Assert(t, strings.Contains(detail, "B: span id 0100000000000000, duration 200ms, status Error"))
+
+	page.table.Select(2, 0)
+
+	assert.Assert(t, strings.Contains(page.detail.GetText(false), "B: (none)"))
+
+	t.Run("escape", func(t *testing.T) {
+		mockHandler.On("onEscapeHandler").Return().Once()
+
+		page.table.Focus(nil)
+		handler := page.base.InputHandler()
+		handler(tcell.NewEventKey(tcell.KeyEscape, ' ', tcell.ModNone), nil)
+
+		mockHandler.AssertExpectations(t)
+	})
+}
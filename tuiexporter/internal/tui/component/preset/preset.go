@@ -0,0 +1,200 @@
+package preset
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/telemetry"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/filter"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/layout"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/navigation"
+)
+
+const maxListHeight = 10
+
+// Picker shows the filter presets of the signal below the table to apply one of them
+// to the filter, and saves the current filter as a preset.
+type Picker struct {
+	container *tview.Flex
+	list      *tview.List
+	input     *tview.InputField
+	presets   *telemetry.PresetStore
+	shown     []telemetry.Preset
+	signal    telemetry.Signal
+	filter    *filter.Filter
+	onDoneFn  func()
+}
+
+// NewPicker creates a new picker shown in the container of the table
+func NewPicker(
+	commands *tview.TextView,
+	container *tview.Flex,
+	presets *telemetry.PresetStore,
+	signal telemetry.Signal,
+	filter *filter.Filter,
+	onDoneFn func(),
+) *Picker {
+	list := tview.NewList().
+		ShowSecondaryText(false).
+		SetHighlightFullLine(true)
+	list.SetBorder(true).SetTitle("Presets")
+
+	input := tview.NewInputField().
+		SetLabel("Save filter as preset: ").
+		SetFieldWidth(20)
+
+	p := &Picker{
+		container: container,
+		list:      list,
+		input:     input,
+		presets:   presets,
+		signal:    signal,
+		filter:    filter,
+		onDoneFn:  onDoneFn,
+	}
+
+	list.SetSelectedFunc(func(i int, _, _ string, _ rune) {
+		p.apply(i)
+	})
+	list.SetDoneFunc(p.hide)
+	input.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEnter {
+			p.save(input.GetText())
+		}
+		p.hide()
+	})
+
+	layout.RegisterCommandList(commands, list, nil, layout.KeyMaps{
+		{
+			Key:         tcell.NewEventKey(tcell.KeyEsc, ' ', tcell.ModNone),
+			Description: "Cancel",
+		},
+		{
+			Key:         tcell.NewEventKey(tcell.KeyEnter, ' ', tcell.ModNone),
+			Description: "Apply preset",
+		},
+	})
+	layout.RegisterCommandList(commands, input, nil, layout.KeyMaps{
+		{
+			Key:         tcell.NewEventKey(tcell.KeyEsc, ' ', tcell.ModNone),
+			Description: "Cancel",
+		},
+		{
+			Key:         tcell.NewEventKey(tcell.KeyEnter, ' ', tcell.ModNone),
+			Description: "Save",
+		},
+	})
+
+	return p
+}
+
+// KeyMaps returns the key maps to open the preset list (p) and to save the current
+// filter as a preset (P)
+func (p *Picker) KeyMaps() layout.KeyMaps {
+	return layout.KeyMaps{
+		{
+			Key:         tcell.NewEventKey(tcell.KeyRune, 'p', tcell.ModNone),
+			Description: "Presets",
+			Handler: func(_ *tcell.EventKey) *tcell.EventKey {
+				p.ShowList()
+				return nil
+			},
+		},
+		{
+			Key:         tcell.NewEventKey(tcell.KeyRune, 'P', tcell.ModNone),
+			Description: "Save preset",
+			Handler: func(_ *tcell.EventKey) *tcell.EventKey {
+				p.ShowInput()
+				return nil
+			},
+		},
+	}
+}
+
+// ShowList shows the presets of the signal
+func (p *Picker) ShowList() {
+	p.shown = p.presets.List(p.signal)
+	if len(p.shown) == 0 {
+		log.Printf("No %s presets saved", p.signal)
+		return
+	}
+
+	p.list.Clear()
+	for _, preset := range p.shown {
+		p.list.AddItem(tview.Escape(Describe(preset)), "", 0, nil)
+	}
+	p.container.RemoveItem(p.list).RemoveItem(p.input)
+	p.container.AddItem(p.list, min(len(p.shown), maxListHeight)+2, 0, true)
+	navigation.Focus(p.list)
+}
+
+// ShowInput shows the input of the name to save the current filter as a preset
+func (p *Picker) ShowInput() {
+	p.input.SetText("")
+	p.container.RemoveItem(p.list).RemoveItem(p.input)
+	p.container.AddItem(p.input, 1, 0, true)
+	navigation.Focus(p.input)
+}
+
+// HasFocus returns true if the preset list or the input has focus
+func (p *Picker) HasFocus() bool {
+	return p.list.HasFocus() || p.input.HasFocus()
+}
+
+func (p *Picker) apply(i int) {
+	if i >= 0 && i < len(p.shown) {
+		preset := p.shown[i]
+		if err := p.filter.Apply(preset.Query, preset.MatchMode(), preset.GetSortType()); err != nil {
+			log.Printf("Failed to apply the preset %s: %v", preset.Name, err)
+		}
+	}
+	p.hide()
+}
+
+func (p *Picker) save(name string) {
+	mode := p.filter.Mode()
+	preset := telemetry.Preset{
+		Name:       strings.TrimSpace(name),
+		Signal:     p.signal,
+		Query:      p.filter.InputConfirmed(),
+		Regex:      mode.Regex,
+		IgnoreCase: mode.IgnoreCase,
+	}
+	if sortType := *p.filter.SortType(); p.signal == telemetry.SignalTraces && !sortType.IsNone() {
+		preset.SortType = sortType
+	}
+	if err := p.presets.Save(preset); err != nil {
+		log.Printf("Failed to save the preset %s: %v", preset.Name, err)
+		return
+	}
+	log.Printf("Saved the preset %s", preset.Name)
+}
+
+func (p *Picker) hide() {
+	p.container.RemoveItem(p.list).RemoveItem(p.input)
+	if p.onDoneFn != nil {
+		p.onDoneFn()
+	}
+}
+
+// Describe returns the text of the preset shown in the list (e.g. "errors: status = error [regex, latency-desc]")
+func Describe(preset telemetry.Preset) string {
+	options := []string{}
+	if preset.Regex {
+		options = append(options, "regex")
+	}
+	if preset.IgnoreCase {
+		options = append(options, "ignore case")
+	}
+	if sortType := preset.GetSortType(); !sortType.IsNone() {
+		options = append(options, string(sortType))
+	}
+	text := fmt.Sprintf("%s: %s", preset.Name, preset.Query)
+	if len(options) > 0 {
+		text = fmt.Sprintf("%s [%s]", text, strings.Join(options, ", "))
+	}
+	return text
+}
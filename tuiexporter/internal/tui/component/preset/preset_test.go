@@ -0,0 +1,101 @@
+package preset
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/telemetry"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/filter"
+	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/tui/component/layout"
+)
+
+func TestPicker(t *testing.T) {
+	presets, err := telemetry.NewPresetStore("")
+	require.NoError(t, err)
+
+	applied := []string{}
+	f := filter.NewFilter(
+		layout.NewCommandList(),
+		"test input: ",
+		func(inputConfirmed string, _ telemetry.MatchMode, _ telemetry.SortType) error {
+			applied = append(applied, inputConfirmed)
+			return nil
+		},
+		nil,
+		nil,
+		nil,
+	)
+	container := tview.NewFlex().SetDirection(tview.FlexRow)
+	doneCount := 0
+	picker := NewPicker(layout.NewCommandList(), container, presets, telemetry.SignalTraces, f, func() {
+		doneCount++
+	})
+	enter := tcell.NewEventKey(tcell.KeyEnter, ' ', tcell.ModNone)
+
+	// no presets to show
+	picker.ShowList()
+	assert.Equal(t, 0, container.GetItemCount())
+
+	// save the current filter
+	mode := telemetry.MatchMode{Regex: true}
+	require.NoError(t, f.Apply("status = error", mode, telemetry.SORT_TYPE_LATENCY_DESC))
+	picker.ShowInput()
+	assert.Equal(t, 1, container.GetItemCount())
+	picker.input.SetText("errors")
+	picker.input.Focus(nil)
+	picker.input.InputHandler()(enter, nil)
+
+	assert.Equal(t, 0, container.GetItemCount())
+	assert.Equal(t, 1, doneCount)
+	got, ok := presets.Get(telemetry.SignalTraces, "errors")
+	assert.True(t, ok)
+	assert.Equal(t, telemetry.Preset{
+		Name:     "errors",
+		Signal:   telemetry.SignalTraces,
+		Query:    "status = error",
+		Regex:    true,
+		SortType: telemetry.SORT_TYPE_LATENCY_DESC,
+	}, got)
+
+	// apply the preset
+	require.NoError(t, f.Apply("", telemetry.MatchMode{}, telemetry.SORT_TYPE_NONE))
+	picker.ShowList()
+	assert.Equal(t, 1, container.GetItemCount())
+	picker.list.Focus(nil)
+	picker.list.InputHandler()(enter, nil)
+
+	assert.Equal(t, 0, container.GetItemCount())
+	assert.Equal(t, 2, doneCount)
+	assert.Equal(t, "status = error", f.InputConfirmed())
+	assert.Equal(t, mode, f.Mode())
+	assert.Equal(t, telemetry.SORT_TYPE_LATENCY_DESC, *f.SortType())
+	assert.Equal(t, []string{"status = error", "", "status = error"}, applied)
+}
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		name   string
+		preset telemetry.Preset
+		want   string
+	}{
+		{
+			name:   "query only",
+			preset: telemetry.Preset{Name: "api", Query: "service.name = api"},
+			want:   "api: service.name = api",
+		},
+		{
+			name:   "with options",
+			preset: telemetry.Preset{Name: "errors", Query: "error", Regex: true, IgnoreCase: true, SortType: telemetry.SORT_TYPE_LATENCY_ASC},
+			want:   "errors: error [regex, ignore case, latency-asc]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Describe(tt.preset))
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package table
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 	"github.com/ymtdzzz/otel-tui/tuiexporter/internal/telemetry"
@@ -139,3 +142,157 @@ func (s SpanDataForTable) getHeaderCell(column int, sortType telemetry.SortType)
 
 	return cell
 }
+
+var defaultTraceSummaryCellMappers = cellMappers[telemetry.TraceSummary]{
+	1: {
+		header: "Root Span",
+		getTextRowFn: func(data *telemetry.TraceSummary) string {
+			if data.RootSpanName == "" {
+				return ""
+			}
+			return data.RootServiceName + ": " + data.RootSpanName
+		},
+	},
+	2: {
+		header: "Services",
+		getTextRowFn: func(data *telemetry.TraceSummary) string {
+			return strings.Join(data.Services, ", ")
+		},
+	},
+	3: {
+		header: "Spans",
+		getTextRowFn: func(data *telemetry.TraceSummary) string {
+			return strconv.Itoa(data.SpanCount)
+		},
+	},
+	4: {
+		header: "Errors",
+		getTextRowFn: func(data *telemetry.TraceSummary) string {
+			return strconv.Itoa(data.ErrorCount)
+		},
+	},
+	5: {
+		header: "Latency",
+		getTextRowFn: func(data *telemetry.TraceSummary) string {
+			return data.GetDurationText()
+		},
+	},
+	6: {
+		header: "Start At",
+		getTextRowFn: func(data *telemetry.TraceSummary) string {
+			panic("Start At column should be overridden")
+		},
+	},
+	7: {
+		header: "Status",
+		getTextRowFn: func(data *telemetry.TraceSummary) string {
+			if data.Incomplete {
+				return "incomplete"
+			}
+			return "complete"
+		},
+	},
+}
+
+// TraceSummaryDataForTable is a wrapper for trace summaries to be displayed in a table.
+type TraceSummaryDataForTable struct {
+	tview.TableContentReadOnly
+	summaries      *[]telemetry.TraceSummary
+	sortType       *telemetry.SortType
+	mapper         cellMappers[telemetry.TraceSummary]
+	isFullDatetime bool
+}
+
+// NewTraceSummaryDataForTable creates a new TraceSummaryDataForTable.
+func NewTraceSummaryDataForTable(summaries *[]telemetry.TraceSummary, sortType *telemetry.SortType) TraceSummaryDataForTable {
+	t := TraceSummaryDataForTable{
+		summaries: summaries,
+		sortType:  sortType,
+		mapper:    cellMappers[telemetry.TraceSummary]{},
+	}
+	for k, m := range defaultTraceSummaryCellMappers {
+		copied := *m
+		t.mapper[k] = &copied
+	}
+	t.updateStartAtMapper()
+
+	return t
+}
+
+// SetFullDatetime sets the full datetime flag for the table.
+func (t *TraceSummaryDataForTable) SetFullDatetime(full bool) {
+	t.isFullDatetime = full
+	t.updateStartAtMapper()
+}
+
+// IsFullDatetime returns the full datetime flag for the table.
+func (t TraceSummaryDataForTable) IsFullDatetime() bool {
+	return t.isFullDatetime
+}
+
+func (t *TraceSummaryDataForTable) updateStartAtMapper() {
+	for _, m := range t.mapper {
+		if m.header == "Start At" {
+			m.getTextRowFn = func(data *telemetry.TraceSummary) string {
+				return data.GetStartTimeText(t.isFullDatetime)
+			}
+			break
+		}
+	}
+}
+
+// implementations for tview Virtual Table
+// see: https://github.com/rivo/tview/wiki/VirtualTable
+func (t TraceSummaryDataForTable) GetCell(row, column int) *tview.TableCell {
+	if row == 0 {
+		return t.getHeaderCell(column, *t.sortType)
+	}
+	if row > 0 && row <= len(*t.summaries) {
+		ts := &(*t.summaries)[row-1]
+		if column == 0 {
+			return t.getErrorIndicator(ts)
+		}
+		return getCellFromData(t.mapper, ts, column)
+	}
+	return tview.NewTableCell("N/A")
+}
+
+func (t TraceSummaryDataForTable) GetRowCount() int {
+	return len(*t.summaries) + 1
+}
+
+func (t TraceSummaryDataForTable) GetColumnCount() int {
+	return len(t.mapper) + 1 // including error indicator
+}
+
+func (t TraceSummaryDataForTable) getErrorIndicator(summary *telemetry.TraceSummary) *tview.TableCell {
+	text := ""
+	if summary.ErrorCount > 0 {
+		text = "[!]"
+	}
+	return tview.NewTableCell(text)
+}
+
+func (t TraceSummaryDataForTable) getHeaderCell(column int, sortType telemetry.SortType) *tview.TableCell {
+	cell := tview.NewTableCell("N/A").
+		SetSelectable(false).
+		SetTextColor(tcell.ColorYellow)
+	h, ok := t.mapper[column]
+	if !ok {
+		if column == 0 {
+			cell.SetText(" ") // Error indicator
+		}
+		return cell
+	}
+	if !sortType.IsNone() && sortType.GetHeaderLabel() == h.header {
+		if sortType.IsDesc() {
+			cell.SetText(h.header + " ▼")
+		} else {
+			cell.SetText(h.header + " ▲")
+		}
+		return cell
+	}
+	cell.SetText(h.header)
+
+	return cell
+}
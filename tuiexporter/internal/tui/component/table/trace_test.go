@@ -197,3 +197,168 @@ func TestSpanDataForTable(t *testing.T) {
 		})
 	})
 }
+
+func TestTraceSummaryDataForTable(t *testing.T) {
+	startAt := time.Date(2024, 3, 30, 12, 30, 15, 0, time.UTC)
+	summaries := &[]telemetry.TraceSummary{
+		{
+			TraceID:         "01000000000000000000000000000000",
+			RootSpanName:    "span-0-0-0",
+			RootServiceName: "test-service-1",
+			StartTime:       startAt,
+			EndTime:         startAt.Add(200 * time.Millisecond),
+			Duration:        200 * time.Millisecond,
+			SpanCount:       3,
+			Services:        []string{"test-service-1", "test-service-2"},
+			ErrorCount:      1,
+		},
+		{
+			TraceID:    "02000000000000000000000000000000",
+			StartTime:  startAt,
+			EndTime:    startAt.Add(time.Second),
+			Duration:   time.Second,
+			SpanCount:  1,
+			Services:   []string{"test-service-1"},
+			Incomplete: true,
+		},
+	}
+	sortType := telemetry.SORT_TYPE_NONE
+	tstable := NewTraceSummaryDataForTable(summaries, &sortType)
+
+	t.Run("GetRowCount", func(t *testing.T) {
+		assert.Equal(t, 3, tstable.GetRowCount()) // including header row
+	})
+
+	t.Run("GetColumnCount", func(t *testing.T) {
+		assert.Equal(t, 8, tstable.GetColumnCount())
+	})
+
+	t.Run("GetCell_Header", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			sortType telemetry.SortType
+			column   int
+			want     string
+		}{
+			{
+				name:     "N/A",
+				sortType: telemetry.SORT_TYPE_NONE,
+				column:   8,
+				want:     "N/A",
+			},
+			{
+				name:     "Latency Desc",
+				sortType: telemetry.SORT_TYPE_LATENCY_DESC,
+				column:   5,
+				want:     "Latency ▼",
+			},
+			{
+				name:     "Root Span no effect",
+				sortType: telemetry.SORT_TYPE_LATENCY_DESC,
+				column:   1,
+				want:     "Root Span",
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				sortType = tt.sortType
+				assert.Equal(t, tt.want, tstable.GetCell(0, tt.column).Text)
+			})
+		}
+	})
+
+	t.Run("GetCell_Body", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			row    int
+			column int
+			want   string
+		}{
+			{
+				name:   "invalid row",
+				row:    2,
+				column: 1,
+				want:   "N/A",
+			},
+			{
+				name:   "has error trace 1",
+				row:    0,
+				column: 0,
+				want:   "[!]",
+			},
+			{
+				name:   "has no errors trace 2",
+				row:    1,
+				column: 0,
+				want:   "",
+			},
+			{
+				name:   "root span trace 1",
+				row:    0,
+				column: 1,
+				want:   "test-service-1: span-0-0-0",
+			},
+			{
+				name:   "root span not received trace 2",
+				row:    1,
+				column: 1,
+				want:   "N/A",
+			},
+			{
+				name:   "services trace 1",
+				row:    0,
+				column: 2,
+				want:   "test-service-1, test-service-2",
+			},
+			{
+				name:   "span count trace 1",
+				row:    0,
+				column: 3,
+				want:   "3",
+			},
+			{
+				name:   "error count trace 1",
+				row:    0,
+				column: 4,
+				want:   "1",
+			},
+			{
+				name:   "latency trace 2",
+				row:    1,
+				column: 5,
+				want:   "1s",
+			},
+			{
+				name:   "start at trace 1",
+				row:    0,
+				column: 6,
+				want:   datetime.GetSimpleTime(startAt.Local()),
+			},
+			{
+				name:   "status trace 1",
+				row:    0,
+				column: 7,
+				want:   "complete",
+			},
+			{
+				name:   "status trace 2",
+				row:    1,
+				column: 7,
+				want:   "incomplete",
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				assert.Equal(t, tt.want, tstable.GetCell(tt.row+1, tt.column).Text)
+			})
+		}
+
+		t.Run("full datetime", func(t *testing.T) {
+			tstable.SetFullDatetime(true)
+			defer tstable.SetFullDatetime(false)
+			assert.Equal(t, datetime.GetFullTime(startAt.Local()), tstable.GetCell(1, 6).Text)
+		})
+	})
+}
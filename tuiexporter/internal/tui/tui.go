@@ -27,7 +27,7 @@ type TUIApp struct {
 }
 
 // NewTUIApp creates a new TUI application.
-func NewTUIApp(store *telemetry.Store, initialInterval time.Duration, debugLogFilePath string) (*TUIApp, error) {
+func NewTUIApp(store *telemetry.Store, presets *telemetry.PresetStore, initialInterval time.Duration, debugLogFilePath string) (*TUIApp, error) {
 	var (
 		logFile *os.File
 		err     error
@@ -48,7 +48,7 @@ func NewTUIApp(store *telemetry.Store, initialInterval time.Duration, debugLogFi
 
 	log.Println("=== otel-tui exporter initialized ===")
 
-	tpages := component.NewTUIPages(store, func(p tview.Primitive) {
+	tpages := component.NewTUIPages(store, presets, func(p tview.Primitive) {
 		app.SetFocus(p)
 	})
 	pages := tpages.GetPages()
@@ -115,7 +115,7 @@ func (t *TUIApp) refresh() {
 	for {
 		<-tick.C
 		if t.refreshedAt.Before(t.store.UpdatedAt()) {
-			t.app.Draw()
+			t.app.QueueUpdateDraw(t.pages.Refresh)
 			t.refreshedAt = time.Now()
 		}
 	}